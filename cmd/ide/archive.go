@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/deldim-kam/Jotnal/internal/archive"
+	"github.com/deldim-kam/Jotnal/internal/backup"
+	"github.com/deldim-kam/Jotnal/internal/database"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/store"
+)
+
+// exportArchive выгружает все записи в переносимый архив JSON
+func exportArchive(env *commandEnv, path string) int {
+	dbManager, err := env.connect()
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка при подключении к БД: %v\n", err)
+		return exitProblems
+	}
+	defer dbManager.Close()
+
+	data, err := store.New(dbManager, "").ExportData()
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка чтения данных: %v\n", err)
+		return exitProblems
+	}
+	if err := archive.Write(path, dbManager.GetVersion(), data); err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка выгрузки: %v\n", err)
+		return exitProblems
+	}
+
+	i18n.Printf("✓ Архив сохранен: %s (версия схемы %d)\n", path, dbManager.GetVersion())
+	printCounts(data.Counts())
+	fmt.Println(i18n.T("Архив не зашифрован: передавайте его только по защищенному каналу."))
+	return exitOK
+}
+
+// importArchive загружает записи из архива JSON, объединяя их с текущими
+// или заменяя ими все данные
+func importArchive(env *commandEnv, path, mode string, yes bool) int {
+	importMode := store.ImportMode(mode)
+	if importMode != store.ImportMerge && importMode != store.ImportReplace {
+		i18n.Fprintf(os.Stderr, "Неизвестный режим %q, допустимо: merge, replace\n", mode)
+		return exitUsage
+	}
+
+	manifest, data, err := archive.Read(path)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitProblems
+	}
+
+	dbManager, err := env.connect()
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка при подключении к БД: %v\n", err)
+		return exitProblems
+	}
+	defer dbManager.Close()
+
+	i18n.Printf("Архив %s от %s, версия схемы %d\n", path, i18n.DateTime(manifest.CreatedAt), manifest.SchemaVersion)
+	printCounts(data.Counts())
+	if manifest.SchemaVersion > database.LatestVersion() {
+		i18n.Printf("Архив выгружен из более новой схемы (%d > %d): поля, которых нет в этой версии, будут пропущены\n",
+			manifest.SchemaVersion, database.LatestVersion())
+	}
+
+	if importMode == store.ImportReplace && !yes {
+		details := []string{i18n.Sprintf("БД:          %s", dbManager.GetPath())}
+		if !confirmReplace(details...) {
+			return exitProblems
+		}
+	}
+
+	fmt.Println(i18n.T("\nСоздание резервной копии..."))
+	backupPath, err := backup.NewScheduler(dbManager, env.cfg.Get().Backup, nil).RunNow(backup.ReasonImport)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка резервного копирования, импорт отменен: %v\n", err)
+		return exitProblems
+	}
+	i18n.Printf("✓ Резервная копия сохранена: %s\n", backupPath)
+
+	result, err := store.New(dbManager, "").ImportData(data, importMode)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка импорта, данные не изменены: %v\n", err)
+		return exitProblems
+	}
+
+	i18n.Printf("✓ Архив загружен (режим %s)\n\n", importMode)
+	fmt.Print(result)
+	fmt.Println(i18n.T("\nРекомендуется проверить БД: jotnal db check"))
+	return exitOK
+}
+
+// printCounts выводит число записей в наборах архива
+func printCounts(counts map[string]int) {
+	for _, set := range archive.Sets {
+		fmt.Printf("  %-18s %d\n", set, counts[set])
+	}
+}
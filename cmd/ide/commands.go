@@ -0,0 +1,152 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/deldim-kam/Jotnal/internal/database"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/secret"
+)
+
+// Коды завершения подкоманд
+const (
+	exitOK       = 0 // успешно, проблем нет
+	exitProblems = 1 // ошибка или найдены проблемы
+	exitUsage    = 2 // неверные аргументы
+	exitNotFound = 3 // запись не найдена
+)
+
+// command - подкоманда командной строки (jotnal db check ...)
+type command struct {
+	name  string
+	usage []string // строки справки; переводятся при выводе, см. usageLines
+	run   func(env *commandEnv, args []string) int
+}
+
+// commands - доступные подкоманды
+var commands = []command{
+	{"db", []string{
+		"db check [--fix]                       проверить целостность БД",
+	}, runDB},
+	{"export", []string{
+		"export --plain <файл> [--force]        выгрузить БД без шифрования",
+		"export --archive <файл.zip> [--force]  выгрузить все записи в архив JSON",
+	}, runExport},
+	{"import", []string{
+		"import --plain <файл> [--yes]          заменить БД данными незашифрованного файла",
+		"import --archive <файл.zip> [--mode merge|replace] [--yes]",
+		"                                       загрузить записи из архива JSON",
+	}, runImport},
+	{"sync", []string{
+		"sync export <файл> [--full]            сохранить изменения в пакет для другого поста",
+		"sync import <файл> [--strategy field|lww] [--trust]",
+		"                                       применить пакет другого поста",
+		"sync status                            узел, доверенные узлы и конфликты",
+		"sync init                              новый идентификатор узла для копии БД",
+	}, runSync},
+	{"shift", []string{
+		"shift open|close                       открыть или закрыть смену",
+		"shift status [--format table|json|csv] открытая смена и ее журнал",
+	}, runShift},
+	{"log", []string{
+		"log <текст>|- [--severity info|warn|error]",
+		"                                       запись в журнал открытой смены",
+	}, runLog},
+	{"project", entityUsage("project", "проекты"), projectCommand.run},
+	{"employee", entityUsage("employee", "сотрудники"), employeeCommand.run},
+	{"snippet", entityUsage("snippet", "сниппеты"), snippetCommand.run},
+	{"config", []string{
+		"config validate                        проверить config.json",
+		"config show [--format table|json|csv]  действующие параметры",
+		"config set <параметр>=<значение>...    изменить параметры config.json",
+	}, runConfig},
+	{"completion", []string{
+		"completion bash|zsh|fish               скрипт дополнения команд для оболочки",
+	}, runCompletion},
+}
+
+// commandEnv - конфигурация и доступ к паролю, общие для подкоманд
+type commandEnv struct {
+	cfg       *config.Manager
+	secrets   *secret.Store
+	overrides config.Overrides
+	migrate   bool // предлагать перенос пароля из config.json
+}
+
+// connect подключается к БД так же, как при обычном запуске
+func (e *commandEnv) connect() (*database.Manager, error) {
+	return connectDatabase(e.cfg, e.secrets, e.migrate)
+}
+
+// runCommand выполняет подкоманду и возвращает код завершения
+func runCommand(args []string, overrides config.Overrides, opts secret.Options) int {
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+
+		// Перенос пароля предлагается только в команде обслуживания БД,
+		// остальные подкоманды используются в скриптах
+		env := &commandEnv{overrides: overrides, migrate: cmd.name == "db"}
+
+		// config загружает файл сам: ей нужно работать и с неверной конфигурацией
+		if cmd.name != "config" {
+			cfgManager, err := loadConfig(overrides)
+			if err != nil {
+				printConfigError(err)
+				return exitProblems
+			}
+			env.cfg = cfgManager
+			env.secrets = secret.NewStore(cfgManager, opts)
+		}
+		return cmd.run(env, args[1:])
+	}
+
+	i18n.Fprintf(os.Stderr, "Неизвестная команда: %s\n\n", args[0])
+	printCommands()
+	return exitUsage
+}
+
+// parseWithArg разбирает флаги и единственный позиционный аргумент (файл, ID),
+// который может стоять как до флагов, так и после них
+func parseWithArg(flags *flag.FlagSet, args []string) (string, bool) {
+	var arg string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		arg, args = args[0], args[1:]
+	}
+	if err := flags.Parse(args); err != nil {
+		return "", false
+	}
+	if arg == "" && flags.NArg() == 1 {
+		return flags.Arg(0), true
+	}
+	return arg, arg != "" && flags.NArg() == 0
+}
+
+// usageLines возвращает строки справки на текущем языке. Переводы сохраняют
+// столбец описаний, по нему commandSummary находит описание команды.
+func (c command) usageLines() []string {
+	lines := make([]string, len(c.usage))
+	for i, line := range c.usage {
+		lines[i] = i18n.T(line)
+	}
+	return lines
+}
+
+// printCommands выводит список подкоманд
+func printCommands() {
+	fmt.Fprintln(os.Stderr, i18n.T("Команды:"))
+	for _, cmd := range commands {
+		for _, line := range cmd.usageLines() {
+			if strings.HasPrefix(line, " ") {
+				fmt.Fprintf(os.Stderr, "         %s\n", line)
+			} else {
+				fmt.Fprintf(os.Stderr, "  jotnal %s\n", line)
+			}
+		}
+	}
+}
@@ -0,0 +1,502 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/deldim-kam/Jotnal/internal/database"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/secret"
+	"github.com/deldim-kam/Jotnal/internal/store"
+)
+
+// filesDirective - ответ __complete, по которому оболочка дополняет имена файлов
+const filesDirective = ":files"
+
+// candidate - вариант дополнения; описание показывают zsh и fish
+type candidate struct {
+	value       string
+	description string
+}
+
+// completionSpec описывает подкоманду для дополнения
+type completionSpec struct {
+	sub   map[string]completionSpec               // вложенные подкоманды
+	flags map[string]func(*completer) []candidate // флаги со значением; nil - произвольное значение
+	bools []string                                // флаги без значения
+	arg   func(*completer) []candidate            // позиционный аргумент, nil - нет
+}
+
+// Значения флагов и аргументов
+var (
+	fileArg   = func(*completer) []candidate { return []candidate{{value: filesDirective}} }
+	formatArg = values(formatTable, formatJSON, formatCSV)
+)
+
+// values возвращает постоянный список вариантов
+func values(list ...string) func(*completer) []candidate {
+	return func(*completer) []candidate {
+		candidates := make([]candidate, len(list))
+		for i, v := range list {
+			candidates[i] = candidate{value: v}
+		}
+		return candidates
+	}
+}
+
+// globalCompletion - флаги, которые указываются до подкоманды
+var globalCompletion = completionSpec{
+	flags: map[string]func(*completer) []candidate{
+		"config":        fileArg,
+		"db":            fileArg,
+		"password-file": fileArg,
+		"password-fd":   nil,
+		"ui":            values(config.UITUI, config.UIMenu, config.UINone),
+		"lang":          values(config.LanguageRU, config.LanguageEN),
+	},
+	bools: []string{"read-only"},
+}
+
+// completions - подкоманды и их флаги
+var completions = map[string]completionSpec{
+	"db": {sub: map[string]completionSpec{
+		"check": {bools: []string{"fix"}},
+	}},
+	"export": {
+		flags: map[string]func(*completer) []candidate{"plain": fileArg, "archive": fileArg},
+		bools: []string{"force"},
+	},
+	"import": {
+		flags: map[string]func(*completer) []candidate{
+			"plain":   fileArg,
+			"archive": fileArg,
+			"mode":    values("merge", "replace"),
+		},
+		bools: []string{"yes"},
+	},
+	"sync": {sub: map[string]completionSpec{
+		"export": {
+			flags: map[string]func(*completer) []candidate{"key-file": fileArg},
+			bools: []string{"full"},
+			arg:   fileArg,
+		},
+		"import": {
+			flags: map[string]func(*completer) []candidate{
+				"key-file": fileArg,
+				"strategy": values(config.SyncStrategyField, config.SyncStrategyLWW),
+			},
+			bools: []string{"trust"},
+			arg:   fileArg,
+		},
+		"status": {},
+		"init":   {bools: []string{"yes"}},
+	}},
+	"shift": {sub: map[string]completionSpec{
+		"open":   {},
+		"close":  {},
+		"status": {flags: map[string]func(*completer) []candidate{"format": formatArg}},
+	}},
+	"log": {
+		flags: map[string]func(*completer) []candidate{
+			"severity": values(store.SeverityInfo, store.SeverityWarn, store.SeverityError),
+		},
+	},
+	"config": {sub: map[string]completionSpec{
+		"validate": {},
+		"show":     {flags: map[string]func(*completer) []candidate{"format": formatArg}},
+		"set":      {},
+	}},
+	"project":    projectCommand.completion(),
+	"employee":   employeeCommand.completion(),
+	"snippet":    snippetCommand.completion(),
+	"completion": {arg: values("bash", "zsh", "fish")},
+}
+
+// completion возвращает описание подкоманд сущности для дополнения
+func (spec *entitySpec[T]) completion() completionSpec {
+	format := map[string]func(*completer) []candidate{"format": formatArg}
+	fields := map[string]func(*completer) []candidate{"format": formatArg}
+	for _, f := range spec.fields {
+		if f.set != nil {
+			fields[flagName(f.name)] = nil
+		}
+	}
+	// Руководитель выбирается из сотрудников
+	if _, ok := fields["manager-id"]; ok {
+		fields["manager-id"] = func(c *completer) []candidate {
+			return employeeCommand.candidates(c, true)
+		}
+	}
+
+	ref := func(c *completer) []candidate { return spec.candidates(c, false) }
+	return completionSpec{sub: map[string]completionSpec{
+		"list":   {flags: format},
+		"get":    {flags: format, arg: ref},
+		"add":    {flags: fields},
+		"update": {flags: fields, arg: ref},
+		"delete": {arg: ref},
+	}}
+}
+
+// candidates возвращает записи вне корзины: имена с ID в описании, а если
+// начат ввод числа или нужны только ID (onlyIDs) - ID с именем в описании
+func (spec *entitySpec[T]) candidates(c *completer, onlyIDs bool) []candidate {
+	st := c.store()
+	if st == nil {
+		return nil
+	}
+	records, err := spec.list(st)
+	if err != nil {
+		return nil
+	}
+
+	byID := onlyIDs || (c.current != "" && c.current[0] >= '0' && c.current[0] <= '9')
+	var candidates []candidate
+	seen := make(map[string]bool)
+	for i := range records {
+		id := strconv.FormatInt(spec.id(&records[i]), 10)
+		label := spec.label(&records[i])
+		if byID {
+			candidates = append(candidates, candidate{value: id, description: label})
+		} else if !seen[label] {
+			seen[label] = true
+			candidates = append(candidates, candidate{value: label, description: "#" + id})
+		}
+	}
+	return candidates
+}
+
+// completer подбирает варианты дополнения; к БД подключается только при
+// необходимости и только если пароль доступен без ввода
+type completer struct {
+	overrides config.Overrides
+	current   string // дополняемое слово
+
+	connected bool
+	db        *database.Manager
+	st        *store.Store
+}
+
+// store возвращает хранилище или nil, если БД недоступна без запроса пароля
+func (c *completer) store() *store.Store {
+	if c.connected {
+		return c.st
+	}
+	c.connected = true
+
+	// БД открывается только для чтения: дополнение не должно применять миграции
+	overrides := c.overrides
+	readOnly := true
+	overrides.ReadOnly = &readOnly
+
+	cfgManager, err := loadConfig(overrides)
+	if err != nil {
+		return nil
+	}
+	secrets := secret.NewStore(cfgManager, secret.Options{
+		PasswordFile: overrides.PasswordFile,
+		PasswordFD:   -1,
+		Prompt: func(string) (string, error) {
+			return "", i18n.Errorf("пароль недоступен при дополнении")
+		},
+	})
+	switch secrets.Mode() {
+	case config.PasswordModeFile, config.PasswordModeConfig:
+	default:
+		return nil
+	}
+
+	dbManager, err := connectDatabase(cfgManager, secrets, false)
+	if err != nil {
+		return nil
+	}
+	c.db = dbManager
+	c.st = store.New(dbManager, "")
+	return c.st
+}
+
+// close закрывает БД, если к ней подключались
+func (c *completer) close() {
+	if c.db != nil {
+		c.db.Close()
+	}
+}
+
+// Скрытая команда без справки регистрируется отдельно: она сама обращается к списку команд
+func init() {
+	commands = append(commands, command{"__complete", nil, runComplete})
+}
+
+// runComplete - скрытая команда, которую вызывают скрипты дополнения:
+// jotnal __complete <слова после jotnal, последнее - дополняемое>.
+// Выводит варианты по одному в строке, описание отделяется табуляцией.
+func runComplete(env *commandEnv, args []string) int {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+
+	c := &completer{current: args[len(args)-1]}
+	defer c.close()
+
+	for _, cand := range c.complete(args) {
+		if cand.description != "" {
+			fmt.Printf("%s\t%s\n", cand.value, cand.description)
+		} else {
+			fmt.Println(cand.value)
+		}
+	}
+	return exitOK
+}
+
+// complete возвращает варианты для последнего слова words
+func (c *completer) complete(words []string) []candidate {
+	done, current := words[:len(words)-1], words[len(words)-1]
+
+	spec := globalCompletion
+	top := true // подкоманда еще не указана
+	global := make(map[string]string)
+	var positional []string
+	pending := "" // флаг, ожидающий значение
+
+	for _, w := range done {
+		switch {
+		case w == "=":
+			// bash отделяет знак равенства в --flag=значение
+		case pending != "":
+			if top {
+				global[pending] = w
+			}
+			pending = ""
+		case strings.HasPrefix(w, "-") && len(w) > 1:
+			name, value, hasValue := strings.Cut(strings.TrimLeft(w, "-"), "=")
+			if hasValue {
+				if top {
+					global[name] = value
+				}
+			} else if _, ok := spec.flags[name]; ok {
+				pending = name
+			}
+		case top:
+			sub, ok := completions[w]
+			if !ok {
+				return nil
+			}
+			spec, top = sub, false
+		case len(positional) == 0 && spec.sub != nil:
+			sub, ok := spec.sub[w]
+			if !ok {
+				return nil
+			}
+			spec = sub
+		default:
+			positional = append(positional, w)
+		}
+	}
+
+	c.overrides = commandLineOverrides(global)
+	if current == "=" {
+		current = ""
+	}
+	c.current = current
+
+	// Значение флага: отдельным словом или после знака равенства
+	if pending != "" {
+		return c.flagValues(spec, pending, "", current)
+	}
+	if strings.HasPrefix(current, "-") {
+		if name, value, ok := strings.Cut(strings.TrimLeft(current, "-"), "="); ok {
+			c.current = value
+			return c.flagValues(spec, name, "--"+name+"=", value)
+		}
+		return filterCandidates(flagCandidates(spec), current)
+	}
+
+	switch {
+	case top:
+		return filterCandidates(commandCandidates(), current)
+	case spec.sub != nil && len(positional) == 0:
+		names := make([]string, 0, len(spec.sub))
+		for name := range spec.sub {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return filterCandidates(values(names...)(c), current)
+	case spec.arg != nil && len(positional) == 0:
+		return filterCandidates(spec.arg(c), current)
+	}
+	return nil
+}
+
+// flagValues возвращает значения флага name; prefix добавляется к каждому варианту
+func (c *completer) flagValues(spec completionSpec, name, prefix, current string) []candidate {
+	fn := spec.flags[name]
+	if fn == nil {
+		return nil
+	}
+	candidates := filterCandidates(fn(c), current)
+	if len(candidates) == 1 && candidates[0].value == filesDirective {
+		return candidates
+	}
+	for i := range candidates {
+		candidates[i].value = prefix + candidates[i].value
+	}
+	return candidates
+}
+
+// commandLineOverrides собирает параметры запуска из глобальных флагов
+// дополняемой строки и переменных окружения
+func commandLineOverrides(flags map[string]string) config.Overrides {
+	o := config.Overrides{
+		ConfigPath:   flags["config"],
+		DatabasePath: flags["db"],
+		PasswordFile: flags["password-file"],
+	}
+	env, err := config.OverridesFromEnv(os.Getenv)
+	if err != nil {
+		return o
+	}
+	return o.Over(env)
+}
+
+// flagCandidates возвращает флаги подкоманды
+func flagCandidates(spec completionSpec) []candidate {
+	var names []string
+	for name := range spec.flags {
+		names = append(names, "--"+name)
+	}
+	for _, name := range spec.bools {
+		names = append(names, "--"+name)
+	}
+	sort.Strings(names)
+	return values(names...)(nil)
+}
+
+// commandCandidates возвращает подкоманды с кратким описанием из справки
+func commandCandidates() []candidate {
+	var candidates []candidate
+	for _, cmd := range commands {
+		if len(cmd.usage) > 0 {
+			candidates = append(candidates, candidate{value: cmd.name, description: commandSummary(cmd.usageLines())})
+		}
+	}
+	return candidates
+}
+
+// commandSummary возвращает описание первой строки справки подкоманды
+func commandSummary(usage []string) string {
+	const column = 39 // столбец описаний в справке
+	if runes := []rune(usage[0]); len(runes) > column && string(runes[column-2:column]) == "  " {
+		return strings.TrimSpace(string(runes[column:]))
+	}
+	if len(usage) > 1 && strings.HasPrefix(usage[1], " ") {
+		return strings.TrimSpace(usage[1])
+	}
+	return ""
+}
+
+// filterCandidates оставляет варианты, начинающиеся с prefix.
+// Имена файлов дополняет сама оболочка, поэтому filesDirective не фильтруется.
+func filterCandidates(candidates []candidate, prefix string) []candidate {
+	if len(candidates) == 1 && candidates[0].value == filesDirective {
+		return candidates
+	}
+	var filtered []candidate
+	for _, cand := range candidates {
+		if strings.HasPrefix(cand.value, prefix) {
+			filtered = append(filtered, cand)
+		}
+	}
+	return filtered
+}
+
+// completionScripts - скрипты дополнения по названию оболочки
+var completionScripts = map[string]string{
+	"bash": bashCompletion,
+	"zsh":  zshCompletion,
+	"fish": fishCompletion,
+}
+
+// runCompletion выводит скрипт дополнения команд для оболочки
+func runCompletion(env *commandEnv, args []string) int {
+	if len(args) == 1 {
+		if script, ok := completionScripts[args[0]]; ok {
+			os.Stdout.WriteString(script)
+			return exitOK
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, i18n.T("Использование: jotnal completion bash|zsh|fish"))
+	return exitUsage
+}
+
+// bashCompletion - скрипт дополнения для bash
+const bashCompletion = `# Дополнение команд jotnal для bash.
+# Подключение: source <(jotnal completion bash)
+_jotnal() {
+    local cur=${COMP_WORDS[COMP_CWORD]} line
+    local IFS=$'\n'
+    local -a lines
+    lines=($(jotnal __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+    COMPREPLY=()
+    if [[ ${lines[0]} == ":files" ]]; then
+        [[ $cur == "=" ]] && cur=""
+        compopt -o filenames 2>/dev/null
+        COMPREPLY=($(compgen -f -- "$cur"))
+        return
+    fi
+    for line in "${lines[@]}"; do
+        COMPREPLY+=("$(printf '%q' "${line%%$'\t'*}")")
+    done
+}
+complete -F _jotnal jotnal
+`
+
+// zshCompletion - скрипт дополнения для zsh
+const zshCompletion = `#compdef jotnal
+# Дополнение команд jotnal для zsh.
+# Подключение: source <(jotnal completion zsh) или файл _jotnal в $fpath
+_jotnal() {
+  local -a lines candidates
+  local line
+  lines=("${(@f)$(jotnal __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+  if [[ ${lines[1]} == ":files" ]]; then
+    _files
+    return
+  fi
+  for line in $lines; do
+    [[ -z $line ]] && continue
+    if [[ $line == *$'\t'* ]]; then
+      candidates+=("${${line%%$'\t'*}//:/\\:}:${line#*$'\t'}")
+    else
+      candidates+=("${line//:/\\:}")
+    fi
+  done
+  _describe -t values jotnal candidates
+}
+if [[ $funcstack[1] == _jotnal ]]; then
+  _jotnal "$@"
+else
+  compdef _jotnal jotnal
+fi
+`
+
+// fishCompletion - скрипт дополнения для fish
+const fishCompletion = `# Дополнение команд jotnal для fish.
+# Подключение: jotnal completion fish | source
+function __jotnal_complete
+    set -l args (commandline -opc)
+    set -e args[1]
+    set -l cur (commandline -ct)
+    set -l out (jotnal __complete $args "$cur" 2>/dev/null)
+    if test "$out[1]" = ":files"
+        __fish_complete_path "$cur"
+        return
+    end
+    printf '%s\n' $out
+end
+complete -c jotnal -f -a '(__jotnal_complete)'
+`
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/ui"
+)
+
+// configUsage - справка по подкомандам config; переводится при выводе
+const configUsage = `Использование:
+  jotnal config validate
+  jotnal config show [--format table|json|csv]
+  jotnal config set <параметр>=<значение>...
+
+Параметры называются через точку, как в выводе show: interface.font_size=16`
+
+// runConfig проверяет, показывает и изменяет config.json. Файл загружается
+// здесь, а не в runCommand: команда должна работать и с неверным файлом.
+func runConfig(env *commandEnv, args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "validate":
+			return configValidate(env, args[1:])
+		case "show":
+			return configShow(env, args[1:])
+		case "set":
+			return configSet(env, args[1:])
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, i18n.T(configUsage))
+	return exitUsage
+}
+
+// configValidate проверяет файл конфигурации, ничего в нем не меняя
+func configValidate(env *commandEnv, args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, i18n.T(configUsage))
+		return exitUsage
+	}
+	path, err := config.ResolvePath(env.overrides.ConfigPath)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitProblems
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		i18n.Fprintf(os.Stderr, "Файл %s не найден, при запуске будет создана конфигурация по умолчанию\n", path)
+		return exitNotFound
+	}
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitProblems
+	}
+
+	cfg, version, err := config.Parse(data, filepath.Dir(path))
+	if err == nil {
+		err = ui.CheckKeyBindings(cfg.Interface.KeyBindings)
+	}
+	if err != nil {
+		fmt.Printf("✗ %s: %v\n", path, err)
+		return exitProblems
+	}
+	i18n.Printf("✓ %s: ошибок нет\n", path)
+	if version < config.CurrentVersion {
+		i18n.Printf("Файл записан в схеме версии %d, при следующем запуске он будет переведен в версию %d (исходный файл сохранится как %s.v%d.bak)\n",
+			version, config.CurrentVersion, filepath.Base(path), version)
+	}
+	return exitOK
+}
+
+// configShow выводит действующие параметры с учетом флагов запуска и
+// переменных JOTNAL_*; пароль и хеш PIN скрываются
+func configShow(env *commandEnv, args []string) int {
+	flags := flag.NewFlagSet("config show", flag.ContinueOnError)
+	format := formatFlag(flags)
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return exitUsage
+	}
+	if err := checkFormat(*format); err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitUsage
+	}
+
+	cfgManager, err := loadConfig(env.overrides)
+	if err != nil {
+		printConfigError(err)
+		return exitProblems
+	}
+	cfg := cfgManager.Get()
+
+	settings := cfg.Settings()
+	keys := make([]string, len(settings))
+	values := make([]string, len(settings))
+	for i, s := range settings {
+		keys[i] = s.Key
+		values[i] = s.Value
+		if s.Secret && s.Value != "" {
+			values[i] = "***"
+			cfg.Set(s.Key, "***")
+		}
+	}
+	return printed(printRecord(os.Stdout, *format, keys, values, cfg))
+}
+
+// configSet изменяет параметры config.json. Все значения проверяются вместе
+// с остальной конфигурацией; при ошибке файл не меняется.
+func configSet(env *commandEnv, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, i18n.T(configUsage))
+		return exitUsage
+	}
+	keys := make([]string, len(args))
+	values := make([]string, len(args))
+	for i, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			i18n.Fprintf(os.Stderr, "Ошибка: %q: ожидается <параметр>=<значение>\n", arg)
+			return exitUsage
+		}
+		keys[i], values[i] = key, value
+	}
+
+	path, err := config.ResolvePath(env.overrides.ConfigPath)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitProblems
+	}
+	var secret map[string]bool
+	err = config.EditFile(path, func(cfg *config.Config) error {
+		for i := range keys {
+			if err := cfg.Set(keys[i], values[i]); err != nil {
+				return err
+			}
+		}
+		if err := ui.CheckKeyBindings(cfg.Interface.KeyBindings); err != nil {
+			return err
+		}
+		secret = make(map[string]bool)
+		for _, s := range cfg.Settings() {
+			secret[s.Key] = s.Secret
+		}
+		return nil
+	})
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitProblems
+	}
+
+	for i := range keys {
+		value := values[i]
+		if secret[keys[i]] && value != "" {
+			value = "***"
+		}
+		fmt.Printf("✓ %s = %s\n", keys[i], value)
+	}
+	return exitOK
+}
+
+// printConfigError выводит ошибку загрузки конфигурации и подсказку, как ее исправить
+func printConfigError(err error) {
+	i18n.Fprintf(os.Stderr, "Ошибка при инициализации конфигурации: %v\n", err)
+	var invalid *config.ValidationError
+	if errors.As(err, &invalid) {
+		fmt.Fprintln(os.Stderr, i18n.T("Исправьте файл или задайте значения командой: jotnal config set <параметр>=<значение>"))
+	}
+}
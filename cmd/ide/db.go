@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/deldim-kam/Jotnal/internal/backup"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/store"
+)
+
+// runDB выполняет команды обслуживания БД
+func runDB(env *commandEnv, args []string) int {
+	if len(args) > 0 && args[0] == "check" {
+		return dbCheck(env, args[1:])
+	}
+
+	fmt.Fprintln(os.Stderr, i18n.T("Использование: jotnal db check [--fix]"))
+	return exitUsage
+}
+
+// dbCheck проверяет целостность БД и с флагом --fix исправляет то, что можно
+// исправить автоматически, предварительно создав резервную копию
+func dbCheck(env *commandEnv, args []string) int {
+	flags := flag.NewFlagSet("db check", flag.ContinueOnError)
+	fix := flags.Bool("fix", false, i18n.T("исправить проблемы, исправимые автоматически (после резервной копии)"))
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	dbManager, err := env.connect()
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка при подключении к БД: %v\n", err)
+		return exitProblems
+	}
+	defer dbManager.Close()
+
+	st := store.New(dbManager, "")
+	report, err := st.CheckIntegrity()
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка проверки: %v\n", err)
+		return exitProblems
+	}
+	fmt.Print(report)
+
+	if report.Fixable() > 0 && !*fix {
+		fmt.Println(i18n.T("Для исправления запустите: jotnal db check --fix"))
+	}
+	if report.Fixable() == 0 || !*fix {
+		return reportExitCode(report)
+	}
+
+	fmt.Println(i18n.T("\nСоздание резервной копии..."))
+	path, err := backup.NewScheduler(dbManager, env.cfg.Get().Backup, nil).RunNow(backup.ReasonRepair)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка резервного копирования, исправление отменено: %v\n", err)
+		return exitProblems
+	}
+	i18n.Printf("✓ Резервная копия сохранена: %s\n", path)
+
+	fixed, err := st.Repair()
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка исправления: %v\n", err)
+		return exitProblems
+	}
+	i18n.Printf("✓ Исправлено записей: %d\n\n", fixed)
+
+	report, err = st.CheckIntegrity()
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка проверки: %v\n", err)
+		return exitProblems
+	}
+	fmt.Print(report)
+	return reportExitCode(report)
+}
+
+// reportExitCode возвращает код завершения по результату проверки
+func reportExitCode(report *store.IntegrityReport) int {
+	if report.OK() {
+		return exitOK
+	}
+	return exitProblems
+}
@@ -0,0 +1,641 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/store"
+	"github.com/deldim-kam/Jotnal/pkg/models"
+)
+
+// dateLayout - формат даты в аргументах и выводе подкоманд
+const dateLayout = "2006-01-02"
+
+// entityField - поле записи в подкомандах project, employee и snippet
+type entityField[T any] struct {
+	name     string // имя столбца CSV и ключа JSON; флаг - то же имя через дефис
+	usage    string // описание поля; переводится при выводе
+	required bool   // обязательно при добавлении
+	column   bool   // показывать в таблице list
+	// multiline - многострочное значение: в подкомандах "-" читает его
+	// из стандартного ввода, в построчном меню оно вводится до строки "."
+	multiline bool
+	get       func(*T) string
+	set       func(*T, string) error // nil - поле только для чтения
+}
+
+// entitySpec описывает сущность для подкоманд list, get, add, update и delete
+type entitySpec[T any] struct {
+	name      string // имя подкоманды
+	title     string // название записи в сообщениях; переводится при выводе
+	fields    []entityField[T]
+	id        func(*T) int64
+	label     func(*T) string // имя, по которому запись можно указать вместо ID
+	labelName string          // название этого имени в справке; переводится при выводе
+	deleted   func(*T) bool   // запись в корзине
+	list      func(*store.Store) ([]T, error)
+	get       func(*store.Store, int64) (*T, error)
+	create    func(*store.Store, T) (int64, error)
+	update    func(*store.Store, T) error
+	remove    func(*store.Store, int64) error
+	check     func(*store.Store, *T) error // проверка связей перед записью, может быть nil
+}
+
+// usage возвращает справку по подкомандам сущности
+func (spec *entitySpec[T]) usage() string {
+	return i18n.Sprintf(`Использование:
+  jotnal %[1]s list [--format table|json|csv]
+  jotnal %[1]s get <id|%[2]s> [--format table|json|csv]
+  jotnal %[1]s add --<поле> <значение>... [--format table|json|csv]
+  jotnal %[1]s update <id|%[2]s> --<поле> <значение>... [--format table|json|csv]
+  jotnal %[1]s delete <id|%[2]s>
+
+Флаги полей: jotnal %[1]s add --help`, spec.name, i18n.T(spec.labelName))
+}
+
+// entityUsage возвращает строки справки для списка команд
+func entityUsage(name, what string) []string {
+	const indent = "                                       "
+	return []string{
+		name + " list|get [<id|имя>] [--format table|json|csv]",
+		indent + what + ": список или одна запись",
+		name + " add|update [<id|имя>] --<поле> <значение>...",
+		indent + "создать или изменить (поля: jotnal " + name + " add --help)",
+		fmt.Sprintf("%-39s%s", name+" delete <id|имя>", "переместить в корзину"),
+	}
+}
+
+// run выполняет подкоманду сущности
+func (spec *entitySpec[T]) run(env *commandEnv, args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "list":
+			return spec.runList(env, args[1:])
+		case "get":
+			return spec.runGet(env, args[1:])
+		case "add":
+			return spec.runAdd(env, args[1:])
+		case "update":
+			return spec.runUpdate(env, args[1:])
+		case "delete":
+			return spec.runDelete(env, args[1:])
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, spec.usage())
+	return exitUsage
+}
+
+// runList выводит все записи, кроме удаленных в корзину
+func (spec *entitySpec[T]) runList(env *commandEnv, args []string) int {
+	flags := flag.NewFlagSet(spec.name+" list", flag.ContinueOnError)
+	format := formatFlag(flags)
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return exitUsage
+	}
+	if err := checkFormat(*format); err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitUsage
+	}
+
+	return withStore(env, func(st *store.Store) int {
+		records, err := spec.list(st)
+		if err != nil {
+			i18n.Fprintf(os.Stderr, "Ошибка чтения: %v\n", err)
+			return exitProblems
+		}
+		if records == nil {
+			records = []T{}
+		}
+
+		// В таблице - только основные поля, в CSV и JSON - все
+		fields := spec.fields
+		if *format == formatTable {
+			fields = nil
+			for _, f := range spec.fields {
+				if f.column {
+					fields = append(fields, f)
+				}
+			}
+		}
+		rows := make([][]string, len(records))
+		for i := range records {
+			rows[i] = fieldValues(fields, &records[i])
+		}
+
+		return printed(printRows(os.Stdout, *format, fieldNames(fields), rows, records))
+	})
+}
+
+// runGet выводит запись по ID, в том числе из корзины
+func (spec *entitySpec[T]) runGet(env *commandEnv, args []string) int {
+	flags := flag.NewFlagSet(spec.name+" get", flag.ContinueOnError)
+	format := formatFlag(flags)
+	ref, ok := spec.parseRef(flags, args)
+	if !ok {
+		return exitUsage
+	}
+	if err := checkFormat(*format); err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitUsage
+	}
+
+	return withStore(env, func(st *store.Store) int {
+		record, code := spec.find(st, ref)
+		if record == nil {
+			return code
+		}
+		return spec.print(os.Stdout, *format, record)
+	})
+}
+
+// runAdd создает запись из значений флагов
+func (spec *entitySpec[T]) runAdd(env *commandEnv, args []string) int {
+	flags := flag.NewFlagSet(spec.name+" add", flag.ContinueOnError)
+	format := formatFlag(flags)
+	values := spec.fieldFlags(flags)
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return exitUsage
+	}
+	if err := checkFormat(*format); err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitUsage
+	}
+
+	var record T
+	if err := spec.apply(&record, flags, values, true); err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitUsage
+	}
+
+	return withStore(env, func(st *store.Store) int {
+		if spec.check != nil {
+			if err := spec.check(st, &record); err != nil {
+				i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+				return exitProblems
+			}
+		}
+
+		id, err := spec.create(st, record)
+		if err != nil {
+			i18n.Fprintf(os.Stderr, "Ошибка записи: %v\n", err)
+			return exitProblems
+		}
+		created, code := spec.load(st, id)
+		if created == nil {
+			return code
+		}
+		return spec.print(os.Stdout, *format, created)
+	})
+}
+
+// runUpdate изменяет поля записи, указанные флагами; остальные поля не меняются
+func (spec *entitySpec[T]) runUpdate(env *commandEnv, args []string) int {
+	flags := flag.NewFlagSet(spec.name+" update", flag.ContinueOnError)
+	format := formatFlag(flags)
+	values := spec.fieldFlags(flags)
+	ref, ok := spec.parseRef(flags, args)
+	if !ok {
+		return exitUsage
+	}
+	if err := checkFormat(*format); err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitUsage
+	}
+	if len(values) == 0 || !anyFieldSet(flags, values) {
+		fmt.Fprintln(os.Stderr, i18n.T("Укажите хотя бы одно поле для изменения"))
+		return exitUsage
+	}
+
+	return withStore(env, func(st *store.Store) int {
+		record, code := spec.find(st, ref)
+		if record == nil {
+			return code
+		}
+		id := spec.id(record)
+		if spec.deleted(record) {
+			i18n.Fprintf(os.Stderr, "%s #%d находится в корзине, восстановите запись перед изменением\n", i18n.T(spec.title), id)
+			return exitProblems
+		}
+
+		if err := spec.apply(record, flags, values, false); err != nil {
+			i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+			return exitUsage
+		}
+		if spec.check != nil {
+			if err := spec.check(st, record); err != nil {
+				i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+				return exitProblems
+			}
+		}
+
+		if err := spec.update(st, *record); err != nil {
+			i18n.Fprintf(os.Stderr, "Ошибка записи: %v\n", err)
+			return exitProblems
+		}
+		updated, code := spec.load(st, id)
+		if updated == nil {
+			return code
+		}
+		return spec.print(os.Stdout, *format, updated)
+	})
+}
+
+// runDelete перемещает запись в корзину
+func (spec *entitySpec[T]) runDelete(env *commandEnv, args []string) int {
+	flags := flag.NewFlagSet(spec.name+" delete", flag.ContinueOnError)
+	ref, ok := spec.parseRef(flags, args)
+	if !ok {
+		return exitUsage
+	}
+
+	return withStore(env, func(st *store.Store) int {
+		record, code := spec.find(st, ref)
+		if record == nil {
+			return code
+		}
+		id := spec.id(record)
+		if err := spec.remove(st, id); err != nil {
+			i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+			return exitProblems
+		}
+		i18n.Printf("✓ %s #%d перемещен в корзину\n", i18n.T(spec.title), id)
+		return exitOK
+	})
+}
+
+// parseRef разбирает флаги и ссылку на запись: ID или имя
+func (spec *entitySpec[T]) parseRef(flags *flag.FlagSet, args []string) (string, bool) {
+	arg, ok := parseWithArg(flags, args)
+	if !ok {
+		fmt.Fprintln(os.Stderr, spec.usage())
+		return "", false
+	}
+	if id, err := strconv.ParseInt(arg, 10, 64); err == nil && id <= 0 {
+		i18n.Fprintf(os.Stderr, "Неверный ID %q\n", arg)
+		return "", false
+	}
+	return arg, true
+}
+
+// find загружает запись по ID или по имени среди записей вне корзины.
+// Если имя носят несколько записей, нужно указать ID.
+func (spec *entitySpec[T]) find(st *store.Store, ref string) (*T, int) {
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		return spec.load(st, id)
+	}
+
+	records, err := spec.list(st)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка чтения: %v\n", err)
+		return nil, exitProblems
+	}
+	var found []*T
+	for i := range records {
+		if strings.EqualFold(spec.label(&records[i]), ref) {
+			found = append(found, &records[i])
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		i18n.Fprintf(os.Stderr, "%s %q не найден\n", i18n.T(spec.title), ref)
+		return nil, exitNotFound
+	case 1:
+		return found[0], exitOK
+	}
+	sort.Slice(found, func(i, j int) bool { return spec.id(found[i]) < spec.id(found[j]) })
+	ids := make([]string, len(found))
+	for i, record := range found {
+		ids[i] = fmt.Sprintf("#%d", spec.id(record))
+	}
+	i18n.Fprintf(os.Stderr, "Имя %q носят несколько записей (%s), укажите ID\n", ref, strings.Join(ids, ", "))
+	return nil, exitUsage
+}
+
+// load загружает запись; если ее нет, выводит сообщение и возвращает код завершения
+func (spec *entitySpec[T]) load(st *store.Store, id int64) (*T, int) {
+	record, err := spec.get(st, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		i18n.Fprintf(os.Stderr, "%s #%d не найден\n", i18n.T(spec.title), id)
+		return nil, exitNotFound
+	}
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка чтения: %v\n", err)
+		return nil, exitProblems
+	}
+	return record, exitOK
+}
+
+// print выводит одну запись со всеми полями
+func (spec *entitySpec[T]) print(w io.Writer, format string, record *T) int {
+	return printed(printRecord(w, format, fieldNames(spec.fields), fieldValues(spec.fields, record), record))
+}
+
+// fieldFlags добавляет флаги изменяемых полей
+func (spec *entitySpec[T]) fieldFlags(flags *flag.FlagSet) map[string]*string {
+	values := make(map[string]*string)
+	for _, f := range spec.fields {
+		if f.set == nil {
+			continue
+		}
+		usage := i18n.T(f.usage)
+		if f.multiline {
+			usage += i18n.T("; - читать из стандартного ввода")
+		}
+		if f.required {
+			usage += i18n.T(" (обязательно)")
+		}
+		values[f.name] = flags.String(flagName(f.name), "", usage)
+	}
+	return values
+}
+
+// apply записывает в запись значения указанных флагов. При добавлении
+// обязательные поля должны быть заданы, при изменении - не могут стать пустыми.
+func (spec *entitySpec[T]) apply(record *T, flags *flag.FlagSet, values map[string]*string, adding bool) error {
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	for _, f := range spec.fields {
+		if f.set == nil {
+			continue
+		}
+		name := flagName(f.name)
+		if !set[name] {
+			if adding && f.required {
+				return i18n.Errorf("не указано обязательное поле --%s", name)
+			}
+			continue
+		}
+
+		value := *values[f.name]
+		if f.multiline && value == "-" {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return i18n.Errorf("--%s: не удалось прочитать стандартный ввод: %w", name, err)
+			}
+			value = string(data)
+		}
+		if f.required && strings.TrimSpace(value) == "" {
+			return i18n.Errorf("поле --%s не может быть пустым", name)
+		}
+		if err := f.set(record, value); err != nil {
+			return fmt.Errorf("--%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// anyFieldSet сообщает, указан ли хотя бы один флаг поля
+func anyFieldSet(flags *flag.FlagSet, values map[string]*string) bool {
+	found := false
+	flags.Visit(func(f *flag.Flag) {
+		if _, ok := values[strings.ReplaceAll(f.Name, "-", "_")]; ok {
+			found = true
+		}
+	})
+	return found
+}
+
+// withStore подключается к БД и выполняет run с хранилищем записей
+func withStore(env *commandEnv, run func(st *store.Store) int) int {
+	dbManager, err := env.connect()
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка при подключении к БД: %v\n", err)
+		return exitProblems
+	}
+	defer dbManager.Close()
+
+	return run(store.New(dbManager, ""))
+}
+
+// printed переводит ошибку вывода в код завершения
+func printed(err error) int {
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка вывода: %v\n", err)
+		return exitProblems
+	}
+	return exitOK
+}
+
+// fieldNames возвращает имена полей для заголовка
+func fieldNames[T any](fields []entityField[T]) []string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.name
+	}
+	return names
+}
+
+// fieldValues возвращает значения полей записи
+func fieldValues[T any](fields []entityField[T], record *T) []string {
+	values := make([]string, len(fields))
+	for i, f := range fields {
+		values[i] = f.get(record)
+	}
+	return values
+}
+
+// flagName возвращает имя флага поля
+func flagName(field string) string {
+	return strings.ReplaceAll(field, "_", "-")
+}
+
+// formatTime форматирует время записи для вывода
+func formatTime(t time.Time) string {
+	return t.Local().Format(time.RFC3339)
+}
+
+// idField - ID записи, только для чтения
+func idField[T any](get func(*T) int64) entityField[T] {
+	return entityField[T]{name: "id", column: true, get: func(r *T) string { return strconv.FormatInt(get(r), 10) }}
+}
+
+// createdField - время создания записи
+func createdField[T any](get func(*T) time.Time, column bool) entityField[T] {
+	return entityField[T]{name: "created_at", column: column, get: func(r *T) string { return formatTime(get(r)) }}
+}
+
+// updatedField - время последнего изменения
+func updatedField[T any](get func(*T) time.Time) entityField[T] {
+	return entityField[T]{name: "updated_at", get: func(r *T) string { return formatTime(get(r)) }}
+}
+
+// deletedField - время удаления в корзину, пусто для действующих записей
+func deletedField[T any](get func(*T) *time.Time) entityField[T] {
+	return entityField[T]{name: "deleted_at", get: func(r *T) string {
+		if t := get(r); t != nil {
+			return formatTime(*t)
+		}
+		return ""
+	}}
+}
+
+// textField - изменяемое текстовое поле
+func textField[T any](name, usage string, required, column bool, ptr func(*T) *string) entityField[T] {
+	return entityField[T]{
+		name:     name,
+		usage:    usage,
+		required: required,
+		column:   column,
+		get:      func(r *T) string { return *ptr(r) },
+		set: func(r *T, value string) error {
+			*ptr(r) = strings.TrimSpace(value)
+			return nil
+		},
+	}
+}
+
+// projectCommand - подкоманды project
+var projectCommand = &entitySpec[models.Project]{
+	name:  "project",
+	title: "Проект",
+	fields: []entityField[models.Project]{
+		idField(func(p *models.Project) int64 { return p.ID }),
+		textField("name", "название", true, true, func(p *models.Project) *string { return &p.Name }),
+		textField("path", "путь к проекту", true, true, func(p *models.Project) *string { return &p.Path }),
+		textField("description", "описание", false, true, func(p *models.Project) *string { return &p.Description }),
+		createdField(func(p *models.Project) time.Time { return p.CreatedAt }, true),
+		updatedField(func(p *models.Project) time.Time { return p.UpdatedAt }),
+		deletedField(func(p *models.Project) *time.Time { return p.DeletedAt }),
+	},
+	id:        func(p *models.Project) int64 { return p.ID },
+	label:     func(p *models.Project) string { return p.Name },
+	labelName: "название",
+	deleted:   func(p *models.Project) bool { return p.DeletedAt != nil },
+	list:      (*store.Store).ListProjects,
+	get:       (*store.Store).GetProject,
+	create:    (*store.Store).CreateProject,
+	update:    (*store.Store).UpdateProject,
+	remove:    (*store.Store).DeleteProject,
+}
+
+// employeeCommand - подкоманды employee
+var employeeCommand = &entitySpec[models.Employee]{
+	name:  "employee",
+	title: "Сотрудник",
+	fields: []entityField[models.Employee]{
+		idField(func(e *models.Employee) int64 { return e.ID }),
+		textField("last_name", "фамилия", true, true, func(e *models.Employee) *string { return &e.LastName }),
+		textField("first_name", "имя", true, true, func(e *models.Employee) *string { return &e.FirstName }),
+		textField("middle_name", "отчество", false, false, func(e *models.Employee) *string { return &e.MiddleName }),
+		textField("email", "email", false, true, func(e *models.Employee) *string { return &e.Email }),
+		textField("position", "должность", true, true, func(e *models.Employee) *string { return &e.Position }),
+		textField("department", "отдел", false, true, func(e *models.Employee) *string { return &e.Department }),
+		{
+			name:   "manager_id",
+			usage:  "ID руководителя, пусто - без руководителя",
+			column: true,
+			get: func(e *models.Employee) string {
+				if e.ManagerID == nil {
+					return ""
+				}
+				return strconv.FormatInt(*e.ManagerID, 10)
+			},
+			set: func(e *models.Employee, value string) error {
+				value = strings.TrimSpace(value)
+				if value == "" {
+					e.ManagerID = nil
+					return nil
+				}
+				id, err := strconv.ParseInt(value, 10, 64)
+				if err != nil || id <= 0 {
+					return i18n.Errorf("неверный ID %q", value)
+				}
+				e.ManagerID = &id
+				return nil
+			},
+		},
+		textField("phone", "телефон", false, false, func(e *models.Employee) *string { return &e.Phone }),
+		{
+			name:   "hire_date",
+			usage:  "дата найма ГГГГ-ММ-ДД, по умолчанию - сегодня",
+			column: true,
+			get:    func(e *models.Employee) string { return e.HireDate.Format(dateLayout) },
+			set: func(e *models.Employee, value string) error {
+				date, err := time.ParseInLocation(dateLayout, strings.TrimSpace(value), time.Local)
+				if err != nil {
+					return i18n.Errorf("неверная дата %q, ожидается ГГГГ-ММ-ДД", value)
+				}
+				e.HireDate = date
+				return nil
+			},
+		},
+		createdField(func(e *models.Employee) time.Time { return e.CreatedAt }, false),
+		updatedField(func(e *models.Employee) time.Time { return e.UpdatedAt }),
+		deletedField(func(e *models.Employee) *time.Time { return e.DeletedAt }),
+	},
+	id:        func(e *models.Employee) int64 { return e.ID },
+	label:     func(e *models.Employee) string { return e.LastName },
+	labelName: "фамилия",
+	deleted:   func(e *models.Employee) bool { return e.DeletedAt != nil },
+	list:      (*store.Store).ListEmployees,
+	get:       (*store.Store).GetEmployee,
+	create:    (*store.Store).CreateEmployee,
+	update:    (*store.Store).UpdateEmployee,
+	remove:    (*store.Store).DeleteEmployee,
+	check:     checkManager,
+}
+
+// checkManager проверяет, что руководитель существует, не удален и не сам сотрудник
+func checkManager(st *store.Store, e *models.Employee) error {
+	if e.ManagerID == nil {
+		return nil
+	}
+	if *e.ManagerID == e.ID {
+		return i18n.Errorf("сотрудник не может быть своим руководителем")
+	}
+	manager, err := st.GetEmployee(*e.ManagerID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && manager.DeletedAt != nil) {
+		return i18n.Errorf("руководитель #%d не найден", *e.ManagerID)
+	}
+	return err
+}
+
+// snippetCommand - подкоманды snippet
+var snippetCommand = &entitySpec[models.Snippet]{
+	name:  "snippet",
+	title: "Сниппет",
+	fields: []entityField[models.Snippet]{
+		idField(func(sn *models.Snippet) int64 { return sn.ID }),
+		textField("title", "название", true, true, func(sn *models.Snippet) *string { return &sn.Title }),
+		textField("language", "язык", true, true, func(sn *models.Snippet) *string { return &sn.Language }),
+		textField("description", "описание", false, false, func(sn *models.Snippet) *string { return &sn.Description }),
+		{
+			name:      "code",
+			usage:     "код",
+			required:  true,
+			multiline: true,
+			get:       func(sn *models.Snippet) string { return sn.Code },
+			set: func(sn *models.Snippet, value string) error {
+				sn.Code = value
+				return nil
+			},
+		},
+		textField("tags", "теги через запятую", false, true, func(sn *models.Snippet) *string { return &sn.Tags }),
+		createdField(func(sn *models.Snippet) time.Time { return sn.CreatedAt }, true),
+		updatedField(func(sn *models.Snippet) time.Time { return sn.UpdatedAt }),
+		deletedField(func(sn *models.Snippet) *time.Time { return sn.DeletedAt }),
+	},
+	id:        func(sn *models.Snippet) int64 { return sn.ID },
+	label:     func(sn *models.Snippet) string { return sn.Title },
+	labelName: "название",
+	deleted:   func(sn *models.Snippet) bool { return sn.DeletedAt != nil },
+	list:      (*store.Store).ListSnippets,
+	get:       (*store.Store).GetSnippet,
+	create:    (*store.Store).CreateSnippet,
+	update:    (*store.Store).UpdateSnippet,
+	remove:    (*store.Store).DeleteSnippet,
+}
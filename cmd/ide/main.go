@@ -2,266 +2,208 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"strings"
-	"syscall"
 
 	"github.com/deldim-kam/Jotnal/internal/config"
-	"github.com/deldim-kam/Jotnal/internal/database"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/secret"
+	"github.com/deldim-kam/Jotnal/internal/security"
 	"github.com/deldim-kam/Jotnal/internal/ui"
-	"golang.org/x/term"
 )
 
 func main() {
-	fmt.Println("=== Jotnal IDE ===")
-	fmt.Println("Запуск приложения...")
-
-	// Инициализация конфигурации
-	cfgManager, err := config.NewManager("")
+	// Язык нужен до разбора флагов: на нем выводятся справка и ошибки запуска
+	i18n.SetLanguage(startupLanguage())
+
+	configPath := flag.String("config", "", i18n.T("файл конфигурации (по умолчанию ~/.jotnal/config.json)"))
+	dbPath := flag.String("db", "", i18n.T("файл БД вместо указанного в конфигурации"))
+	passwordFile := flag.String("password-file", "", i18n.T("файл, первая строка которого содержит пароль БД"))
+	passwordFD := flag.Int("password-fd", -1, i18n.T("файловый дескриптор, из которого читается пароль БД"))
+	uiMode := flag.String("ui", "", i18n.T("интерфейс: tui, menu или none (только подключиться и выйти)"))
+	readOnly := flag.Bool("read-only", false, i18n.T("открыть БД только для чтения"))
+	lang := flag.String("lang", "", i18n.T("язык интерфейса: ru или en"))
+	flag.Parse()
+
+	// Флаги переопределяют переменные окружения JOTNAL_*, а те - config.json
+	overrides := config.Overrides{
+		ConfigPath:   *configPath,
+		DatabasePath: *dbPath,
+		PasswordFile: *passwordFile,
+		UI:           *uiMode,
+		Language:     *lang,
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "read-only" {
+			overrides.ReadOnly = readOnly
+		}
+	})
+	if err := config.ValidateUI(overrides.UI); err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: --ui: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	if err := config.ValidateLanguage(overrides.Language); err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: --lang: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	env, err := config.OverridesFromEnv(os.Getenv)
 	if err != nil {
-		log.Fatalf("Ошибка при инициализации конфигурации: %v", err)
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	overrides = overrides.Over(env)
+	if overrides.Language != "" {
+		i18n.SetLanguage(overrides.Language)
+	} else if overrides.ConfigPath != "" {
+		i18n.SetLanguage(config.PeekLanguage(overrides.ConfigPath))
 	}
 
-	cfg := cfgManager.Get()
-	fmt.Printf("Конфигурация загружена из: %s/.jotnal/config.json\n", mustGetHomeDir())
+	secretOptions := secret.Options{
+		PasswordFile: overrides.PasswordFile,
+		PasswordFD:   *passwordFD,
+		Prompt:       readSecret,
+	}
 
-	// Проверяем наличие пароля БД
-	if cfg.Database.Password == "" {
-		fmt.Println("\nПервый запуск: необходимо установить пароль для базы данных")
-		password := promptPassword()
-		if err := cfgManager.UpdateDatabasePassword(password); err != nil {
-			log.Fatalf("Ошибка при сохранении пароля: %v", err)
-		}
-		cfg = cfgManager.Get()
-		fmt.Println("Пароль успешно установлен!")
+	// Подкоманды (jotnal db check ...) выполняются без выбора интерфейса
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Args(), overrides, secretOptions))
 	}
 
-	// Автоматическое подключение к БД
-	fmt.Printf("\nПодключение к базе данных: %s\n", cfg.Database.Path)
-	dbManager, err := database.NewManager(cfg.Database.Path, cfg.Database.Password)
+	fmt.Println("=== Jotnal IDE ===")
+	fmt.Println(i18n.T("Запуск приложения..."))
+
+	// Инициализация конфигурации
+	cfgManager, err := loadConfig(overrides)
 	if err != nil {
-		log.Fatalf("Ошибка при создании менеджера БД: %v", err)
+		printConfigError(err)
+		os.Exit(exitProblems)
 	}
 
-	if err := dbManager.Connect(); err != nil {
-		log.Fatalf("Ошибка при подключении к БД: %v", err)
+	i18n.Printf("Конфигурация загружена из: %s\n", cfgManager.Path())
+
+	// Получаем пароль БД выбранным способом и подключаемся
+	secrets := secret.NewStore(cfgManager, secretOptions)
+	dbManager, err := connectDatabase(cfgManager, secrets, true)
+	if err != nil {
+		log.Fatalf(i18n.T("Ошибка при подключении к БД: %v"), err)
 	}
 	defer dbManager.Close()
 
-	fmt.Printf("✓ Успешно подключено к базе данных\n")
-	fmt.Printf("✓ Версия схемы БД: %d\n", dbManager.GetVersion())
+	i18n.Printf("✓ Успешно подключено к базе данных\n")
+	i18n.Printf("✓ Версия схемы БД: %d\n", dbManager.GetVersion())
 
-	// Выбор интерфейса
-	fmt.Println("\n=== Выбор интерфейса ===")
-	fmt.Println("1. Графический интерфейс (TUI) - рекомендуется")
-	fmt.Println("2. Текстовое меню (старый интерфейс)")
-	fmt.Print("\nВыберите интерфейс (1-2, Enter для графического): ")
-
-	reader := bufio.NewReader(os.Stdin)
-	input, _ := reader.ReadString('\n')
-	input = strings.TrimSpace(input)
+	if dbManager.ReadOnly() {
+		fmt.Println(i18n.T("✓ БД открыта только для чтения"))
+	}
 
-	if input == "2" {
-		// Старый текстовый интерфейс
-		showMenu(cfgManager, dbManager)
-	} else {
+	switch chooseUI(cfgManager.Get().Interface.UI) {
+	case config.UINone:
+		// Подключение проверено, миграции применены
+	case config.UIMenu:
+		// Построчный интерфейс без перерисовки экрана
+		showMenu(cfgManager, dbManager, secrets)
+	default:
 		// Новый графический интерфейс
-		fmt.Println("\nЗапуск графического интерфейса...")
-		app := ui.NewApp(dbManager.GetDB(), cfgManager)
+		fmt.Println(i18n.T("\nЗапуск графического интерфейса..."))
+		app := ui.NewApp(dbManager, cfgManager, secrets)
 		if err := app.Run(); err != nil {
-			log.Fatalf("Ошибка при запуске UI: %v", err)
+			log.Fatalf(i18n.T("Ошибка при запуске UI: %v"), err)
 		}
 	}
 }
 
-func showMenu(cfgManager *config.Manager, dbManager *database.Manager) {
-	reader := bufio.NewReader(os.Stdin)
-
-	for {
-		fmt.Println("\n=== Главное меню ===")
-		fmt.Println("1. Показать информацию о БД")
-		fmt.Println("2. Изменить путь к БД")
-		fmt.Println("3. Изменить пароль БД")
-		fmt.Println("4. Показать настройки интерфейса")
-		fmt.Println("5. Изменить настройки интерфейса")
-		fmt.Println("6. Выход")
-		fmt.Print("\nВыберите действие (1-6): ")
-
-		input, _ := reader.ReadString('\n')
-		input = strings.TrimSpace(input)
-
-		switch input {
-		case "1":
-			showDatabaseInfo(dbManager, cfgManager)
-		case "2":
-			changeDatabasePath(cfgManager, dbManager)
-		case "3":
-			changeDatabasePassword(cfgManager, dbManager)
-		case "4":
-			showInterfaceSettings(cfgManager)
-		case "5":
-			changeInterfaceSettings(cfgManager)
-		case "6":
-			fmt.Println("До свидания!")
-			return
-		default:
-			fmt.Println("Неверный выбор, попробуйте снова")
-		}
+// loadConfig загружает конфигурацию и накладывает на нее параметры запуска
+func loadConfig(overrides config.Overrides) (*config.Manager, error) {
+	cfgManager, err := config.NewManager(overrides.ConfigPath)
+	if err != nil {
+		return nil, err
 	}
+	cfgManager.SetOverrides(overrides)
+	i18n.SetLanguage(cfgManager.Get().Interface.Language)
+	return cfgManager, nil
 }
 
-func showDatabaseInfo(dbManager *database.Manager, cfgManager *config.Manager) {
-	cfg := cfgManager.Get()
-	fmt.Println("\n=== Информация о базе данных ===")
-	fmt.Printf("Путь: %s\n", cfg.Database.Path)
-	fmt.Printf("Версия схемы: %d\n", dbManager.GetVersion())
-
-	// Проверяем размер файла БД
-	if info, err := os.Stat(cfg.Database.Path); err == nil {
-		fmt.Printf("Размер файла: %.2f КБ\n", float64(info.Size())/1024)
+// startupLanguage возвращает язык до разбора флагов, чтобы справка по флагам
+// выводилась на нужном языке: из --lang, JOTNAL_LANG или config.json
+func startupLanguage() string {
+	if lang := peekFlag("lang"); lang != "" {
+		return lang
 	}
-
-	// Показываем список таблиц
-	db := dbManager.GetDB()
-	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type='table' ORDER BY name")
-	if err != nil {
-		fmt.Printf("Ошибка при получении списка таблиц: %v\n", err)
-		return
+	if lang := os.Getenv(config.EnvLanguage); lang != "" {
+		return lang
 	}
-	defer rows.Close()
-
-	fmt.Println("\nТаблицы в БД:")
-	for rows.Next() {
-		var tableName string
-		if err := rows.Scan(&tableName); err == nil {
-			fmt.Printf("  - %s\n", tableName)
-		}
+	configPath := peekFlag("config")
+	if configPath == "" {
+		configPath = os.Getenv(config.EnvConfig)
 	}
+	return config.PeekLanguage(configPath)
 }
 
-func changeDatabasePath(cfgManager *config.Manager, dbManager *database.Manager) {
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Print("\nВведите новый путь к БД (или Enter для отмены): ")
-	input, _ := reader.ReadString('\n')
-	input = strings.TrimSpace(input)
-
-	if input == "" {
-		fmt.Println("Отменено")
-		return
-	}
-
-	if err := cfgManager.UpdateDatabasePath(input); err != nil {
-		fmt.Printf("Ошибка при обновлении пути: %v\n", err)
-		return
+// peekFlag находит значение флага name в аргументах до их разбора
+func peekFlag(name string) string {
+	args := os.Args[1:]
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		arg = strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		if value, ok := strings.CutPrefix(arg, name+"="); ok {
+			return value
+		}
+		if arg == name && i+1 < len(args) {
+			return args[i+1]
+		}
 	}
-
-	fmt.Println("✓ Путь к БД обновлен. Перезапустите приложение для применения изменений.")
+	return ""
 }
 
-func changeDatabasePassword(cfgManager *config.Manager, dbManager *database.Manager) {
-	fmt.Println("\n=== Смена пароля базы данных ===")
-	newPassword := promptPassword()
-
-	if err := dbManager.ChangePassword(newPassword); err != nil {
-		fmt.Printf("Ошибка при смене пароля БД: %v\n", err)
-		return
+// chooseUI возвращает интерфейс запуска; если он не задан, спрашивает пользователя
+func chooseUI(mode string) string {
+	if mode != config.UIPrompt {
+		return mode
 	}
 
-	if err := cfgManager.UpdateDatabasePassword(newPassword); err != nil {
-		fmt.Printf("Ошибка при сохранении пароля в конфигурации: %v\n", err)
-		return
-	}
-
-	fmt.Println("✓ Пароль успешно изменен!")
-}
-
-func showInterfaceSettings(cfgManager *config.Manager) {
-	cfg := cfgManager.Get()
-	fmt.Println("\n=== Настройки интерфейса ===")
-	fmt.Printf("Тема: %s\n", cfg.Interface.Theme)
-	fmt.Printf("Размер шрифта: %d\n", cfg.Interface.FontSize)
-	fmt.Printf("Размер окна: %dx%d\n", cfg.Interface.WindowSize.Width, cfg.Interface.WindowSize.Height)
-	fmt.Printf("Язык: %s\n", cfg.Interface.Language)
-}
+	fmt.Println(i18n.T("\n=== Выбор интерфейса ==="))
+	fmt.Println(i18n.T("1. Графический интерфейс (TUI) - рекомендуется"))
+	fmt.Println(i18n.T("2. Построчный интерфейс (последовательная консоль, экранный диктор)"))
+	fmt.Print(i18n.T("\nВыберите интерфейс (1-2, Enter для графического): "))
 
-func changeInterfaceSettings(cfgManager *config.Manager) {
 	reader := bufio.NewReader(os.Stdin)
-	cfg := cfgManager.Get()
-
-	fmt.Println("\n=== Изменение настроек интерфейса ===")
-
-	fmt.Printf("Тема (текущая: %s, например: dark/light): ", cfg.Interface.Theme)
-	theme, _ := reader.ReadString('\n')
-	theme = strings.TrimSpace(theme)
-	if theme == "" {
-		theme = cfg.Interface.Theme
-	}
-
-	fmt.Printf("Размер шрифта (текущий: %d): ", cfg.Interface.FontSize)
-	var fontSize int
-	fmt.Fscanf(reader, "%d\n", &fontSize)
-	if fontSize == 0 {
-		fontSize = cfg.Interface.FontSize
-	}
-
-	fmt.Printf("Ширина окна (текущая: %d): ", cfg.Interface.WindowSize.Width)
-	var width int
-	fmt.Fscanf(reader, "%d\n", &width)
-	if width == 0 {
-		width = cfg.Interface.WindowSize.Width
-	}
-
-	fmt.Printf("Высота окна (текущая: %d): ", cfg.Interface.WindowSize.Height)
-	var height int
-	fmt.Fscanf(reader, "%d\n", &height)
-	if height == 0 {
-		height = cfg.Interface.WindowSize.Height
-	}
-
-	fmt.Printf("Язык (текущий: %s, например: ru/en): ", cfg.Interface.Language)
-	language, _ := reader.ReadString('\n')
-	language = strings.TrimSpace(language)
-	if language == "" {
-		language = cfg.Interface.Language
-	}
-
-	if err := cfgManager.UpdateInterfaceSettings(theme, fontSize, width, height, language); err != nil {
-		fmt.Printf("Ошибка при обновлении настроек: %v\n", err)
-		return
+	input, _ := reader.ReadString('\n')
+	if strings.TrimSpace(input) == "2" {
+		return config.UIMenu
 	}
-
-	fmt.Println("✓ Настройки успешно обновлены!")
+	return config.UITUI
 }
 
-func promptPassword() string {
-	fmt.Print("Введите пароль для базы данных: ")
-	password, err := term.ReadPassword(int(syscall.Stdin))
-	if err != nil {
-		log.Fatalf("Ошибка при чтении пароля: %v", err)
-	}
-	fmt.Println()
+// promptPassword запрашивает новый пароль БД с подтверждением и проверкой политики
+func promptPassword(policy security.Policy) (string, error) {
+	i18n.Fprintf(os.Stderr, "Требования к паролю: %s\n", policy.Describe())
 
-	fmt.Print("Повторите пароль: ")
-	password2, err := term.ReadPassword(int(syscall.Stdin))
-	if err != nil {
-		log.Fatalf("Ошибка при чтении пароля: %v", err)
-	}
-	fmt.Println()
+	for {
+		password, err := readSecret(i18n.T("Введите пароль для базы данных: "))
+		if err != nil {
+			return "", err
+		}
+		if err := policy.Validate(password); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		i18n.Fprintf(os.Stderr, "Надежность пароля: %s\n", security.EstimateStrength(password))
 
-	if string(password) != string(password2) {
-		fmt.Println("Пароли не совпадают, попробуйте снова")
-		return promptPassword()
-	}
+		confirm, err := readSecret(i18n.T("Повторите пароль: "))
+		if err != nil {
+			return "", err
+		}
 
-	return string(password)
-}
+		if password != confirm {
+			fmt.Fprintln(os.Stderr, i18n.T("Пароли не совпадают, попробуйте снова"))
+			continue
+		}
 
-func mustGetHomeDir() string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "~"
+		return password, nil
 	}
-	return home
 }
@@ -0,0 +1,703 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/deldim-kam/Jotnal/internal/database"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/secret"
+	"github.com/deldim-kam/Jotnal/internal/security"
+	"github.com/deldim-kam/Jotnal/internal/store"
+	"github.com/deldim-kam/Jotnal/pkg/models"
+)
+
+// lineUI - построчный интерфейс: нумерованные меню и вопросы без
+// псевдографики, цвета и перерисовки экрана. Подходит для последовательных
+// консолей и экранного диктора; записи меняются через тот же store, что и в TUI.
+type lineUI struct {
+	in         *bufio.Reader
+	cfgManager *config.Manager
+	dbManager  *database.Manager
+	secrets    *secret.Store
+	st         *store.Store
+	closed     bool // стандартный ввод закрыт: все меню завершаются
+}
+
+// menuItem - пункт построчного меню
+type menuItem struct {
+	title string
+	run   func()
+}
+
+// showMenu запускает построчный интерфейс
+func showMenu(cfgManager *config.Manager, dbManager *database.Manager, secrets *secret.Store) {
+	u := &lineUI{
+		in:         bufio.NewReader(os.Stdin),
+		cfgManager: cfgManager,
+		dbManager:  dbManager,
+		secrets:    secrets,
+		st:         store.New(dbManager, ""),
+	}
+
+	title := i18n.T("Главное меню")
+	if dbManager.ReadOnly() {
+		title += i18n.T(" (только чтение)")
+	}
+	u.menu(title, i18n.T("Выход"), []menuItem{
+		{i18n.T("Проекты"), func() { entityMenu(u, projectCommand, i18n.T("Проекты")) }},
+		{i18n.T("Сотрудники"), func() { entityMenu(u, employeeCommand, i18n.T("Сотрудники")) }},
+		{i18n.T("Сниппеты"), func() { entityMenu(u, snippetCommand, i18n.T("Сниппеты")) }},
+		{i18n.T("Поиск"), u.search},
+		{i18n.T("Смена и журнал"), u.shiftMenu},
+		{i18n.T("Корзина"), u.trash},
+		{i18n.T("Настройки и база данных"), u.settingsMenu},
+	})
+	fmt.Println(i18n.T("До свидания!"))
+}
+
+// menu выводит пункты с номерами и выполняет выбранный, пока не выбран 0
+// или не закрыт ввод
+func (u *lineUI) menu(title, exit string, items []menuItem) {
+	for !u.closed {
+		fmt.Printf("\n=== %s ===\n", title)
+		for i, item := range items {
+			fmt.Printf("%d. %s\n", i+1, item.title)
+		}
+		fmt.Printf("0. %s\n", exit)
+
+		input, ok := u.ask(i18n.Sprintf("\nВыберите действие (0-%d): ", len(items)))
+		if !ok || input == "0" {
+			return
+		}
+		n, err := strconv.Atoi(input)
+		if err != nil || n < 1 || n > len(items) {
+			fmt.Println(i18n.T("Неверный выбор, попробуйте снова"))
+			continue
+		}
+		items[n-1].run()
+	}
+}
+
+// ask выводит вопрос и читает строку ответа без пробелов по краям.
+// Возвращает false, если ввод закрыт (Ctrl+D или конец файла).
+func (u *lineUI) ask(prompt string) (string, bool) {
+	if u.closed {
+		return "", false
+	}
+	fmt.Print(prompt)
+	line, err := u.in.ReadString('\n')
+	if err != nil {
+		if err != io.EOF || line == "" {
+			fmt.Println()
+			u.closed = true
+			return "", false
+		}
+	}
+	return strings.TrimSpace(line), true
+}
+
+// askLines читает несколько строк до строки из одной точки или конца ввода
+func (u *lineUI) askLines(prompt string) (string, bool) {
+	if u.closed {
+		return "", false
+	}
+	fmt.Println(prompt)
+	var lines []string
+	for {
+		line, err := u.in.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if err == nil && line == "." {
+			break
+		}
+		if err != nil {
+			if line != "" {
+				lines = append(lines, line)
+			}
+			if len(lines) == 0 {
+				u.closed = true
+				return "", false
+			}
+			break
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+// confirm задает вопрос «да/нет»; по умолчанию - нет
+func (u *lineUI) confirm(prompt string) bool {
+	input, ok := u.ask(prompt + i18n.T(" (д/Н): "))
+	switch strings.ToLower(input) {
+	case "д", "да", "y", "yes":
+		return ok
+	}
+	return false
+}
+
+// entityMenu - меню записей одной сущности
+func entityMenu[T any](u *lineUI, spec *entitySpec[T], title string) {
+	u.menu(title, i18n.T("Назад"), []menuItem{
+		{i18n.T("Список"), func() { lineList(u, spec) }},
+		{i18n.T("Просмотр"), func() {
+			if record := lineFind(u, spec); record != nil {
+				spec.print(os.Stdout, formatTable, record)
+			}
+		}},
+		{i18n.T("Добавить"), func() { lineAdd(u, spec) }},
+		{i18n.T("Изменить"), func() { lineEdit(u, spec) }},
+		{i18n.T("Удалить в корзину"), func() { lineDelete(u, spec) }},
+	})
+}
+
+// lineList выводит записи вне корзины таблицей основных полей
+func lineList[T any](u *lineUI, spec *entitySpec[T]) {
+	records, err := spec.list(u.st)
+	if err != nil {
+		i18n.Printf("Ошибка чтения: %v\n", err)
+		return
+	}
+	if len(records) == 0 {
+		fmt.Println(i18n.T("Записей нет"))
+		return
+	}
+
+	var fields []entityField[T]
+	for _, f := range spec.fields {
+		if f.column {
+			fields = append(fields, f)
+		}
+	}
+	rows := make([][]string, len(records))
+	for i := range records {
+		rows[i] = fieldValues(fields, &records[i])
+	}
+	printRows(os.Stdout, formatTable, fieldNames(fields), rows, records)
+	i18n.Printf("Всего: %d\n", len(records))
+}
+
+// lineFind спрашивает ID или имя записи и загружает ее.
+// Пустой ответ отменяет действие; сообщения о поиске выводит find.
+func lineFind[T any](u *lineUI, spec *entitySpec[T]) *T {
+	ref, ok := u.ask(i18n.Sprintf("ID или %s (Enter - отмена): ", i18n.T(spec.labelName)))
+	if !ok || ref == "" {
+		return nil
+	}
+	record, _ := spec.find(u.st, ref)
+	return record
+}
+
+// lineAdd спрашивает поля новой записи и сохраняет ее
+func lineAdd[T any](u *lineUI, spec *entitySpec[T]) {
+	var record T
+	fmt.Println(i18n.T("Пустой ответ оставляет необязательное поле пустым."))
+	for _, f := range spec.fields {
+		if f.set == nil {
+			continue
+		}
+		for {
+			value, ok := lineField(u, f, "")
+			if !ok {
+				return
+			}
+			if f.required && strings.TrimSpace(value) == "" {
+				fmt.Println(i18n.T("Поле обязательно"))
+				continue
+			}
+			if value == "" && !f.required {
+				break
+			}
+			if err := f.set(&record, value); err != nil {
+				i18n.Printf("Ошибка: %v\n", err)
+				continue
+			}
+			break
+		}
+	}
+
+	if spec.check != nil {
+		if err := spec.check(u.st, &record); err != nil {
+			i18n.Printf("Ошибка: %v\n", err)
+			return
+		}
+	}
+	id, err := spec.create(u.st, record)
+	if err != nil {
+		i18n.Printf("Ошибка записи: %v\n", err)
+		return
+	}
+	i18n.Printf("✓ %s #%d добавлен\n", i18n.T(spec.title), id)
+}
+
+// lineEdit спрашивает новые значения полей записи; Enter оставляет
+// текущее значение, "-" очищает необязательное поле
+func lineEdit[T any](u *lineUI, spec *entitySpec[T]) {
+	record := lineFind(u, spec)
+	if record == nil {
+		return
+	}
+	id := spec.id(record)
+	if spec.deleted(record) {
+		i18n.Printf("%s #%d находится в корзине, восстановите запись перед изменением\n", i18n.T(spec.title), id)
+		return
+	}
+
+	fmt.Println(i18n.T("Enter оставляет текущее значение, \"-\" очищает необязательное поле."))
+	changed := false
+	for _, f := range spec.fields {
+		if f.set == nil {
+			continue
+		}
+		for {
+			value, ok := lineField(u, f, f.get(record))
+			if !ok {
+				return
+			}
+			if value == "" {
+				break
+			}
+			if value == "-" && !f.multiline {
+				if f.required {
+					fmt.Println(i18n.T("Поле обязательно"))
+					continue
+				}
+				value = ""
+			}
+			if err := f.set(record, value); err != nil {
+				i18n.Printf("Ошибка: %v\n", err)
+				continue
+			}
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		fmt.Println(i18n.T("Изменений нет"))
+		return
+	}
+
+	if spec.check != nil {
+		if err := spec.check(u.st, record); err != nil {
+			i18n.Printf("Ошибка: %v\n", err)
+			return
+		}
+	}
+	if err := spec.update(u.st, *record); err != nil {
+		i18n.Printf("Ошибка записи: %v\n", err)
+		if errors.Is(err, store.ErrConflict) {
+			fmt.Println(i18n.T("Изменения не сохранены, откройте запись заново"))
+		}
+		return
+	}
+	i18n.Printf("✓ %s #%d изменен\n", i18n.T(spec.title), id)
+}
+
+// lineField спрашивает значение поля; current - текущее значение при изменении
+func lineField[T any](u *lineUI, f entityField[T], current string) (string, bool) {
+	label := i18n.T(f.usage)
+	if f.required {
+		label += i18n.T(" (обязательно)")
+	}
+
+	if f.multiline {
+		prompt := label + i18n.T(": несколько строк, строка \".\" завершает ввод")
+		if current != "" {
+			prompt += i18n.Sprintf("; сразу \".\" - оставить текущее (%d стр.)", strings.Count(current, "\n")+1)
+		}
+		return u.askLines(prompt)
+	}
+
+	if current != "" {
+		label += " [" + current + "]"
+	}
+	return u.ask(label + ": ")
+}
+
+// lineDelete перемещает запись в корзину после подтверждения
+func lineDelete[T any](u *lineUI, spec *entitySpec[T]) {
+	record := lineFind(u, spec)
+	if record == nil {
+		return
+	}
+	id := spec.id(record)
+	if spec.deleted(record) {
+		i18n.Printf("%s #%d уже в корзине\n", i18n.T(spec.title), id)
+		return
+	}
+	if !u.confirm(i18n.Sprintf("Переместить в корзину %q (#%d)?", spec.label(record), id)) {
+		fmt.Println(i18n.T("Отменено"))
+		return
+	}
+
+	if err := spec.remove(u.st, id); err != nil {
+		i18n.Printf("Ошибка: %v\n", err)
+		return
+	}
+	i18n.Printf("✓ %s #%d перемещен в корзину\n", i18n.T(spec.title), id)
+}
+
+// showRecord выводит запись любой сущности по ID
+func (u *lineUI) showRecord(entity string, id int64) {
+	switch entity {
+	case store.EntityProject:
+		lineShow(u, projectCommand, id)
+	case store.EntityEmployee:
+		lineShow(u, employeeCommand, id)
+	case store.EntitySnippet:
+		lineShow(u, snippetCommand, id)
+	}
+}
+
+// lineShow загружает и выводит запись по ID
+func lineShow[T any](u *lineUI, spec *entitySpec[T], id int64) {
+	if record, _ := spec.load(u.st, id); record != nil {
+		spec.print(os.Stdout, formatTable, record)
+	}
+}
+
+// entityTitle возвращает название записи сущности для списков
+func entityTitle(entity string) string {
+	switch entity {
+	case store.EntityProject:
+		return i18n.T(projectCommand.title)
+	case store.EntityEmployee:
+		return i18n.T(employeeCommand.title)
+	case store.EntitySnippet:
+		return i18n.T(snippetCommand.title)
+	}
+	return entity
+}
+
+// search ищет текст во всех записях и открывает выбранную
+func (u *lineUI) search() {
+	query, ok := u.ask(i18n.T("Текст для поиска (Enter - отмена): "))
+	if !ok || query == "" {
+		return
+	}
+	results, err := u.st.Search(query)
+	if err != nil {
+		i18n.Printf("Ошибка поиска: %v\n", err)
+		return
+	}
+	if len(results) == 0 {
+		fmt.Println(i18n.T("Ничего не найдено"))
+		return
+	}
+
+	i18n.Printf("Найдено: %d\n", len(results))
+	for i, r := range results {
+		fmt.Printf("%d. %s #%d %s: %s\n", i+1, entityTitle(r.Entity), r.ID, r.Title, tableCell(r.Match))
+	}
+	for {
+		input, ok := u.ask(i18n.T("Номер записи для просмотра (Enter - назад): "))
+		if !ok || input == "" {
+			return
+		}
+		n, err := strconv.Atoi(input)
+		if err != nil || n < 1 || n > len(results) {
+			fmt.Println(i18n.T("Неверный номер"))
+			continue
+		}
+		u.showRecord(results[n-1].Entity, results[n-1].ID)
+	}
+}
+
+// shiftMenu - смена и ее журнал
+func (u *lineUI) shiftMenu() {
+	u.menu(i18n.T("Смена и журнал"), i18n.T("Назад"), []menuItem{
+		{i18n.T("Состояние смены и журнал"), u.shiftStatus},
+		{i18n.T("Открыть смену"), u.shiftOpen},
+		{i18n.T("Добавить запись в журнал"), u.shiftLog},
+		{i18n.T("Закрыть смену"), u.shiftClose},
+	})
+}
+
+// shiftStatus выводит открытую смену и записи ее журнала по одной в строке
+func (u *lineUI) shiftStatus() {
+	shift, err := u.st.CurrentShift()
+	if errors.Is(err, store.ErrNoOpenShift) {
+		fmt.Println(i18n.T("Смена не открыта"))
+		return
+	}
+	if err != nil {
+		i18n.Printf("Ошибка чтения: %v\n", err)
+		return
+	}
+	entries, err := u.st.ListShiftEntries(shift.ID)
+	if err != nil {
+		i18n.Printf("Ошибка чтения: %v\n", err)
+		return
+	}
+
+	i18n.Printf("Смена #%d открыта: %s, %s\n", shift.ID, shift.OpenedBy, i18n.DateTime(shift.OpenedAt.Local()))
+	if len(entries) == 0 {
+		fmt.Println(i18n.T("Записей в журнале нет"))
+		return
+	}
+	for _, e := range entries {
+		printShiftEntry(e)
+	}
+}
+
+// printShiftEntry выводит запись журнала смены; строки многострочного текста - с отступом
+func printShiftEntry(e models.ShiftEntry) {
+	message := strings.ReplaceAll(e.Message, "\n", "\n    ")
+	fmt.Printf("%s %s %s: %s\n", e.CreatedAt.Local().Format("15:04"), e.Severity, e.Author, message)
+}
+
+// shiftOpen открывает смену
+func (u *lineUI) shiftOpen() {
+	shift, err := u.st.OpenShift()
+	if err != nil {
+		i18n.Printf("Ошибка: %v\n", err)
+		return
+	}
+	i18n.Printf("✓ Смена #%d открыта (%s, %s)\n", shift.ID, shift.OpenedBy, i18n.DateTime(shift.OpenedAt.Local()))
+}
+
+// shiftLog добавляет запись в журнал открытой смены
+func (u *lineUI) shiftLog() {
+	message, ok := u.ask(i18n.T("Текст записи (Enter - отмена): "))
+	if !ok || message == "" {
+		return
+	}
+	severity := store.SeverityInfo
+	for {
+		input, ok := u.ask(i18n.T("Важность: info, warn, error [info]: "))
+		if !ok {
+			return
+		}
+		if input == "" {
+			break
+		}
+		if err := store.ValidateSeverity(input); err != nil {
+			i18n.Printf("Ошибка: %v\n", err)
+			continue
+		}
+		severity = input
+		break
+	}
+
+	entry, err := u.st.AddShiftEntry(severity, message)
+	if errors.Is(err, store.ErrNoOpenShift) {
+		fmt.Println(i18n.T("Смена не открыта, сначала откройте ее"))
+		return
+	}
+	if err != nil {
+		i18n.Printf("Ошибка: %v\n", err)
+		return
+	}
+	i18n.Printf("✓ Запись #%d добавлена в журнал смены #%d\n", entry.ID, entry.ShiftID)
+}
+
+// shiftClose закрывает смену после подтверждения и при необходимости создает резервную копию
+func (u *lineUI) shiftClose() {
+	if !u.confirm(i18n.T("Закрыть смену?")) {
+		fmt.Println(i18n.T("Отменено"))
+		return
+	}
+	shift, err := u.st.CloseShift()
+	if errors.Is(err, store.ErrNoOpenShift) {
+		fmt.Println(i18n.T("Смена не открыта"))
+		return
+	}
+	if err != nil {
+		i18n.Printf("Ошибка: %v\n", err)
+		return
+	}
+	i18n.Printf("✓ Смена #%d закрыта (%s, %s)\n", shift.ID, shift.ClosedBy, i18n.DateTime(shift.ClosedAt.Local()))
+
+	path, err := shiftCloseBackup(u.dbManager, u.cfgManager.Get().Backup)
+	if err != nil {
+		i18n.Printf("Ошибка резервного копирования: %v\n", err)
+		return
+	}
+	if path != "" {
+		i18n.Printf("✓ Резервная копия сохранена: %s\n", path)
+	}
+}
+
+// trash выводит записи в корзине и восстанавливает выбранную
+func (u *lineUI) trash() {
+	items, err := u.st.ListTrash()
+	if err != nil {
+		i18n.Printf("Ошибка чтения: %v\n", err)
+		return
+	}
+	if len(items) == 0 {
+		fmt.Println(i18n.T("Корзина пуста"))
+		return
+	}
+
+	for i, item := range items {
+		i18n.Printf("%d. %s #%d %s, удален %s\n", i+1, entityTitle(item.Entity), item.ID, item.Title, i18n.DateTime(item.DeletedAt.Local()))
+	}
+	input, ok := u.ask(i18n.T("Номер записи для восстановления (Enter - назад): "))
+	if !ok || input == "" {
+		return
+	}
+	n, err := strconv.Atoi(input)
+	if err != nil || n < 1 || n > len(items) {
+		fmt.Println(i18n.T("Неверный номер"))
+		return
+	}
+
+	item := items[n-1]
+	if err := u.st.Restore(item.Entity, item.ID); err != nil {
+		i18n.Printf("Ошибка: %v\n", err)
+		return
+	}
+	i18n.Printf("✓ %s #%d восстановлен\n", entityTitle(item.Entity), item.ID)
+}
+
+// settingsMenu - база данных и настройки интерфейса
+func (u *lineUI) settingsMenu() {
+	u.menu(i18n.T("Настройки и база данных"), i18n.T("Назад"), []menuItem{
+		{i18n.T("Информация о БД"), u.showDatabaseInfo},
+		{i18n.T("Изменить путь к БД"), u.changeDatabasePath},
+		{i18n.T("Изменить пароль БД"), u.changeDatabasePassword},
+		{i18n.T("Показать настройки интерфейса"), u.showInterfaceSettings},
+		{i18n.T("Изменить настройки интерфейса"), u.changeInterfaceSettings},
+	})
+}
+
+func (u *lineUI) showDatabaseInfo() {
+	cfg := u.cfgManager.Get()
+	fmt.Println(i18n.T("\n=== Информация о базе данных ==="))
+	i18n.Printf("Путь: %s\n", cfg.Database.Path)
+	i18n.Printf("Версия схемы: %d\n", u.dbManager.GetVersion())
+
+	// Проверяем размер файла БД
+	if info, err := os.Stat(cfg.Database.Path); err == nil {
+		i18n.Printf("Размер файла: %.2f КБ\n", float64(info.Size())/1024)
+	}
+
+	// Показываем список таблиц
+	db := u.dbManager.GetDB()
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type='table' ORDER BY name")
+	if err != nil {
+		i18n.Printf("Ошибка при получении списка таблиц: %v\n", err)
+		return
+	}
+	defer rows.Close()
+
+	fmt.Println(i18n.T("\nТаблицы в БД:"))
+	for rows.Next() {
+		var tableName string
+		if err := rows.Scan(&tableName); err == nil {
+			fmt.Printf("  - %s\n", tableName)
+		}
+	}
+}
+
+func (u *lineUI) changeDatabasePath() {
+	input, ok := u.ask(i18n.T("\nВведите новый путь к БД (или Enter для отмены): "))
+	if !ok || input == "" {
+		fmt.Println(i18n.T("Отменено"))
+		return
+	}
+
+	if err := u.cfgManager.UpdateDatabasePath(input); err != nil {
+		i18n.Printf("Ошибка при обновлении пути: %v\n", err)
+		return
+	}
+
+	fmt.Println(i18n.T("✓ Путь к БД обновлен. Перезапустите приложение для применения изменений."))
+}
+
+func (u *lineUI) changeDatabasePassword() {
+	fmt.Println(i18n.T("\n=== Смена пароля базы данных ==="))
+	newPassword, err := promptPassword(security.NewPolicy(u.cfgManager.Get().Security))
+	if err != nil {
+		i18n.Printf("Ошибка при смене пароля БД: %v\n", err)
+		return
+	}
+
+	// Новый пароль сохраняется только после проверки нового ключа,
+	// при ошибке сохранения ключ БД откатывается
+	err = u.dbManager.ChangePassword(newPassword, func() error {
+		return u.secrets.Save(newPassword)
+	})
+	if err != nil {
+		i18n.Printf("Ошибка при смене пароля БД: %v\n", err)
+		return
+	}
+
+	fmt.Println(i18n.T("✓ Пароль успешно изменен!"))
+	if !u.secrets.Persistent() {
+		fmt.Println(i18n.T("Запомните новый пароль: он потребуется при следующем запуске."))
+	}
+}
+
+func (u *lineUI) showInterfaceSettings() {
+	cfg := u.cfgManager.Get()
+	fmt.Println(i18n.T("\n=== Настройки интерфейса ==="))
+	i18n.Printf("Тема: %s\n", cfg.Interface.Theme)
+	i18n.Printf("Размер шрифта: %d\n", cfg.Interface.FontSize)
+	i18n.Printf("Размер окна: %dx%d\n", cfg.Interface.WindowSize.Width, cfg.Interface.WindowSize.Height)
+	i18n.Printf("Язык: %s\n", cfg.Interface.Language)
+}
+
+func (u *lineUI) changeInterfaceSettings() {
+	cfg := u.cfgManager.Get()
+
+	fmt.Println(i18n.T("\n=== Изменение настроек интерфейса ==="))
+
+	theme, ok := u.askDefault(i18n.Sprintf("Тема (текущая: %s, например: dark/light/high-contrast): ", cfg.Interface.Theme), cfg.Interface.Theme)
+	if !ok {
+		return
+	}
+	fontSize, ok := u.askNumber(i18n.Sprintf("Размер шрифта (текущий: %d): ", cfg.Interface.FontSize), cfg.Interface.FontSize)
+	if !ok {
+		return
+	}
+	width, ok := u.askNumber(i18n.Sprintf("Ширина окна (текущая: %d): ", cfg.Interface.WindowSize.Width), cfg.Interface.WindowSize.Width)
+	if !ok {
+		return
+	}
+	height, ok := u.askNumber(i18n.Sprintf("Высота окна (текущая: %d): ", cfg.Interface.WindowSize.Height), cfg.Interface.WindowSize.Height)
+	if !ok {
+		return
+	}
+	language, ok := u.askDefault(i18n.Sprintf("Язык (текущий: %s, например: ru/en): ", cfg.Interface.Language), cfg.Interface.Language)
+	if !ok {
+		return
+	}
+
+	if err := u.cfgManager.UpdateInterfaceSettings(theme, fontSize, width, height, language); err != nil {
+		i18n.Printf("Ошибка при обновлении настроек: %v\n", err)
+		return
+	}
+
+	fmt.Println(i18n.T("✓ Настройки успешно обновлены!"))
+}
+
+// askDefault спрашивает значение; пустой ответ возвращает current
+func (u *lineUI) askDefault(prompt, current string) (string, bool) {
+	input, ok := u.ask(prompt)
+	if input == "" {
+		input = current
+	}
+	return input, ok
+}
+
+// askNumber спрашивает положительное число; пустой ответ возвращает current
+func (u *lineUI) askNumber(prompt string, current int) (int, bool) {
+	for {
+		input, ok := u.ask(prompt)
+		if !ok || input == "" {
+			return current, ok
+		}
+		n, err := strconv.Atoi(input)
+		if err == nil && n > 0 {
+			return n, true
+		}
+		fmt.Println(i18n.T("Введите положительное число"))
+	}
+}
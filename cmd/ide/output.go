@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+)
+
+// Форматы вывода подкоманд
+const (
+	formatTable = "table" // выровненные столбцы для чтения человеком
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+// tableCellWidth - максимальная ширина значения в таблице; длинные значения обрезаются
+const tableCellWidth = 40
+
+// formatFlag добавляет флаг --format
+func formatFlag(flags *flag.FlagSet) *string {
+	return flags.String("format", formatTable, i18n.T("формат вывода: table, json, csv"))
+}
+
+// checkFormat проверяет значение --format
+func checkFormat(format string) error {
+	switch format {
+	case formatTable, formatJSON, formatCSV:
+		return nil
+	}
+	return i18n.Errorf("неизвестный формат %q, допустимо: table, json, csv", format)
+}
+
+// printRows выводит список записей: таблицей, CSV или массивом JSON из records
+func printRows(w io.Writer, format string, header []string, rows [][]string, records interface{}) error {
+	switch format {
+	case formatJSON:
+		return printJSON(w, records)
+	case formatCSV:
+		return printCSV(w, header, rows)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, value := range row {
+			cells[i] = tableCell(value)
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// printRecord выводит одну запись: парами «поле значение», строкой CSV или объектом JSON
+func printRecord(w io.Writer, format string, header []string, row []string, record interface{}) error {
+	switch format {
+	case formatJSON:
+		return printJSON(w, record)
+	case formatCSV:
+		return printCSV(w, header, [][]string{row})
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, name := range header {
+		// Многострочные значения (код сниппета) выводятся с отступом под значением
+		value := strings.ReplaceAll(strings.TrimRight(row[i], "\n"), "\n", "\n\t")
+		fmt.Fprintf(tw, "%s:\t%s\n", name, value)
+	}
+	return tw.Flush()
+}
+
+// printJSON выводит значение в JSON с отступами
+func printJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printCSV выводит заголовок и строки в CSV
+func printCSV(w io.Writer, header []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// tableCell готовит значение для таблицы: одна строка не длиннее tableCellWidth
+func tableCell(value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	if utf8.RuneCountInString(value) <= tableCellWidth {
+		return value
+	}
+	return string([]rune(value)[:tableCellWidth-1]) + "…"
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/deldim-kam/Jotnal/internal/backup"
+	"github.com/deldim-kam/Jotnal/internal/database"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+)
+
+// exportPlain выгружает БД в незашифрованный файл для передачи на аудит
+func exportPlain(env *commandEnv, path string) int {
+	dbManager, err := env.connect()
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка при подключении к БД: %v\n", err)
+		return exitProblems
+	}
+	defer dbManager.Close()
+
+	if err := dbManager.ExportPlain(path); err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка выгрузки: %v\n", err)
+		return exitProblems
+	}
+
+	i18n.Printf("✓ БД выгружена без шифрования: %s (версия схемы %d)\n", path, dbManager.GetVersion())
+	fmt.Println(i18n.T("Файл содержит все данные в открытом виде: передавайте его только по защищенному каналу и удалите после использования."))
+	return exitOK
+}
+
+// importPlain заменяет БД данными незашифрованного файла, зашифровав их текущим паролем
+func importPlain(env *commandEnv, path string, yes bool) int {
+	// Версию файла проверяем до запроса пароля
+	version, err := database.PlainSchemaVersion(path)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitProblems
+	}
+	if version > database.LatestVersion() {
+		i18n.Fprintf(os.Stderr, "Версия схемы файла %d новее поддерживаемой приложением %d, обновите приложение\n",
+			version, database.LatestVersion())
+		return exitProblems
+	}
+
+	dbManager, err := env.connect()
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка при подключении к БД: %v\n", err)
+		return exitProblems
+	}
+	defer dbManager.Close()
+
+	details := []string{
+		i18n.Sprintf("БД:          %s (версия схемы %d)", dbManager.GetPath(), dbManager.GetVersion()),
+		i18n.Sprintf("Файл:        %s (версия схемы %d)", path, version),
+	}
+	if version < dbManager.GetVersion() {
+		details = append(details, i18n.Sprintf("Файл создан старой версией, после импорта схема будет обновлена до %d", database.LatestVersion()))
+	}
+	if !yes && !confirmReplace(details...) {
+		return exitProblems
+	}
+
+	fmt.Println(i18n.T("\nСоздание резервной копии..."))
+	backupPath, err := backup.NewScheduler(dbManager, env.cfg.Get().Backup, nil).RunNow(backup.ReasonImport)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка резервного копирования, импорт отменен: %v\n", err)
+		return exitProblems
+	}
+	i18n.Printf("✓ Резервная копия сохранена: %s\n", backupPath)
+
+	if err := dbManager.ImportPlain(path); err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка импорта: %v\n", err)
+		return exitProblems
+	}
+
+	i18n.Printf("✓ БД заменена данными из %s и зашифрована, версия схемы %d\n", path, dbManager.GetVersion())
+	fmt.Println(i18n.T("Рекомендуется проверить БД: jotnal db check"))
+	return exitOK
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/deldim-kam/Jotnal/internal/backup"
+	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/deldim-kam/Jotnal/internal/database"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/store"
+	"github.com/deldim-kam/Jotnal/pkg/models"
+)
+
+// shiftUsage - справка по подкомандам смены; переводится при выводе
+const shiftUsage = `Использование:
+  jotnal shift open
+  jotnal shift close
+  jotnal shift status [--format table|json|csv]
+  jotnal log <текст>|- [--severity info|warn|error]`
+
+// runShift открывает и закрывает смену и показывает ее журнал
+func runShift(env *commandEnv, args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "open":
+			return shiftOpen(env, args[1:])
+		case "close":
+			return shiftClose(env, args[1:])
+		case "status":
+			return shiftStatus(env, args[1:])
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, i18n.T(shiftUsage))
+	return exitUsage
+}
+
+// shiftOpen открывает смену от имени текущего пользователя
+func shiftOpen(env *commandEnv, args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, i18n.T(shiftUsage))
+		return exitUsage
+	}
+
+	return withStore(env, func(st *store.Store) int {
+		shift, err := st.OpenShift()
+		if err != nil {
+			i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+			return exitProblems
+		}
+		i18n.Printf("✓ Смена #%d открыта (%s, %s)\n", shift.ID, shift.OpenedBy, i18n.DateTime(shift.OpenedAt.Local()))
+		return exitOK
+	})
+}
+
+// shiftClose закрывает открытую смену и, если это включено в настройках,
+// создает резервную копию
+func shiftClose(env *commandEnv, args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, i18n.T(shiftUsage))
+		return exitUsage
+	}
+
+	dbManager, err := env.connect()
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка при подключении к БД: %v\n", err)
+		return exitProblems
+	}
+	defer dbManager.Close()
+
+	shift, err := store.New(dbManager, "").CloseShift()
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return shiftExitCode(err)
+	}
+	i18n.Printf("✓ Смена #%d закрыта (%s, %s)\n", shift.ID, shift.ClosedBy, i18n.DateTime(shift.ClosedAt.Local()))
+
+	path, err := shiftCloseBackup(dbManager, env.cfg.Get().Backup)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка резервного копирования: %v\n", err)
+		return exitProblems
+	}
+	if path != "" {
+		i18n.Printf("✓ Резервная копия сохранена: %s\n", path)
+	}
+	return exitOK
+}
+
+// shiftCloseBackup создает резервную копию при закрытии смены, если это
+// включено в настройках; иначе возвращает пустой путь
+func shiftCloseBackup(dbManager *database.Manager, cfg config.BackupConfig) (string, error) {
+	if !cfg.Enabled || !cfg.OnShiftClose {
+		return "", nil
+	}
+	return backup.NewScheduler(dbManager, cfg, nil).RunNow(backup.ReasonShiftClose)
+}
+
+// shiftStatus выводит открытую смену и ее журнал
+func shiftStatus(env *commandEnv, args []string) int {
+	flags := flag.NewFlagSet("shift status", flag.ContinueOnError)
+	format := formatFlag(flags)
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return exitUsage
+	}
+	if err := checkFormat(*format); err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitUsage
+	}
+
+	return withStore(env, func(st *store.Store) int {
+		shift, err := st.CurrentShift()
+		if err != nil {
+			i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+			return shiftExitCode(err)
+		}
+		entries, err := st.ListShiftEntries(shift.ID)
+		if err != nil {
+			i18n.Fprintf(os.Stderr, "Ошибка чтения: %v\n", err)
+			return exitProblems
+		}
+		if entries == nil {
+			entries = []models.ShiftEntry{}
+		}
+
+		switch *format {
+		case formatJSON:
+			return printed(printJSON(os.Stdout, struct {
+				Shift   *models.Shift       `json:"shift"`
+				Entries []models.ShiftEntry `json:"entries"`
+			}{shift, entries}))
+		case formatTable:
+			i18n.Printf("Смена #%d открыта: %s, %s\n\n", shift.ID, shift.OpenedBy, i18n.DateTime(shift.OpenedAt.Local()))
+		}
+
+		header := []string{"id", "created_at", "severity", "author", "message"}
+		rows := make([][]string, len(entries))
+		for i, e := range entries {
+			rows[i] = []string{strconv.FormatInt(e.ID, 10), formatTime(e.CreatedAt), e.Severity, e.Author, e.Message}
+		}
+		return printed(printRows(os.Stdout, *format, header, rows, entries))
+	})
+}
+
+// runLog добавляет запись в журнал открытой смены от имени текущего пользователя
+func runLog(env *commandEnv, args []string) int {
+	flags := flag.NewFlagSet("log", flag.ContinueOnError)
+	severity := flags.String("severity", store.SeverityInfo, i18n.T("важность: info, warn, error"))
+	message, ok := parseWithArg(flags, args)
+	if !ok {
+		fmt.Fprintln(os.Stderr, i18n.T("Использование: jotnal log <текст>|- [--severity info|warn|error]"))
+		return exitUsage
+	}
+	if err := store.ValidateSeverity(*severity); err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitUsage
+	}
+
+	// "-" - текст записи читается из стандартного ввода
+	if message == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			i18n.Fprintf(os.Stderr, "Не удалось прочитать запись: %v\n", err)
+			return exitProblems
+		}
+		message = string(data)
+	}
+	if strings.TrimSpace(message) == "" {
+		fmt.Fprintln(os.Stderr, i18n.T("Ошибка: запись журнала не может быть пустой"))
+		return exitUsage
+	}
+
+	return withStore(env, func(st *store.Store) int {
+		entry, err := st.AddShiftEntry(*severity, message)
+		if err != nil {
+			i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+			return shiftExitCode(err)
+		}
+		i18n.Printf("✓ Запись #%d добавлена в журнал смены #%d\n", entry.ID, entry.ShiftID)
+		return exitOK
+	})
+}
+
+// shiftExitCode возвращает код завершения для ошибки действия со сменой
+func shiftExitCode(err error) int {
+	if errors.Is(err, store.ErrNoOpenShift) {
+		fmt.Fprintln(os.Stderr, i18n.T("Откройте смену: jotnal shift open"))
+		return exitNotFound
+	}
+	return exitProblems
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/deldim-kam/Jotnal/internal/backup"
+	"github.com/deldim-kam/Jotnal/internal/changeset"
+	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/store"
+)
+
+// syncUsage - справка по подкомандам синхронизации; переводится при выводе
+const syncUsage = `Использование:
+  jotnal sync export <файл> [--full] [--key-file <файл>]
+  jotnal sync import <файл> [--strategy field|lww] [--trust] [--key-file <файл>]
+  jotnal sync status
+  jotnal sync init [--yes]`
+
+// runSync обменивается изменениями с другими БД через файлы пакетов
+func runSync(env *commandEnv, args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "export":
+			return syncExport(env, args[1:])
+		case "import":
+			return syncImport(env, args[1:])
+		case "status":
+			return syncStatus(env, args[1:])
+		case "init":
+			return syncInit(env, args[1:])
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, i18n.T(syncUsage))
+	return exitUsage
+}
+
+// syncExport сохраняет изменения этой БД, которые еще не получили другие узлы,
+// в зашифрованный и подписанный пакет
+func syncExport(env *commandEnv, args []string) int {
+	flags := flag.NewFlagSet("sync export", flag.ContinueOnError)
+	full := flags.Bool("full", false, i18n.T("выгрузить весь журнал изменений, а не только неподтвержденные"))
+	keyFile := flags.String("key-file", "", i18n.T("файл с общим ключом синхронизации"))
+	path, ok := parseWithArg(flags, args)
+	if !ok {
+		fmt.Fprintln(os.Stderr, i18n.T(syncUsage))
+		return exitUsage
+	}
+
+	passphrase, err := syncPassphrase(env, *keyFile)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitProblems
+	}
+
+	dbManager, err := env.connect()
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка при подключении к БД: %v\n", err)
+		return exitProblems
+	}
+	defer dbManager.Close()
+
+	st := store.New(dbManager, "")
+	node, err := st.SyncNode()
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitProblems
+	}
+	cs, err := st.ExportChanges(*full)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка чтения журнала изменений: %v\n", err)
+		return exitProblems
+	}
+	data, err := changeset.Seal(cs, passphrase, node.Key)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка шифрования пакета: %v\n", err)
+		return exitProblems
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка сохранения пакета: %v\n", err)
+		return exitProblems
+	}
+
+	i18n.Printf("✓ Пакет сохранен: %s\n", path)
+	i18n.Printf("  Узел:       %s (%s)\n", node.Name, node.ID)
+	i18n.Printf("  Отпечаток:  %s\n", changeset.Fingerprint(node.PublicKey()))
+	i18n.Printf("  Изменений:  %d\n", len(cs.Changes))
+	return exitOK
+}
+
+// syncImport применяет пакет другого узла
+func syncImport(env *commandEnv, args []string) int {
+	flags := flag.NewFlagSet("sync import", flag.ContinueOnError)
+	strategy := flags.String("strategy", "", i18n.T("разрешение конфликтов: field - вручную, lww - побеждает более позднее изменение"))
+	trust := flags.Bool("trust", false, i18n.T("доверять узлу-отправителю без запроса, если он еще неизвестен"))
+	keyFile := flags.String("key-file", "", i18n.T("файл с общим ключом синхронизации"))
+	path, ok := parseWithArg(flags, args)
+	if !ok {
+		fmt.Fprintln(os.Stderr, i18n.T(syncUsage))
+		return exitUsage
+	}
+	if *strategy == "" {
+		*strategy = env.cfg.Get().Sync.Strategy
+	}
+	if err := config.ValidateStrategy(*strategy); err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitUsage
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitProblems
+	}
+	passphrase, err := syncPassphrase(env, *keyFile)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitProblems
+	}
+	cs, key, err := changeset.Open(data, passphrase)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitProblems
+	}
+	i18n.Printf("Пакет узла %s (%s) от %s, изменений: %d\n",
+		cs.Name, cs.Node, i18n.DateTime(cs.CreatedAt), len(cs.Changes))
+
+	dbManager, err := env.connect()
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка при подключении к БД: %v\n", err)
+		return exitProblems
+	}
+	defer dbManager.Close()
+
+	st := store.New(dbManager, "")
+	err = st.CheckSyncPeer(cs, key)
+	var unknown *store.UnknownPeerError
+	if errors.As(err, &unknown) && !*trust {
+		if !confirmPeer(unknown) {
+			fmt.Println(i18n.T("Синхронизация отменена"))
+			return exitProblems
+		}
+	} else if err != nil && unknown == nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitProblems
+	}
+
+	fmt.Println(i18n.T("Создание резервной копии..."))
+	backupPath, err := backup.NewScheduler(dbManager, env.cfg.Get().Backup, nil).RunNow(backup.ReasonSync)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка резервного копирования, синхронизация отменена: %v\n", err)
+		return exitProblems
+	}
+	i18n.Printf("✓ Резервная копия сохранена: %s\n", backupPath)
+
+	result, err := st.ImportChanges(cs, key, *strategy, true)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка синхронизации, данные не изменены: %v\n", err)
+		return exitProblems
+	}
+
+	i18n.Printf("✓ Пакет применен (стратегия %s)\n", *strategy)
+	i18n.Printf("  Применено:   %d\n", result.Applied)
+	i18n.Printf("  Пропущено:   %d\n", result.Skipped)
+	i18n.Printf("  Конфликтов:  %d\n", result.Conflicts)
+	for _, note := range result.Notes {
+		fmt.Printf("  ! %s\n", note)
+	}
+	if result.Conflicts > 0 && *strategy == config.SyncStrategyField {
+		fmt.Println(i18n.T("\nКонфликты ждут решения на экране «Синхронизация»."))
+	}
+	return exitOK
+}
+
+// confirmPeer показывает отпечаток ключа неизвестного узла и спрашивает,
+// доверять ли ему. Отпечаток нужно сверить с выводом sync status на том посту.
+func confirmPeer(peer *store.UnknownPeerError) bool {
+	i18n.Printf("\nУзел %s (%s) еще не входит в доверенные.\n", peer.Name, peer.Node)
+	i18n.Printf("Отпечаток ключа: %s\n", peer.Fingerprint)
+	fmt.Println(i18n.T("Сверьте отпечаток с выводом «jotnal sync status» на посту-отправителе."))
+	fmt.Print(i18n.T("Доверять этому узлу? [y/N]: "))
+	input, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer := strings.ToLower(strings.TrimSpace(input))
+	return answer == "y" || answer == "д"
+}
+
+// syncStatus выводит узел этой БД, доверенные узлы и состояние обмена
+func syncStatus(env *commandEnv, args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, i18n.T(syncUsage))
+		return exitUsage
+	}
+
+	dbManager, err := env.connect()
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка при подключении к БД: %v\n", err)
+		return exitProblems
+	}
+	defer dbManager.Close()
+
+	status, err := store.New(dbManager, "").SyncStatus()
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitProblems
+	}
+
+	i18n.Printf("Узел:                %s (%s)\n", status.Node.Name, status.Node.ID)
+	i18n.Printf("Отпечаток ключа:     %s\n", changeset.Fingerprint(status.Node.PublicKey()))
+	i18n.Printf("Стратегия:           %s\n", env.cfg.Get().Sync.Strategy)
+	i18n.Printf("Не отправлено:       %d\n", status.Pending)
+	i18n.Printf("Открытых конфликтов: %d\n", status.OpenConflicts)
+
+	if len(status.Peers) == 0 {
+		fmt.Println(i18n.T("\nДоверенных узлов нет"))
+		return exitOK
+	}
+	fmt.Println(i18n.T("\nДоверенные узлы:"))
+	for _, p := range status.Peers {
+		last := i18n.T("не загружался")
+		if p.LastImport != nil {
+			last = i18n.DateTime(*p.LastImport)
+		}
+		fmt.Printf("  %s (%s)  %s\n", p.Name, p.Node, changeset.Fingerprint(p.PublicKey))
+		i18n.Printf("    последний пакет: %s, получено до %d, подтверждено до %d\n", last, p.Received, p.Acked)
+	}
+	return exitOK
+}
+
+// syncInit дает БД новый идентификатор узла; нужен на копии файла БД
+func syncInit(env *commandEnv, args []string) int {
+	flags := flag.NewFlagSet("sync init", flag.ContinueOnError)
+	yes := flags.Bool("yes", false, i18n.T("не запрашивать подтверждение"))
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return exitUsage
+	}
+
+	dbManager, err := env.connect()
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка при подключении к БД: %v\n", err)
+		return exitProblems
+	}
+	defer dbManager.Close()
+
+	if !*yes {
+		fmt.Println(i18n.T("Команда выполняется на КОПИИ файла БД, перенесенной на другой пост."))
+		fmt.Println(i18n.T("БД получит новый идентификатор и ключ, а исходная БД станет доверенным узлом."))
+		fmt.Print(i18n.T("Продолжить? [y/N]: "))
+		input, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer := strings.ToLower(strings.TrimSpace(input))
+		if answer != "y" && answer != "д" {
+			fmt.Println(i18n.T("Отменено"))
+			return exitProblems
+		}
+	}
+
+	node, err := store.New(dbManager, "").ResetSyncNode()
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return exitProblems
+	}
+	i18n.Printf("✓ Новый узел: %s (%s)\n", node.Name, node.ID)
+	i18n.Printf("  Отпечаток ключа: %s\n", changeset.Fingerprint(node.PublicKey()))
+	return exitOK
+}
+
+// syncPassphrase возвращает общий ключ синхронизации: из файла, указанного
+// флагом или в конфигурации, иначе запрашивает его
+func syncPassphrase(env *commandEnv, keyFile string) (string, error) {
+	if keyFile == "" {
+		keyFile = env.cfg.Get().Sync.KeyFile
+	}
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return "", i18n.Errorf("не удалось прочитать ключ синхронизации: %w", err)
+		}
+		passphrase := strings.TrimSpace(string(data))
+		if passphrase == "" {
+			return "", i18n.Errorf("файл ключа синхронизации %s пуст", keyFile)
+		}
+		return passphrase, nil
+	}
+
+	passphrase, err := readSecret(i18n.T("Общий ключ синхронизации: "))
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", i18n.Errorf("ключ синхронизации не может быть пустым")
+	}
+	return passphrase, nil
+}
+
+// writeFileAtomic сохраняет файл через временный, чтобы не оставить недописанный
+func writeFileAtomic(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
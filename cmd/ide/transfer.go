@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+)
+
+// importConfirmation - слово, которое нужно ввести для подтверждения замены
+// БД; вводится на языке интерфейса
+const importConfirmation = "заменить"
+
+// runExport выгружает БД в незашифрованный файл SQLite или в архив JSON
+func runExport(env *commandEnv, args []string) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	plain := flags.String("plain", "", i18n.T("незашифрованный файл SQLite, в который выгружается БД"))
+	archivePath := flags.String("archive", "", i18n.T("zip архив JSON, в который выгружаются все записи"))
+	force := flags.Bool("force", false, i18n.T("перезаписать существующий файл"))
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	target := *plain + *archivePath
+	if (*plain == "") == (*archivePath == "") || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, i18n.T("Использование: jotnal export --plain <файл> | --archive <файл.zip> [--force]"))
+		return exitUsage
+	}
+	if samePath(target, env.cfg.Get().Database.Path) {
+		fmt.Fprintln(os.Stderr, i18n.T("Файл выгрузки совпадает с файлом БД"))
+		return exitUsage
+	}
+	if _, err := os.Stat(target); err == nil && !*force {
+		i18n.Fprintf(os.Stderr, "Файл %s уже существует, для перезаписи укажите --force\n", target)
+		return exitUsage
+	}
+
+	if *plain != "" {
+		return exportPlain(env, *plain)
+	}
+	return exportArchive(env, *archivePath)
+}
+
+// runImport загружает данные из незашифрованного файла SQLite или из архива JSON
+func runImport(env *commandEnv, args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	plain := flags.String("plain", "", i18n.T("незашифрованный файл SQLite, которым заменяется БД"))
+	archivePath := flags.String("archive", "", i18n.T("zip архив JSON, из которого загружаются записи"))
+	mode := flags.String("mode", "merge", i18n.T("режим загрузки архива: merge - объединить, replace - заменить все данные"))
+	yes := flags.Bool("yes", false, i18n.T("не запрашивать подтверждение"))
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	source := *plain + *archivePath
+	if (*plain == "") == (*archivePath == "") || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, i18n.T("Использование: jotnal import --plain <файл> | --archive <файл.zip> [--mode merge|replace] [--yes]"))
+		return exitUsage
+	}
+	if samePath(source, env.cfg.Get().Database.Path) {
+		fmt.Fprintln(os.Stderr, i18n.T("Файл импорта совпадает с файлом БД"))
+		return exitUsage
+	}
+
+	if *plain != "" {
+		return importPlain(env, *plain, *yes)
+	}
+	return importArchive(env, *archivePath, *mode, *yes)
+}
+
+// confirmReplace выводит предупреждение о замене данных и запрашивает подтверждение
+func confirmReplace(details ...string) bool {
+	fmt.Println()
+	fmt.Println("╔══════════════════════════════════════════════════════════════╗")
+	fmt.Println(i18n.T("║                         !!! ВНИМАНИЕ !!!                     ║"))
+	fmt.Println(i18n.T("║  Все данные текущей БД будут ЗАМЕНЕНЫ данными из файла.      ║"))
+	fmt.Println(i18n.T("║  Изменения, сделанные в БД после выгрузки файла, пропадут.   ║"))
+	fmt.Println(i18n.T("║  Закройте другие экземпляры Jotnal, работающие с этой БД.    ║"))
+	fmt.Println("╚══════════════════════════════════════════════════════════════╝")
+	for _, line := range details {
+		fmt.Println("  " + line)
+	}
+	fmt.Println(i18n.T("  Перед импортом будет создана резервная копия текущей БД."))
+
+	word := i18n.T(importConfirmation)
+	i18n.Printf("\nДля продолжения введите «%s»: ", word)
+	input, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.TrimSpace(input) != word {
+		fmt.Println(i18n.T("Импорт отменен"))
+		return false
+	}
+	return true
+}
+
+// samePath сообщает, что пути указывают на один файл
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
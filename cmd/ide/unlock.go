@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/deldim-kam/Jotnal/internal/database"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/secret"
+	"github.com/deldim-kam/Jotnal/internal/security"
+	"golang.org/x/term"
+)
+
+// connectDatabase получает пароль и подключается к БД. Неудачные попытки
+// учитываются в файле состояния рядом с БД; после нескольких ошибок подряд
+// вход блокируется с экспоненциально растущей задержкой. Перенести пароль из
+// config.json или настроить его хранение при первом запуске предлагается,
+// только если offerMigration: подкоманды для скриптов и дополнение не должны
+// выводить лишнего и ждать ответа.
+func connectDatabase(cfgManager *config.Manager, secrets *secret.Store, offerMigration bool) (*database.Manager, error) {
+	dbPath := cfgManager.Get().Database.Path
+	lockout := security.NewLockout(dbPath, cfgManager.Get().Security)
+
+	if err := cfgManager.Get().Database.ValidateAccess(); err != nil {
+		return nil, err
+	}
+
+	// О занятой БД сообщаем до запроса пароля
+	if cfgManager.Get().Database.SingleInstance {
+		if err := database.CheckInstance(dbPath); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		if wait := lockout.Remaining(); wait > 0 {
+			return nil, errors.New(i18n.Plural(lockout.Failures(),
+				"вход заблокирован после %d неудачных попыток, повторите через %s",
+				lockout.Failures(), security.FormatWait(wait)))
+		}
+
+		dbManager, err := tryConnect(cfgManager, secrets, offerMigration)
+		if err == nil {
+			if err := lockout.RecordSuccess(); err != nil {
+				i18n.Fprintf(os.Stderr, "Не удалось сбросить счетчик попыток: %v\n", err)
+			}
+			return dbManager, nil
+		}
+
+		if !errors.Is(err, database.ErrWrongPassword) && !errors.Is(err, secret.ErrWrongPassphrase) {
+			return nil, err
+		}
+
+		delay, lockErr := lockout.RecordFailure()
+		if lockErr != nil {
+			fmt.Fprintln(os.Stderr, lockErr)
+		}
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+
+		// Повторный ввод возможен только в интерактивных режимах
+		mode := secrets.Mode()
+		if mode != config.PasswordModePrompt && mode != config.PasswordModeKeyFile {
+			return nil, err
+		}
+		if delay > 0 {
+			return nil, i18n.Errorf("слишком много неудачных попыток, вход заблокирован на %s", security.FormatWait(delay))
+		}
+	}
+}
+
+// tryConnect выполняет одну попытку получить пароль и подключиться к БД
+func tryConnect(cfgManager *config.Manager, secrets *secret.Store, offerMigration bool) (*database.Manager, error) {
+	password, err := unlockDatabase(cfgManager, secrets, offerMigration)
+	if err != nil {
+		return nil, err
+	}
+
+	// Служебные сообщения - в stderr, чтобы не смешивать их с выводом подкоманд
+	cfg := cfgManager.Get()
+	i18n.Fprintf(os.Stderr, "\nПодключение к базе данных: %s\n", cfg.Database.Path)
+	dbManager, err := database.NewManager(cfg.Database.Path, password)
+	if err != nil {
+		return nil, err
+	}
+	dbManager.SetAccessOptions(accessOptions(cfg.Database))
+
+	if err := dbManager.Connect(); err != nil {
+		return nil, err
+	}
+
+	return dbManager, nil
+}
+
+// accessOptions переводит настройки совместного доступа из конфигурации
+func accessOptions(cfg config.DatabaseConfig) database.AccessOptions {
+	return database.AccessOptions{
+		JournalMode:    cfg.JournalMode,
+		BusyTimeout:    time.Duration(cfg.BusyTimeout) * time.Millisecond,
+		WriteRetries:   cfg.WriteRetries,
+		SingleInstance: cfg.SingleInstance,
+		ReadOnly:       cfg.ReadOnly,
+	}
+}
+
+// unlockDatabase возвращает пароль БД. При первом запуске предлагает выбрать
+// способ хранения пароля, а для старых конфигураций с паролем в открытом
+// виде - перенести его в более безопасное место (если offerMigration).
+func unlockDatabase(cfgManager *config.Manager, secrets *secret.Store, offerMigration bool) (string, error) {
+	switch secrets.Mode() {
+	case "":
+		if !offerMigration || !term.IsTerminal(int(syscall.Stdin)) {
+			return "", i18n.Errorf("способ хранения пароля БД не настроен: запустите jotnal в терминале без подкоманды или передайте пароль через --password-file, --password-fd или %s", config.EnvPasswordFile)
+		}
+		// БД уже создана, а config.json новый: пароль известен пользователю
+		if _, err := os.Stat(cfgManager.Get().Database.Path); err == nil {
+			return readSecret(i18n.T("Введите пароль для базы данных: "))
+		}
+		return setupPasswordStorage(cfgManager, secrets)
+
+	case config.PasswordModeConfig:
+		password, err := secrets.Load()
+		if err != nil {
+			return "", err
+		}
+		if offerMigration {
+			if err := offerSecretMigration(cfgManager, secrets, password); err != nil {
+				return "", err
+			}
+		}
+		return password, nil
+
+	case config.PasswordModePrompt:
+		// Для новой БД пароль вводится с подтверждением
+		if _, err := os.Stat(cfgManager.Get().Database.Path); os.IsNotExist(err) {
+			fmt.Fprintln(os.Stderr, i18n.T("\nБаза данных еще не создана: задайте пароль"))
+			return promptPassword(security.NewPolicy(cfgManager.Get().Security))
+		}
+	}
+
+	return secrets.Load()
+}
+
+// setupPasswordStorage настраивает хранение пароля при первом запуске.
+// Сообщения идут в stderr, как и при переносе пароля.
+func setupPasswordStorage(cfgManager *config.Manager, secrets *secret.Store) (string, error) {
+	fmt.Fprintln(os.Stderr, i18n.T("\nПервый запуск: необходимо установить пароль для базы данных"))
+
+	switch choosePasswordStorage(false) {
+	case config.PasswordModeKeyFile:
+		// Пароль БД генерируется случайно и известен только ключевому файлу
+		password, err := secret.RandomPassword()
+		if err != nil {
+			return "", err
+		}
+		passphrase, err := promptPassphrase(security.NewPolicy(cfgManager.Get().Security))
+		if err != nil {
+			return "", err
+		}
+		if err := secrets.SetupKeyFile(passphrase, password); err != nil {
+			return "", err
+		}
+		i18n.Fprintf(os.Stderr, "✓ Ключевой файл создан: %s\n", cfgManager.Get().Database.KeyFile)
+		return password, nil
+
+	default:
+		password, err := promptPassword(security.NewPolicy(cfgManager.Get().Security))
+		if err != nil {
+			return "", err
+		}
+		if err := cfgManager.UpdatePasswordStorage(config.PasswordModePrompt, "", ""); err != nil {
+			return "", i18n.Errorf("не удалось сохранить настройки: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, i18n.T("Пароль успешно установлен! Он будет запрашиваться при каждом запуске."))
+		return password, nil
+	}
+}
+
+// offerSecretMigration предлагает убрать пароль в открытом виде из config.json.
+// Сообщения идут в stderr, чтобы не смешиваться с выводом подкоманд.
+func offerSecretMigration(cfgManager *config.Manager, secrets *secret.Store, password string) error {
+	fmt.Fprintln(os.Stderr, i18n.T("\n⚠ Пароль БД хранится в config.json в открытом виде."))
+
+	if !term.IsTerminal(int(syscall.Stdin)) {
+		fmt.Fprintln(os.Stderr, i18n.T("Запустите приложение в терминале, чтобы перенести пароль в безопасное место."))
+		return nil
+	}
+
+	switch choosePasswordStorage(true) {
+	case config.PasswordModeKeyFile:
+		passphrase, err := promptPassphrase(security.NewPolicy(cfgManager.Get().Security))
+		if err != nil {
+			return i18n.Errorf("не удалось перенести пароль: %w", err)
+		}
+		if err := secrets.SetupKeyFile(passphrase, password); err != nil {
+			return i18n.Errorf("не удалось перенести пароль: %w", err)
+		}
+		i18n.Fprintf(os.Stderr, "✓ Пароль перенесен в ключевой файл %s и удален из config.json\n", cfgManager.Get().Database.KeyFile)
+
+	case config.PasswordModePrompt:
+		if err := cfgManager.UpdatePasswordStorage(config.PasswordModePrompt, "", ""); err != nil {
+			return i18n.Errorf("не удалось перенести пароль: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, i18n.T("✓ Пароль удален из config.json и будет запрашиваться при каждом запуске"))
+
+	default:
+		fmt.Fprintln(os.Stderr, i18n.T("Пароль оставлен в config.json"))
+	}
+
+	return nil
+}
+
+// choosePasswordStorage спрашивает, как хранить пароль БД.
+// Если allowKeep, можно оставить текущий способ (возвращается "").
+func choosePasswordStorage(allowKeep bool) string {
+	fmt.Fprintln(os.Stderr, i18n.T("\nКак хранить пароль БД?"))
+	fmt.Fprintln(os.Stderr, i18n.T("1. Запрашивать при каждом запуске"))
+	fmt.Fprintln(os.Stderr, i18n.T("2. Ключевой файл, защищенный парольной фразой (Argon2id)"))
+	if allowKeep {
+		fmt.Fprintln(os.Stderr, i18n.T("3. Оставить в config.json (не рекомендуется)"))
+	}
+	fmt.Fprint(os.Stderr, i18n.T("\nВыберите вариант (Enter - 1): "))
+
+	reader := bufio.NewReader(os.Stdin)
+	input, _ := reader.ReadString('\n')
+
+	switch strings.TrimSpace(input) {
+	case "2":
+		return config.PasswordModeKeyFile
+	case "3":
+		if allowKeep {
+			return ""
+		}
+	}
+	return config.PasswordModePrompt
+}
+
+// readSecret запрашивает секрет без отображения ввода
+func readSecret(label string) (string, error) {
+	fmt.Fprint(os.Stderr, label)
+	value, err := term.ReadPassword(int(syscall.Stdin))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", i18n.Errorf("не удалось прочитать ввод: %w", err)
+	}
+	return string(value), nil
+}
+
+// promptPassphrase запрашивает новую парольную фразу для ключевого файла
+func promptPassphrase(policy security.Policy) (string, error) {
+	i18n.Fprintf(os.Stderr, "Требования к парольной фразе: %s\n", policy.Describe())
+
+	for {
+		phrase, err := readSecret(i18n.T("Придумайте парольную фразу для ключевого файла: "))
+		if err != nil {
+			return "", err
+		}
+		if err := policy.Validate(phrase); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		i18n.Fprintf(os.Stderr, "Надежность фразы: %s\n", security.EstimateStrength(phrase))
+
+		confirm, err := readSecret(i18n.T("Повторите парольную фразу: "))
+		if err != nil {
+			return "", err
+		}
+
+		if phrase != confirm {
+			fmt.Fprintln(os.Stderr, i18n.T("Фразы не совпадают, попробуйте снова"))
+			continue
+		}
+		return phrase, nil
+	}
+}
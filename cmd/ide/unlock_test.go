@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/deldim-kam/Jotnal/internal/secret"
+)
+
+func TestUnlockDatabaseNotConfigured(t *testing.T) {
+	tests := []struct {
+		name     string
+		dbExists bool
+	}{
+		{"подкоманда при первом запуске", false},
+		{"подкоманда с существующей БД", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			cfg, err := config.NewManager(filepath.Join(dir, "config.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			dbPath := filepath.Join(dir, "jotnal.db")
+			if err := cfg.UpdateDatabasePath(dbPath); err != nil {
+				t.Fatal(err)
+			}
+			if tt.dbExists {
+				if err := os.WriteFile(dbPath, nil, 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			secrets := secret.NewStore(cfg, secret.Options{PasswordFD: -1})
+
+			_, err = unlockDatabase(cfg, secrets, false)
+			if err == nil || !strings.Contains(err.Error(), "--password-file") {
+				t.Errorf("unlockDatabase() = %v, ожидалась ошибка с подсказкой --password-file", err)
+			}
+			if mode := cfg.Get().Database.PasswordMode; mode != "" {
+				t.Errorf("способ хранения пароля изменен на %q", mode)
+			}
+		})
+	}
+}
@@ -2,15 +2,19 @@ module github.com/deldim-kam/Jotnal
 
 go 1.24.7
 
+require (
+	github.com/gdamore/tcell/v2 v2.13.4
+	github.com/mutecomm/go-sqlcipher/v4 v4.4.2
+	github.com/rivo/tview v0.42.0
+	golang.org/x/crypto v0.45.0
+	golang.org/x/sys v0.39.0
+	golang.org/x/term v0.38.0
+)
+
 require (
 	github.com/gdamore/encoding v1.0.1 // indirect
-	github.com/gdamore/tcell/v2 v2.13.4 // indirect
 	github.com/lucasb-eyer/go-colorful v1.3.0 // indirect
 	github.com/mattn/go-runewidth v0.0.16 // indirect
-	github.com/mutecomm/go-sqlcipher/v4 v4.4.2 // indirect
-	github.com/rivo/tview v0.42.0 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
-	golang.org/x/sys v0.39.0 // indirect
-	golang.org/x/term v0.38.0 // indirect
 	golang.org/x/text v0.31.0 // indirect
 )
@@ -0,0 +1,252 @@
+package archive
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/pkg/models"
+)
+
+// Format - идентификатор формата в манифесте
+const Format = "jotnal-archive"
+
+// FormatVersion - версия формата архива. Увеличивается, только если старая
+// версия приложения не сможет правильно прочитать новый архив.
+const FormatVersion = 1
+
+// manifestName - имя файла манифеста в архиве
+const manifestName = "manifest.json"
+
+// Имена наборов записей в архиве; файл набора - <имя>.jsonl
+const (
+	Projects        = "projects"
+	ProjectSettings = "project_settings"
+	Files           = "files"
+	FileHistory     = "file_history"
+	Bookmarks       = "bookmarks"
+	Snippets        = "snippets"
+	Employees       = "employees"
+	Shifts          = "shifts"
+	ShiftLog        = "shift_log"
+)
+
+// Sets - наборы записей в порядке загрузки: родительские раньше зависимых
+var Sets = []string{Projects, ProjectSettings, Files, FileHistory, Bookmarks, Snippets, Employees, Shifts, ShiftLog}
+
+// Manifest описывает архив
+type Manifest struct {
+	Format        string         `json:"format"`
+	FormatVersion int            `json:"format_version"`
+	SchemaVersion int            `json:"schema_version"` // версия схемы БД, из которой выгружен архив
+	CreatedAt     time.Time      `json:"created_at"`
+	Counts        map[string]int `json:"counts"`
+}
+
+// Data - все записи архива. В архиве это zip с манифестом и файлами JSON Lines
+// по одному на набор; поля записей соответствуют json тегам pkg/models, поэтому
+// архив читается установками с другой версией схемы: незнакомые поля
+// пропускаются, отсутствующие получают значения по умолчанию.
+type Data struct {
+	Projects        []models.Project
+	ProjectSettings []models.ProjectSettings
+	Files           []models.File
+	FileHistory     []models.FileHistory
+	Bookmarks       []models.Bookmark
+	Snippets        []models.Snippet
+	Employees       []models.Employee
+	Shifts          []models.Shift
+	ShiftLog        []models.ShiftEntry
+}
+
+// Counts возвращает число записей в каждом наборе
+func (d *Data) Counts() map[string]int {
+	return map[string]int{
+		Projects:        len(d.Projects),
+		ProjectSettings: len(d.ProjectSettings),
+		Files:           len(d.Files),
+		FileHistory:     len(d.FileHistory),
+		Bookmarks:       len(d.Bookmarks),
+		Snippets:        len(d.Snippets),
+		Employees:       len(d.Employees),
+		Shifts:          len(d.Shifts),
+		ShiftLog:        len(d.ShiftLog),
+	}
+}
+
+// Write сохраняет данные в архив path. Архив сначала пишется во временный
+// файл, чтобы при сбое не оставить недописанный.
+func Write(path string, schemaVersion int, data *Data) error {
+	tmpPath := path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return i18n.Errorf("не удалось создать архив: %w", err)
+	}
+
+	if err := write(f, schemaVersion, data); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return i18n.Errorf("не удалось сохранить архив: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return i18n.Errorf("не удалось сохранить архив: %w", err)
+	}
+	return nil
+}
+
+// write пишет манифест и наборы записей в zip
+func write(w io.Writer, schemaVersion int, data *Data) error {
+	zw := zip.NewWriter(w)
+
+	manifest := Manifest{
+		Format:        Format,
+		FormatVersion: FormatVersion,
+		SchemaVersion: schemaVersion,
+		CreatedAt:     time.Now(),
+		Counts:        data.Counts(),
+	}
+	mw, err := create(zw, manifestName, manifest.CreatedAt)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(mw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return err
+	}
+
+	writers := map[string]func(io.Writer) error{
+		Projects:        func(w io.Writer) error { return writeLines(w, data.Projects) },
+		ProjectSettings: func(w io.Writer) error { return writeLines(w, data.ProjectSettings) },
+		Files:           func(w io.Writer) error { return writeLines(w, data.Files) },
+		FileHistory:     func(w io.Writer) error { return writeLines(w, data.FileHistory) },
+		Bookmarks:       func(w io.Writer) error { return writeLines(w, data.Bookmarks) },
+		Snippets:        func(w io.Writer) error { return writeLines(w, data.Snippets) },
+		Employees:       func(w io.Writer) error { return writeLines(w, data.Employees) },
+		Shifts:          func(w io.Writer) error { return writeLines(w, data.Shifts) },
+		ShiftLog:        func(w io.Writer) error { return writeLines(w, data.ShiftLog) },
+	}
+	for _, set := range Sets {
+		w, err := create(zw, set+".jsonl", manifest.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if err := writers[set](w); err != nil {
+			return i18n.Errorf("не удалось записать %s: %w", set, err)
+		}
+	}
+
+	return zw.Close()
+}
+
+// create добавляет в архив сжатый файл с временем выгрузки
+func create(zw *zip.Writer, name string, modified time.Time) (io.Writer, error) {
+	return zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+}
+
+// writeLines пишет записи набора по одной JSON строке на запись
+func writeLines[T any](w io.Writer, records []T) error {
+	enc := json.NewEncoder(w)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read читает архив path. Архив незнакомого формата или более новой версии
+// формата отклоняется; отсутствующие наборы считаются пустыми.
+func Read(path string) (*Manifest, *Data, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, nil, i18n.Errorf("не удалось открыть архив: %w", err)
+	}
+	defer zr.Close()
+
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	mf, ok := files[manifestName]
+	if !ok {
+		return nil, nil, i18n.Errorf("в архиве нет %s, это не архив Jotnal", manifestName)
+	}
+	var manifest Manifest
+	if err := readJSON(mf, func(dec *json.Decoder) error { return dec.Decode(&manifest) }); err != nil {
+		return nil, nil, i18n.Errorf("не удалось прочитать %s: %w", manifestName, err)
+	}
+	if manifest.Format != Format {
+		return nil, nil, i18n.Errorf("неизвестный формат архива %q", manifest.Format)
+	}
+	if manifest.FormatVersion > FormatVersion {
+		return nil, nil, i18n.Errorf("версия формата архива %d новее поддерживаемой %d, обновите приложение",
+			manifest.FormatVersion, FormatVersion)
+	}
+
+	data := &Data{}
+	readers := map[string]func(*zip.File) error{
+		Projects:        func(f *zip.File) error { return readLines(f, &data.Projects) },
+		ProjectSettings: func(f *zip.File) error { return readLines(f, &data.ProjectSettings) },
+		Files:           func(f *zip.File) error { return readLines(f, &data.Files) },
+		FileHistory:     func(f *zip.File) error { return readLines(f, &data.FileHistory) },
+		Bookmarks:       func(f *zip.File) error { return readLines(f, &data.Bookmarks) },
+		Snippets:        func(f *zip.File) error { return readLines(f, &data.Snippets) },
+		Employees:       func(f *zip.File) error { return readLines(f, &data.Employees) },
+		Shifts:          func(f *zip.File) error { return readLines(f, &data.Shifts) },
+		ShiftLog:        func(f *zip.File) error { return readLines(f, &data.ShiftLog) },
+	}
+	for _, set := range Sets {
+		f, ok := files[set+".jsonl"]
+		if !ok {
+			continue
+		}
+		if err := readers[set](f); err != nil {
+			return nil, nil, i18n.Errorf("не удалось прочитать %s: %w", f.Name, err)
+		}
+	}
+
+	return &manifest, data, nil
+}
+
+// readLines читает записи набора, по одному JSON объекту на строку
+func readLines[T any](f *zip.File, records *[]T) error {
+	return readJSON(f, func(dec *json.Decoder) error {
+		for line := 1; ; line++ {
+			var record T
+			err := dec.Decode(&record)
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return i18n.Errorf("запись %d: %w", line, err)
+			}
+			*records = append(*records, record)
+		}
+	})
+}
+
+// readJSON открывает файл архива и передает его содержимое decode
+func readJSON(f *zip.File, decode func(*json.Decoder) error) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return decode(json.NewDecoder(r))
+}
@@ -0,0 +1,252 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/deldim-kam/Jotnal/internal/database"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+)
+
+// Reason описывает причину создания резервной копии
+type Reason string
+
+const (
+	ReasonInterval   Reason = "interval"
+	ReasonShiftClose Reason = "shift_close"
+	ReasonExit       Reason = "exit"
+	ReasonManual     Reason = "manual"
+	ReasonRepair     Reason = "repair"
+	ReasonImport     Reason = "import"
+	ReasonSync       Reason = "sync"
+)
+
+// String возвращает человекочитаемое описание причины
+func (r Reason) String() string {
+	switch r {
+	case ReasonInterval:
+		return i18n.T("по расписанию")
+	case ReasonShiftClose:
+		return i18n.T("закрытие смены")
+	case ReasonExit:
+		return i18n.T("выход из приложения")
+	case ReasonManual:
+		return i18n.T("вручную")
+	case ReasonRepair:
+		return i18n.T("перед исправлением БД")
+	case ReasonImport:
+		return i18n.T("перед импортом БД")
+	case ReasonSync:
+		return i18n.T("перед синхронизацией")
+	}
+	return string(r)
+}
+
+// filePrefix - префикс имен файлов резервных копий
+const filePrefix = "jotnal-"
+
+// Event описывает ход резервного копирования
+type Event struct {
+	Reason   Reason
+	Path     string
+	Started  time.Time
+	Duration time.Duration
+	Done     bool  // false - копирование началось, true - завершилось
+	Err      error // ошибка, если копирование завершилось неудачно
+}
+
+// Scheduler выполняет резервное копирование в фоне по расписанию и по событиям
+type Scheduler struct {
+	db     *database.Manager
+	cfg    config.BackupConfig
+	notify func(Event)
+
+	trigger chan Reason
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.Mutex // не допускает одновременного копирования
+	started atomic.Bool
+}
+
+// NewScheduler создает планировщик резервного копирования.
+// notify вызывается из фоновой горутины и не должен блокироваться.
+func NewScheduler(db *database.Manager, cfg config.BackupConfig, notify func(Event)) *Scheduler {
+	if notify == nil {
+		notify = func(Event) {}
+	}
+
+	return &Scheduler{
+		db:      db,
+		cfg:     cfg,
+		notify:  notify,
+		trigger: make(chan Reason, 4),
+		stop:    make(chan struct{}),
+	}
+}
+
+// Start запускает фоновый цикл планировщика
+func (s *Scheduler) Start() error {
+	interval, err := s.cfg.IntervalDuration()
+	if err != nil {
+		return err
+	}
+
+	if !s.cfg.Enabled || !s.started.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	s.wg.Add(1)
+	go s.loop(interval)
+
+	return nil
+}
+
+// loop обрабатывает срабатывания таймера и внешние запросы на копирование
+func (s *Scheduler) loop(interval time.Duration) {
+	defer s.wg.Done()
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-tick:
+			s.run(ReasonInterval)
+		case reason := <-s.trigger:
+			// Настройки читаются здесь: Reconfigure меняет их, только
+			// остановив цикл
+			if reason == ReasonShiftClose && !s.cfg.OnShiftClose {
+				continue
+			}
+			s.run(reason)
+		}
+	}
+}
+
+// Trigger ставит в очередь внеплановое копирование и сразу возвращает управление.
+// Копирование по закрытию смены выполняется только если оно включено в настройках.
+func (s *Scheduler) Trigger(reason Reason) {
+	if !s.started.Load() {
+		return
+	}
+
+	select {
+	case s.trigger <- reason:
+	default:
+		// Очередь заполнена - копирование и так скоро будет выполнено
+	}
+}
+
+// Stop останавливает фоновый цикл и дожидается завершения текущего копирования
+func (s *Scheduler) Stop() {
+	if !s.started.CompareAndSwap(true, false) {
+		return
+	}
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// Reconfigure применяет новые настройки: фоновый цикл останавливается и
+// запускается заново. Если настройки не изменились, ничего не делает, чтобы
+// не сбивать отсчет интервала.
+func (s *Scheduler) Reconfigure(cfg config.BackupConfig) error {
+	if cfg == s.cfg {
+		return nil
+	}
+
+	s.Stop()
+	s.mu.Lock()
+	s.cfg = cfg
+	s.mu.Unlock()
+	s.stop = make(chan struct{})
+
+	return s.Start()
+}
+
+// RunAsync создает копию в отдельной горутине независимо от расписания.
+// О ходе копирования сообщается через notify.
+func (s *Scheduler) RunAsync(reason Reason) {
+	go s.run(reason)
+}
+
+// RunNow синхронно создает резервную копию и возвращает путь к ней.
+// Используется для копирования при выходе, когда фоновый цикл уже остановлен.
+func (s *Scheduler) RunNow(reason Reason) (string, error) {
+	ev := s.backup(reason)
+	return ev.Path, ev.Err
+}
+
+// run создает копию и сообщает о ее ходе через notify
+func (s *Scheduler) run(reason Reason) {
+	s.notify(Event{Reason: reason, Started: time.Now()})
+	s.notify(s.backup(reason))
+}
+
+// backup создает копию и удаляет устаревшие
+func (s *Scheduler) backup(reason Reason) Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ev := Event{Reason: reason, Started: time.Now(), Done: true}
+	ev.Path = filepath.Join(s.directory(), filePrefix+ev.Started.Format("20060102-150405")+".db")
+
+	if err := s.db.Backup(ev.Path); err != nil {
+		ev.Err = err
+	} else if err := s.prune(); err != nil {
+		ev.Err = i18n.Errorf("копия создана, но не удалось удалить старые: %w", err)
+	}
+
+	ev.Duration = time.Since(ev.Started)
+	return ev
+}
+
+// directory возвращает директорию для резервных копий
+func (s *Scheduler) directory() string {
+	if s.cfg.Directory != "" {
+		return s.cfg.Directory
+	}
+	return filepath.Join(filepath.Dir(s.db.GetPath()), "backups")
+}
+
+// prune оставляет только KeepLast самых свежих копий
+func (s *Scheduler) prune() error {
+	if s.cfg.KeepLast <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(s.directory())
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, filePrefix) && strings.HasSuffix(name, ".db") {
+			names = append(names, name)
+		}
+	}
+
+	// Имена содержат метку времени, поэтому сортировка по имени - это сортировка по дате
+	sort.Strings(names)
+	for len(names) > s.cfg.KeepLast {
+		if err := os.Remove(filepath.Join(s.directory(), names[0])); err != nil {
+			return err
+		}
+		names = names[1:]
+	}
+
+	return nil
+}
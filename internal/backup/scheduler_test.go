@@ -0,0 +1,76 @@
+package backup
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/deldim-kam/Jotnal/internal/database"
+)
+
+func TestSchedulerTrigger(t *testing.T) {
+	dir := t.TempDir()
+	db, err := database.NewManager(filepath.Join(dir, "jotnal.db"), "test-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	done := make(chan Event, 16)
+	s := NewScheduler(db, config.BackupConfig{Enabled: true, Directory: filepath.Join(dir, "backups")}, func(ev Event) {
+		if ev.Done {
+			done <- ev
+		}
+	})
+
+	// Trigger вызывается из другой горутины, пока настройки меняются
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				s.Trigger(ReasonShiftClose) // копирование по закрытию смены выключено
+			}
+		}
+	}()
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 10 {
+		cfg := s.cfg
+		cfg.Enabled = i%2 == 1
+		if err := s.Reconfigure(cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	s.Trigger(ReasonManual)
+	select {
+	case ev := <-done:
+		if ev.Err != nil || ev.Reason != ReasonManual {
+			t.Errorf("копирование: %+v", ev)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("копирование не выполнено")
+	}
+
+	s.Stop()
+	s.Trigger(ReasonManual)
+	select {
+	case ev := <-done:
+		t.Errorf("копирование после остановки: %+v", ev)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,197 @@
+package changeset
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"golang.org/x/crypto/argon2"
+)
+
+// ErrWrongKey возвращается, если пакет не удалось расшифровать общим ключом
+var ErrWrongKey = i18n.NewError("неверный ключ синхронизации или пакет поврежден")
+
+// ErrBadSignature возвращается, если подпись пакета не сходится
+var ErrBadSignature = i18n.NewError("подпись пакета недействительна, пакет изменен после создания")
+
+// Format - идентификатор формата пакета
+const Format = "jotnal-sync"
+
+// FormatVersion - версия формата пакета
+const FormatVersion = 1
+
+// Длины соли Argon2 и nonce AES-GCM в пакете
+const (
+	saltSize  = 16
+	nonceSize = 12
+)
+
+// Ref - глобальная ссылка на запись: узел, на котором запись создана, и ее ID там
+type Ref struct {
+	Node string `json:"node"`
+	ID   int64  `json:"id"`
+}
+
+// Change - одна запись журнала изменений узла-отправителя
+type Change struct {
+	Seq    int64                  `json:"seq"` // ID записи журнала на отправителе
+	Entity string                 `json:"entity"`
+	Ref    Ref                    `json:"ref"`
+	Action string                 `json:"action"`
+	Actor  string                 `json:"actor"`
+	Time   time.Time              `json:"time"`
+	Before map[string]interface{} `json:"before,omitempty"` // ссылки на записи заменены на Ref
+	After  map[string]interface{} `json:"after,omitempty"`
+}
+
+// Changeset - пакет изменений узла с момента последней синхронизации
+type Changeset struct {
+	Node      string           `json:"node"`
+	Name      string           `json:"name"` // имя компьютера отправителя
+	CreatedAt time.Time        `json:"created_at"`
+	Since     int64            `json:"since"` // в пакете изменения с Seq больше Since
+	Ack       map[string]int64 `json:"ack"`   // последние примененные изменения других узлов
+	Changes   []Change         `json:"changes"`
+}
+
+// envelope - зашифрованный и подписанный пакет в файле
+type envelope struct {
+	Format        string `json:"format"`
+	FormatVersion int    `json:"format_version"`
+	Node          string `json:"node"`
+	PublicKey     []byte `json:"public_key"`
+	Time          uint32 `json:"time"`
+	Memory        uint32 `json:"memory"` // КиБ
+	Threads       uint8  `json:"threads"`
+	Salt          []byte `json:"salt"`
+	Nonce         []byte `json:"nonce"`
+	Ciphertext    []byte `json:"ciphertext"`
+	Signature     []byte `json:"signature"`
+}
+
+// Seal шифрует пакет ключом, выведенным из общей фразы, и подписывает его
+// ключом узла. Подпись покрывает шифртекст, поэтому проверяется до расшифровки.
+func Seal(cs *Changeset, passphrase string, key ed25519.PrivateKey) ([]byte, error) {
+	plain, err := json.Marshal(cs)
+	if err != nil {
+		return nil, err
+	}
+
+	env := &envelope{
+		Format:        Format,
+		FormatVersion: FormatVersion,
+		Node:          cs.Node,
+		PublicKey:     key.Public().(ed25519.PublicKey),
+		Time:          3,
+		Memory:        64 * 1024,
+		Threads:       4,
+		Salt:          make([]byte, saltSize),
+	}
+	if _, err := rand.Read(env.Salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := env.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	env.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return nil, err
+	}
+	env.Ciphertext = aead.Seal(nil, env.Nonce, plain, env.aad())
+	env.Signature = ed25519.Sign(key, env.signed())
+
+	return json.MarshalIndent(env, "", "  ")
+}
+
+// Open проверяет подпись пакета, расшифровывает его и возвращает вместе с
+// открытым ключом отправителя. Доверять ли ключу, решает вызывающий.
+func Open(data []byte, passphrase string) (*Changeset, ed25519.PublicKey, error) {
+	env := &envelope{}
+	if err := json.Unmarshal(data, env); err != nil || env.Format != Format {
+		return nil, nil, i18n.Errorf("файл не является пакетом синхронизации Jotnal")
+	}
+	if env.FormatVersion > FormatVersion {
+		return nil, nil, i18n.Errorf("версия формата пакета %d новее поддерживаемой %d, обновите приложение",
+			env.FormatVersion, FormatVersion)
+	}
+	// Параметры Argon2 задает отправитель: не даем занять всю память
+	if env.Time == 0 || env.Time > 10 || env.Memory > 1024*1024 || env.Threads == 0 {
+		return nil, nil, i18n.Errorf("недопустимые параметры ключа в пакете")
+	}
+	// Пакет может подписать кто угодно, поэтому длины проверяются до GCM,
+	// который на чужой длине nonce паникует
+	if len(env.Salt) != saltSize || len(env.Nonce) != nonceSize {
+		return nil, nil, i18n.Errorf("недопустимая длина соли или nonce в пакете")
+	}
+	if len(env.PublicKey) != ed25519.PublicKeySize || !ed25519.Verify(env.PublicKey, env.signed(), env.Signature) {
+		return nil, nil, ErrBadSignature
+	}
+
+	aead, err := env.aead(passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	plain, err := aead.Open(nil, env.Nonce, env.Ciphertext, env.aad())
+	if err != nil {
+		return nil, nil, ErrWrongKey
+	}
+
+	cs := &Changeset{}
+	if err := json.Unmarshal(plain, cs); err != nil {
+		return nil, nil, i18n.Errorf("поврежденный пакет синхронизации: %w", err)
+	}
+	if cs.Node != env.Node {
+		return nil, nil, ErrBadSignature
+	}
+
+	return cs, env.PublicKey, nil
+}
+
+// Fingerprint возвращает отпечаток открытого ключа для сверки узлов человеком
+func Fingerprint(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	hexSum := hex.EncodeToString(sum[:8])
+
+	groups := make([]string, 0, len(hexSum)/4)
+	for i := 0; i < len(hexSum); i += 4 {
+		groups = append(groups, hexSum[i:i+4])
+	}
+	return strings.ToUpper(strings.Join(groups, "-"))
+}
+
+// aead создает AES-256-GCM с ключом, выведенным из общей фразы через Argon2id
+func (e *envelope) aead(passphrase string) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(passphrase), e.Salt, e.Time, e.Memory, e.Threads, 32)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// aad связывает шифртекст с форматом и узлом-отправителем
+func (e *envelope) aad() []byte {
+	return []byte(fmt.Sprintf("%s-v%d:%s", Format, e.FormatVersion, e.Node))
+}
+
+// signed возвращает подписываемые данные пакета
+func (e *envelope) signed() []byte {
+	var b []byte
+	b = append(b, e.aad()...)
+	b = append(b, fmt.Sprintf(":%d:%d:%d", e.Time, e.Memory, e.Threads)...)
+	b = append(b, 0)
+	b = append(b, e.Salt...)
+	b = append(b, e.Nonce...)
+	b = append(b, e.Ciphertext...)
+	return b
+}
@@ -0,0 +1,175 @@
+package changeset
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestKey создает ключ узла для подписи пакетов
+func newTestKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// testChangeset возвращает пакет с одним изменением
+func testChangeset() *Changeset {
+	return &Changeset{
+		Node:      "node-a",
+		Name:      "host-a",
+		CreatedAt: time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC),
+		Changes: []Change{{
+			Seq:    1,
+			Entity: "project",
+			Ref:    Ref{Node: "node-a", ID: 1},
+			Action: "create",
+			After:  map[string]interface{}{"name": "Альфа"},
+		}},
+	}
+}
+
+// resign подписывает измененный конверт ключом key, как это сделал бы
+// отправитель поддельного пакета
+func resign(t *testing.T, env *envelope, key ed25519.PrivateKey) []byte {
+	t.Helper()
+
+	env.PublicKey = key.Public().(ed25519.PublicKey)
+	env.Signature = ed25519.Sign(key, env.signed())
+	data, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestSealOpen(t *testing.T) {
+	key := newTestKey(t)
+	data, err := Seal(testChangeset(), "общая фраза", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cs, pub, err := Open(data, "общая фраза")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Equal(key.Public()) {
+		t.Error("Open вернул чужой открытый ключ")
+	}
+	if cs.Node != "node-a" || len(cs.Changes) != 1 || cs.Changes[0].After["name"] != "Альфа" {
+		t.Errorf("пакет после расшифровки: %+v", cs)
+	}
+}
+
+func TestOpenRejectsTamperedPackages(t *testing.T) {
+	key := newTestKey(t)
+	sealed, err := Seal(testChangeset(), "общая фраза", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		tamper  func(t *testing.T, env *envelope) []byte
+		wantErr error // nil - любая ошибка
+	}{
+		{"неверная фраза", func(t *testing.T, env *envelope) []byte {
+			data, _ := json.Marshal(env)
+			return data
+		}, ErrWrongKey},
+		{"изменен шифртекст", func(t *testing.T, env *envelope) []byte {
+			env.Ciphertext[0] ^= 0xff
+			data, _ := json.Marshal(env)
+			return data
+		}, ErrBadSignature},
+		{"подменен узел", func(t *testing.T, env *envelope) []byte {
+			env.Node = "node-b"
+			data, _ := json.Marshal(env)
+			return data
+		}, ErrBadSignature},
+		{"обрезанная подпись", func(t *testing.T, env *envelope) []byte {
+			env.Signature = env.Signature[:10]
+			data, _ := json.Marshal(env)
+			return data
+		}, ErrBadSignature},
+		{"короткий nonce с новой подписью", func(t *testing.T, env *envelope) []byte {
+			env.Nonce = env.Nonce[:3]
+			return resign(t, env, newTestKey(t))
+		}, nil},
+		{"без nonce с новой подписью", func(t *testing.T, env *envelope) []byte {
+			env.Nonce = nil
+			return resign(t, env, newTestKey(t))
+		}, nil},
+		{"короткая соль с новой подписью", func(t *testing.T, env *envelope) []byte {
+			env.Salt = env.Salt[:4]
+			return resign(t, env, newTestKey(t))
+		}, nil},
+		{"огромная память", func(t *testing.T, env *envelope) []byte {
+			env.Memory = 1 << 31
+			return resign(t, env, newTestKey(t))
+		}, nil},
+		{"нулевые потоки", func(t *testing.T, env *envelope) []byte {
+			env.Threads = 0
+			return resign(t, env, newTestKey(t))
+		}, nil},
+		{"чужой узел внутри пакета", func(t *testing.T, env *envelope) []byte {
+			// Пакет node-a переупакован в конверт node-b с верной подписью
+			env.Node = "node-b"
+			aead, err := env.aead("общая фраза")
+			if err != nil {
+				t.Fatal(err)
+			}
+			plain, _ := json.Marshal(testChangeset())
+			env.Ciphertext = aead.Seal(nil, env.Nonce, plain, env.aad())
+			return resign(t, env, newTestKey(t))
+		}, ErrBadSignature},
+		{"новая версия формата", func(t *testing.T, env *envelope) []byte {
+			env.FormatVersion = FormatVersion + 1
+			data, _ := json.Marshal(env)
+			return data
+		}, nil},
+		{"не пакет", func(t *testing.T, env *envelope) []byte {
+			return []byte(`{"format":"other"}`)
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := &envelope{}
+			if err := json.Unmarshal(sealed, env); err != nil {
+				t.Fatal(err)
+			}
+			data := tt.tamper(t, env)
+
+			passphrase := "общая фраза"
+			if tt.wantErr == ErrWrongKey {
+				passphrase = "другая фраза"
+			}
+			_, _, err := Open(data, passphrase)
+			if err == nil {
+				t.Fatal("ожидалась ошибка")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Open() = %v, ожидалась %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	key := make(ed25519.PublicKey, ed25519.PublicKeySize)
+	got := Fingerprint(key)
+	if len(got) != 19 || got[4] != '-' {
+		t.Errorf("Fingerprint() = %q, ожидалось четыре группы по четыре символа", got)
+	}
+	if Fingerprint(newTestKey(t).Public().(ed25519.PublicKey)) == got {
+		t.Error("разные ключи дали одинаковый отпечаток")
+	}
+}
@@ -1,21 +1,87 @@
 package config
 
 import (
-	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
 )
 
 // Config представляет конфигурацию приложения
 type Config struct {
+	Version   int             `json:"version"` // версия схемы файла, см. CurrentVersion
 	Database  DatabaseConfig  `json:"database"`
 	Interface InterfaceConfig `json:"interface"`
+	Backup    BackupConfig    `json:"backup"`
+	Security  SecurityConfig  `json:"security"`
+	Trash     TrashConfig     `json:"trash"`
+	Sync      SyncConfig      `json:"sync"`
 }
 
+// Способы хранения пароля БД
+const (
+	PasswordModeConfig  = "config"  // в config.json в открытом виде (устаревший)
+	PasswordModePrompt  = "prompt"  // запрашивается при каждом запуске
+	PasswordModeFile    = "file"    // читается из файла password_file
+	PasswordModeFD      = "fd"      // читается из файлового дескриптора password_fd
+	PasswordModeKeyFile = "keyfile" // хранится в key_file под парольной фразой
+)
+
 // DatabaseConfig содержит настройки базы данных
 type DatabaseConfig struct {
-	Path     string `json:"path"`
-	Password string `json:"password"`
+	Path         string `json:"path"`
+	Password     string `json:"password,omitempty" secret:"true"` // только для режима config
+	PasswordMode string `json:"password_mode"`
+	PasswordFile string `json:"password_file,omitempty"`
+	PasswordFD   int    `json:"password_fd,omitempty"`
+	KeyFile      string `json:"key_file,omitempty"`
+
+	// Совместный доступ нескольких процессов к одному файлу БД
+	JournalMode    string `json:"journal_mode"`    // wal или delete
+	BusyTimeout    int    `json:"busy_timeout"`    // мс ожидания занятой БД
+	WriteRetries   int    `json:"write_retries"`   // повторов записи, если БД все еще занята
+	SingleInstance bool   `json:"single_instance"` // не открывать БД, если она открыта другим экземпляром
+	ReadOnly       bool   `json:"read_only"`       // открывать БД только для чтения
+
+	SizeWarningMB int `json:"size_warning_mb"` // предупреждать, если файл БД больше, МБ; 0 - не предупреждать
+}
+
+// Режимы журнала SQLite
+const (
+	JournalModeWAL    = "wal"
+	JournalModeDelete = "delete"
+)
+
+// ValidateAccess проверяет параметры совместного доступа к БД
+func (d DatabaseConfig) ValidateAccess() error {
+	switch d.JournalMode {
+	case JournalModeWAL, JournalModeDelete:
+	default:
+		return i18n.Errorf("неверный режим журнала %q (допустимо: wal, delete)", d.JournalMode)
+	}
+	if d.BusyTimeout < 0 {
+		return i18n.Errorf("время ожидания БД не может быть отрицательным")
+	}
+	if d.WriteRetries < 0 {
+		return i18n.Errorf("число повторов записи не может быть отрицательным")
+	}
+	return nil
+}
+
+// SizeWarning возвращает размер БД в байтах, после которого выводится предупреждение (0 - никогда)
+func (d DatabaseConfig) SizeWarning() int64 {
+	return int64(d.SizeWarningMB) << 20
+}
+
+// EffectivePasswordMode возвращает способ хранения пароля с учетом старых
+// конфигураций, в которых режим не указан ("" - пароль еще не задан)
+func (d DatabaseConfig) EffectivePasswordMode() string {
+	if d.PasswordMode == "" && d.Password != "" {
+		return PasswordModeConfig
+	}
+	return d.PasswordMode
 }
 
 // InterfaceConfig содержит настройки интерфейса
@@ -26,23 +92,124 @@ type InterfaceConfig struct {
 		Width  int `json:"width"`
 		Height int `json:"height"`
 	} `json:"window_size"`
-	Language string `json:"language"`
+	Language string                 `json:"language"`
+	UI       string                 `json:"ui"`               // интерфейс при запуске: tui, menu, none; пусто - спросить
+	Themes   map[string]ThemeColors `json:"themes,omitempty"` // пользовательские темы по имени
+	// Переназначенные клавиши: экран -> действие -> клавиша; пустая клавиша
+	// или отсутствующее действие - клавиша по умолчанию
+	KeyBindings map[string]map[string]string `json:"key_bindings,omitempty"`
+}
+
+// ThemeColors - пользовательская тема: цвета задаются именем цвета терминала
+// (yellow, darkcyan), в виде #rrggbb или default (цвет терминала).
+// Пустой цвет берется из темы base.
+type ThemeColors struct {
+	Base string `json:"base,omitempty"` // встроенная тема, на которой основана пользовательская; пусто - dark
+
+	Background             string `json:"background,omitempty"`
+	ContrastBackground     string `json:"contrast_background,omitempty"` // поля ввода и кнопки
+	MoreContrastBackground string `json:"more_contrast_background,omitempty"`
+	Border                 string `json:"border,omitempty"`
+	Title                  string `json:"title,omitempty"`
+	Graphics               string `json:"graphics,omitempty"` // линии таблиц
+	Text                   string `json:"text,omitempty"`
+	Label                  string `json:"label,omitempty"`  // подписи и заголовки столбцов
+	Accent                 string `json:"accent,omitempty"` // горячие клавиши и сообщения об успехе
+	Error                  string `json:"error,omitempty"`
+	Muted                  string `json:"muted,omitempty"` // второстепенный текст
+	InverseText            string `json:"inverse_text,omitempty"`
+	ContrastLabel          string `json:"contrast_label,omitempty"` // подписи на фоне contrast_background
+}
+
+// BackupConfig содержит настройки автоматического резервного копирования
+type BackupConfig struct {
+	Enabled      bool   `json:"enabled"`
+	Directory    string `json:"directory"`
+	Interval     string `json:"interval"` // off, hourly, daily или длительность Go (например, 30m)
+	OnShiftClose bool   `json:"on_shift_close"`
+	OnExit       bool   `json:"on_exit"`
+	KeepLast     int    `json:"keep_last"` // 0 - хранить все копии
+}
+
+// IntervalDuration возвращает период резервного копирования (0 - отключено)
+func (b BackupConfig) IntervalDuration() (time.Duration, error) {
+	switch b.Interval {
+	case "", "off":
+		return 0, nil
+	case "hourly":
+		return time.Hour, nil
+	case "daily":
+		return 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(b.Interval)
+	if err != nil {
+		return 0, i18n.Errorf("неверный интервал резервного копирования %q", b.Interval)
+	}
+	if d < time.Minute {
+		return 0, i18n.Errorf("интервал резервного копирования не может быть меньше минуты")
+	}
+	return d, nil
+}
+
+// SecurityConfig содержит политику паролей и параметры блокировки входа
+type SecurityConfig struct {
+	MinPasswordLength int `json:"min_password_length"`
+	MinCharClasses    int `json:"min_char_classes"` // из 4: строчные, прописные, цифры, символы
+
+	LockoutThreshold int `json:"lockout_threshold"`  // неудачных попыток до блокировки
+	LockoutBaseDelay int `json:"lockout_base_delay"` // секунд, удваивается с каждой попыткой
+	LockoutMaxDelay  int `json:"lockout_max_delay"`  // секунд
+
+	IdleLockMinutes int    `json:"idle_lock_minutes"`                // автоблокировка интерфейса, 0 - отключена
+	PINHash         string `json:"pin_hash,omitempty" secret:"true"` // хеш PIN для разблокировки (Argon2id)
+}
+
+// TrashConfig содержит настройки корзины удаленных записей
+type TrashConfig struct {
+	RetentionDays int `json:"retention_days"` // через сколько дней записи удаляются окончательно, 0 - никогда
+}
+
+// Retention возвращает срок хранения записей в корзине (0 - бессрочно)
+func (t TrashConfig) Retention() time.Duration {
+	return time.Duration(t.RetentionDays) * 24 * time.Hour
+}
+
+// Стратегии разрешения конфликтов синхронизации
+const (
+	SyncStrategyField = "field" // поля объединяются, одновременные изменения поля - конфликт
+	SyncStrategyLWW   = "lww"   // одновременно измененное поле получает более позднее значение
+)
+
+// SyncConfig содержит настройки синхронизации с другими БД
+type SyncConfig struct {
+	Strategy string `json:"strategy"` // field или lww
+	KeyFile  string `json:"key_file"` // файл с общим ключом пакетов, пусто - запрашивать
+}
+
+// ValidateStrategy проверяет стратегию разрешения конфликтов
+func ValidateStrategy(strategy string) error {
+	switch strategy {
+	case SyncStrategyField, SyncStrategyLWW:
+		return nil
+	}
+	return i18n.Errorf("неверная стратегия синхронизации %q (допустимо: field, lww)", strategy)
 }
 
 // Manager управляет конфигурацией приложения
 type Manager struct {
 	configPath string
-	config     *Config
+	config     *Config   // значения config.json
+	overrides  Overrides // параметры запуска поверх config.json
 }
 
-// NewManager создает новый менеджер конфигурации
+// NewManager создает новый менеджер конфигурации. Пустой configPath -
+// ~/.jotnal/config.json; файлы по умолчанию (БД, ключевой файл, резервные
+// копии) располагаются рядом с конфигурацией.
 func NewManager(configPath string) (*Manager, error) {
-	if configPath == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return nil, err
-		}
-		configPath = filepath.Join(homeDir, ".jotnal", "config.json")
+	configPath, err := ResolvePath(configPath)
+	if err != nil {
+		return nil, err
 	}
 
 	m := &Manager{
@@ -59,7 +226,7 @@ func NewManager(configPath string) (*Manager, error) {
 	if err := m.Load(); err != nil {
 		// Если файл не существует, создаем конфигурацию по умолчанию
 		if os.IsNotExist(err) {
-			m.config = m.defaultConfig()
+			m.config = defaultConfig(configDir)
 			if err := m.Save(); err != nil {
 				return nil, err
 			}
@@ -71,73 +238,296 @@ func NewManager(configPath string) (*Manager, error) {
 	return m, nil
 }
 
-// defaultConfig возвращает конфигурацию по умолчанию
-func (m *Manager) defaultConfig() *Config {
-	homeDir, _ := os.UserHomeDir()
-	defaultDBPath := filepath.Join(homeDir, ".jotnal", "jotnal.db")
+// defaultConfig возвращает конфигурацию по умолчанию для config.json в каталоге dir
+func defaultConfig(dir string) *Config {
+	defaultDBPath := filepath.Join(dir, "jotnal.db")
 
 	cfg := &Config{
 		Database: DatabaseConfig{
-			Path:     defaultDBPath,
-			Password: "", // Пароль будет установлен при первом запуске
-		},
-		Interface: InterfaceConfig{
-			Theme:    "dark",
-			FontSize: 14,
-			Language: "ru",
+			Path: defaultDBPath,
+			// Способ хранения пароля выбирается при первом запуске
+			KeyFile:       filepath.Join(dir, "db.key"),
+			JournalMode:   JournalModeWAL,
+			BusyTimeout:   5000,
+			WriteRetries:  3,
+			SizeWarningMB: 500,
 		},
+		Interface: defaultInterfaceConfig(),
+		Backup:    defaultBackupConfig(dir),
+		Security:  defaultSecurityConfig(),
+		Trash:     defaultTrashConfig(),
+		Sync:      defaultSyncConfig(),
 	}
 
-	cfg.Interface.WindowSize.Width = 1280
-	cfg.Interface.WindowSize.Height = 720
+	return cfg
+}
+
+// loadDefaults возвращает значения, которые получают параметры, отсутствующие
+// в config.json: старые конфигурации не содержат новых параметров
+func loadDefaults(dir string) *Config {
+	return &Config{
+		Database: DatabaseConfig{
+			KeyFile:       filepath.Join(dir, "db.key"),
+			JournalMode:   JournalModeWAL,
+			BusyTimeout:   5000,
+			WriteRetries:  3,
+			SizeWarningMB: 500,
+		},
+		Interface: defaultInterfaceConfig(),
+		Backup:    defaultBackupConfig(dir),
+		Security:  defaultSecurityConfig(),
+		Trash:     defaultTrashConfig(),
+		Sync:      defaultSyncConfig(),
+	}
+}
 
+// defaultInterfaceConfig возвращает настройки интерфейса по умолчанию
+func defaultInterfaceConfig() InterfaceConfig {
+	cfg := InterfaceConfig{
+		Theme:    ThemeDark,
+		FontSize: 14,
+		Language: LanguageRU,
+	}
+	cfg.WindowSize.Width = 1280
+	cfg.WindowSize.Height = 720
 	return cfg
 }
 
-// Load загружает конфигурацию из файла
+// defaultBackupConfig возвращает настройки резервного копирования по умолчанию
+// для конфигурации в каталоге dir
+func defaultBackupConfig(dir string) BackupConfig {
+	return BackupConfig{
+		Enabled:      true,
+		Directory:    filepath.Join(dir, "backups"),
+		Interval:     "hourly",
+		OnShiftClose: true,
+		OnExit:       true,
+		KeepLast:     24,
+	}
+}
+
+// defaultSecurityConfig возвращает политику безопасности по умолчанию
+func defaultSecurityConfig() SecurityConfig {
+	return SecurityConfig{
+		MinPasswordLength: 10,
+		MinCharClasses:    3,
+		LockoutThreshold:  3,
+		LockoutBaseDelay:  30,
+		LockoutMaxDelay:   3600,
+		IdleLockMinutes:   10,
+	}
+}
+
+// defaultTrashConfig возвращает настройки корзины по умолчанию
+func defaultTrashConfig() TrashConfig {
+	return TrashConfig{
+		RetentionDays: 30,
+	}
+}
+
+// defaultSyncConfig возвращает настройки синхронизации по умолчанию
+func defaultSyncConfig() SyncConfig {
+	return SyncConfig{
+		Strategy: SyncStrategyField,
+	}
+}
+
+// Load загружает конфигурацию из файла. Файл старой схемы переводится в
+// текущую и перезаписывается, исходный файл сохраняется рядом
+// (config.json.v0.bak). Ошибки в параметрах возвращаются как *ValidationError.
 func (m *Manager) Load() error {
 	data, err := os.ReadFile(m.configPath)
 	if err != nil {
 		return err
 	}
 
-	m.config = &Config{}
-	return json.Unmarshal(data, m.config)
+	cfg, version, err := Parse(data, filepath.Dir(m.configPath))
+	if err != nil {
+		return fmt.Errorf("%s: %w", m.configPath, err)
+	}
+	m.config = cfg
+
+	if version < CurrentVersion {
+		if err := backupOldVersion(m.configPath, data, version); err != nil {
+			return err
+		}
+		return m.Save()
+	}
+	return nil
 }
 
-// Save сохраняет конфигурацию в файл
-func (m *Manager) Save() error {
-	data, err := json.MarshalIndent(m.config, "", "  ")
-	if err != nil {
+// Reload перечитывает config.json, измененный вручную или другим процессом.
+// Если файл неверен, действующая конфигурация не меняется.
+func (m *Manager) Reload() error {
+	old := m.config
+	if err := m.Load(); err != nil {
+		m.config = old
 		return err
 	}
+	return nil
+}
 
-	return os.WriteFile(m.configPath, data, 0600)
+// Save проверяет конфигурацию и сохраняет ее в файл
+func (m *Manager) Save() error {
+	if err := m.config.Validate(); err != nil {
+		return err
+	}
+	return writeConfig(m.configPath, m.config)
 }
 
-// Get возвращает текущую конфигурацию
+// Get возвращает текущую конфигурацию с учетом параметров запуска
 func (m *Manager) Get() *Config {
-	return m.config
+	cfg := *m.config
+	m.overrides.apply(&cfg)
+	return &cfg
+}
+
+// SetOverrides задает параметры запуска, действующие поверх config.json
+func (m *Manager) SetOverrides(overrides Overrides) {
+	m.overrides = overrides
+}
+
+// Path возвращает путь к файлу конфигурации
+func (m *Manager) Path() string {
+	return m.configPath
 }
 
 // UpdateDatabasePath обновляет путь к базе данных
 func (m *Manager) UpdateDatabasePath(path string) error {
+	old := m.config.Database.Path
 	m.config.Database.Path = path
-	return m.Save()
+	if err := m.Save(); err != nil {
+		m.config.Database.Path = old
+		return err
+	}
+	return nil
 }
 
 // UpdateDatabasePassword обновляет пароль базы данных
 func (m *Manager) UpdateDatabasePassword(password string) error {
+	oldPassword := m.config.Database.Password
 	m.config.Database.Password = password
-	return m.Save()
+	if err := m.Save(); err != nil {
+		// Не оставляем в памяти пароль, который не удалось сохранить
+		m.config.Database.Password = oldPassword
+		return err
+	}
+	return nil
+}
+
+// UpdatePasswordStorage меняет способ хранения пароля БД.
+// Пароль в открытом виде удаляется из конфигурации для всех режимов, кроме config.
+func (m *Manager) UpdatePasswordStorage(mode, passwordFile, keyFile string) error {
+	db := m.config.Database
+	db.PasswordMode = mode
+	db.PasswordFile = passwordFile
+	if keyFile != "" {
+		db.KeyFile = keyFile
+	}
+	if mode != PasswordModeConfig {
+		db.Password = ""
+	}
+
+	oldDB := m.config.Database
+	m.config.Database = db
+	if err := m.Save(); err != nil {
+		m.config.Database = oldDB
+		return err
+	}
+	return nil
+}
+
+// UpdateDatabaseAccess обновляет параметры совместного доступа к БД
+func (m *Manager) UpdateDatabaseAccess(journalMode string, busyTimeout, writeRetries int, singleInstance bool) error {
+	db := m.config.Database
+	db.JournalMode = journalMode
+	db.BusyTimeout = busyTimeout
+	db.WriteRetries = writeRetries
+	db.SingleInstance = singleInstance
+	if err := db.ValidateAccess(); err != nil {
+		return err
+	}
+
+	oldDB := m.config.Database
+	m.config.Database = db
+	if err := m.Save(); err != nil {
+		m.config.Database = oldDB
+		return err
+	}
+	return nil
+}
+
+// UpdateSizeWarning задает размер БД в МБ, после которого выводится предупреждение
+func (m *Manager) UpdateSizeWarning(mb int) error {
+	if mb < 0 {
+		return i18n.Errorf("порог размера БД не может быть отрицательным")
+	}
+
+	old := m.config.Database.SizeWarningMB
+	m.config.Database.SizeWarningMB = mb
+	if err := m.Save(); err != nil {
+		m.config.Database.SizeWarningMB = old
+		return err
+	}
+	return nil
+}
+
+// UpdateSecuritySettings обновляет настройки безопасности
+func (m *Manager) UpdateSecuritySettings(security SecurityConfig) error {
+	if security.IdleLockMinutes < 0 {
+		return i18n.Errorf("время автоблокировки не может быть отрицательным")
+	}
+
+	oldSecurity := m.config.Security
+	m.config.Security = security
+	if err := m.Save(); err != nil {
+		m.config.Security = oldSecurity
+		return err
+	}
+	return nil
 }
 
 // UpdateInterfaceSettings обновляет настройки интерфейса
 func (m *Manager) UpdateInterfaceSettings(theme string, fontSize int, width, height int, language string) error {
+	old := m.config.Interface
 	m.config.Interface.Theme = theme
 	m.config.Interface.FontSize = fontSize
 	m.config.Interface.WindowSize.Width = width
 	m.config.Interface.WindowSize.Height = height
 	m.config.Interface.Language = language
-	return m.Save()
+	if err := m.Save(); err != nil {
+		m.config.Interface = old
+		return err
+	}
+	return nil
+}
+
+// UpdateBackupSettings обновляет настройки резервного копирования
+func (m *Manager) UpdateBackupSettings(backup BackupConfig) error {
+	if _, err := backup.IntervalDuration(); err != nil {
+		return err
+	}
+
+	oldBackup := m.config.Backup
+	m.config.Backup = backup
+	if err := m.Save(); err != nil {
+		m.config.Backup = oldBackup
+		return err
+	}
+	return nil
+}
+
+// UpdateTrashSettings обновляет настройки корзины
+func (m *Manager) UpdateTrashSettings(trash TrashConfig) error {
+	if trash.RetentionDays < 0 {
+		return i18n.Errorf("срок хранения в корзине не может быть отрицательным")
+	}
+
+	oldTrash := m.config.Trash
+	m.config.Trash = trash
+	if err := m.Save(); err != nil {
+		m.config.Trash = oldTrash
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+)
+
+// Переменные окружения с параметрами запуска
+const (
+	EnvConfig       = "JOTNAL_CONFIG"        // путь к config.json
+	EnvDB           = "JOTNAL_DB"            // путь к файлу БД
+	EnvPasswordFile = "JOTNAL_PASSWORD_FILE" // файл, первая строка которого содержит пароль БД
+	EnvUI           = "JOTNAL_UI"            // интерфейс: tui, menu или none
+	EnvReadOnly     = "JOTNAL_READ_ONLY"     // 1 или true - открыть БД только для чтения
+	EnvLanguage     = "JOTNAL_LANG"          // язык интерфейса: ru или en
+)
+
+// Интерфейсы, запускаемые без подкоманды
+const (
+	UIPrompt = ""     // спросить при запуске
+	UITUI    = "tui"  // графический интерфейс
+	UIMenu   = "menu" // текстовое меню
+	UINone   = "none" // только подключиться к БД, применить миграции и выйти
+)
+
+// ValidateUI проверяет интерфейс запуска
+func ValidateUI(ui string) error {
+	switch ui {
+	case UIPrompt, UITUI, UIMenu, UINone:
+		return nil
+	}
+	return i18n.Errorf("неверный интерфейс %q (допустимо: tui, menu, none)", ui)
+}
+
+// ValidateLanguage проверяет язык интерфейса из параметров запуска; пустой -
+// язык из config.json
+func ValidateLanguage(lang string) error {
+	switch lang {
+	case "", LanguageRU, LanguageEN:
+		return nil
+	}
+	return i18n.Errorf("неизвестный язык %q (допустимо: ru, en)", lang)
+}
+
+// Overrides - параметры запуска из флагов командной строки и переменных
+// окружения. Действуют поверх config.json и не сохраняются в него; пустое
+// значение ничего не переопределяет.
+//
+// Порядок приоритета: флаг, переменная окружения, config.json, значение по умолчанию.
+type Overrides struct {
+	ConfigPath   string
+	DatabasePath string
+	PasswordFile string // передается в secret.Options, как и --password-fd
+	UI           string
+	ReadOnly     *bool
+	Language     string
+}
+
+// OverridesFromEnv читает параметры запуска из переменных окружения
+func OverridesFromEnv(getenv func(string) string) (Overrides, error) {
+	o := Overrides{
+		ConfigPath:   getenv(EnvConfig),
+		DatabasePath: getenv(EnvDB),
+		PasswordFile: getenv(EnvPasswordFile),
+		UI:           getenv(EnvUI),
+		Language:     getenv(EnvLanguage),
+	}
+
+	if value := getenv(EnvReadOnly); value != "" {
+		readOnly, err := strconv.ParseBool(value)
+		if err != nil {
+			return Overrides{}, i18n.Errorf("неверное значение %s=%q (ожидается true или false)", EnvReadOnly, value)
+		}
+		o.ReadOnly = &readOnly
+	}
+
+	if err := ValidateUI(o.UI); err != nil {
+		return Overrides{}, fmt.Errorf("%s: %w", EnvUI, err)
+	}
+	if err := ValidateLanguage(o.Language); err != nil {
+		return Overrides{}, fmt.Errorf("%s: %w", EnvLanguage, err)
+	}
+	return o, nil
+}
+
+// Over возвращает параметры o, дополненные параметрами lower там, где o их не задает
+func (o Overrides) Over(lower Overrides) Overrides {
+	if o.ConfigPath == "" {
+		o.ConfigPath = lower.ConfigPath
+	}
+	if o.DatabasePath == "" {
+		o.DatabasePath = lower.DatabasePath
+	}
+	if o.PasswordFile == "" {
+		o.PasswordFile = lower.PasswordFile
+	}
+	if o.UI == "" {
+		o.UI = lower.UI
+	}
+	if o.ReadOnly == nil {
+		o.ReadOnly = lower.ReadOnly
+	}
+	if o.Language == "" {
+		o.Language = lower.Language
+	}
+	return o
+}
+
+// apply накладывает параметры запуска на конфигурацию
+func (o Overrides) apply(cfg *Config) {
+	if o.DatabasePath != "" {
+		cfg.Database.Path = o.DatabasePath
+	}
+	if o.UI != "" {
+		cfg.Interface.UI = o.UI
+	}
+	if o.ReadOnly != nil {
+		cfg.Database.ReadOnly = *o.ReadOnly
+	}
+	if o.Language != "" {
+		cfg.Interface.Language = o.Language
+	}
+}
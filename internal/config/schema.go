@@ -0,0 +1,722 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/gdamore/tcell/v2"
+)
+
+// CurrentVersion - версия схемы config.json, в которой приложение записывает файл
+const CurrentVersion = 1
+
+// migrations[v] переводит разобранный config.json из версии v в v+1
+var migrations = []func(raw map[string]interface{}){
+	migrateV0,
+}
+
+// migrateV0 переводит конфигурацию, записанную до появления поля version:
+// пароль в открытом виде без password_mode получает явный режим config
+func migrateV0(raw map[string]interface{}) {
+	db, ok := raw["database"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	password, _ := db["password"].(string)
+	mode, _ := db["password_mode"].(string)
+	if password != "" && mode == "" {
+		db["password_mode"] = PasswordModeConfig
+	}
+}
+
+// Встроенные темы интерфейса
+const (
+	ThemeDark         = "dark"
+	ThemeLight        = "light"
+	ThemeHighContrast = "high-contrast"
+)
+
+// IsBuiltinTheme сообщает, является ли name встроенной темой
+func IsBuiltinTheme(name string) bool {
+	switch name {
+	case ThemeDark, ThemeLight, ThemeHighContrast:
+		return true
+	}
+	return false
+}
+
+// ValidColor сообщает, можно ли использовать name как цвет темы:
+// имя цвета терминала, #rrggbb или default
+func ValidColor(name string) bool {
+	return name == "default" || tcell.GetColor(name) != tcell.ColorDefault
+}
+
+// NormalizeKey проверяет клавишу из interface.key_bindings и возвращает ее
+// в том виде, в котором ее называет интерфейс: a, Enter, F2, Ctrl+D, Alt+x.
+// Модификаторы Ctrl, Alt и Shift пишутся через «+» в любом регистре; Ctrl
+// сочетается только с латинской буквой или с именованной клавишей.
+func NormalizeKey(name string) (string, error) {
+	var ctrl, alt, shift bool
+	rest := name
+	for {
+		mod, key, ok := strings.Cut(rest, "+")
+		if !ok || key == "" {
+			break // «+» - сама клавиша плюс
+		}
+		switch strings.ToLower(mod) {
+		case "ctrl":
+			ctrl = true
+		case "alt":
+			alt = true
+		case "shift":
+			shift = true
+		default:
+			return "", i18n.Errorf("неизвестный модификатор %q (допустимо: Ctrl, Alt, Shift)", mod)
+		}
+		rest = key
+	}
+
+	if r, size := utf8.DecodeRuneInString(rest); size > 0 && size == len(rest) && unicode.IsPrint(r) {
+		switch {
+		case ctrl && r < unicode.MaxASCII && unicode.IsLetter(r):
+			return keyModifiers(true, alt, shift) + string(unicode.ToUpper(r)), nil
+		case ctrl:
+			return "", i18n.Errorf("с Ctrl сочетается только латинская буква, указано %q", name)
+		case shift:
+			return "", i18n.Errorf("%q: вместо Shift укажите сам символ в нужном регистре", name)
+		}
+		return keyModifiers(false, alt, false) + rest, nil
+	}
+
+	for _, known := range tcell.KeyNames {
+		if !strings.EqualFold(known, rest) || strings.HasPrefix(known, "Ctrl-") {
+			continue
+		}
+		// Shift+Tab терминал передает как Backtab
+		if known == "Tab" && shift {
+			known, shift = "Backtab", false
+		}
+		return keyModifiers(ctrl, alt, shift) + known, nil
+	}
+	return "", i18n.Errorf("неизвестная клавиша %q", name)
+}
+
+// keyModifiers возвращает модификаторы клавиши в порядке Ctrl, Alt, Shift
+func keyModifiers(ctrl, alt, shift bool) string {
+	var prefix string
+	if ctrl {
+		prefix += "Ctrl+"
+	}
+	if alt {
+		prefix += "Alt+"
+	}
+	if shift {
+		prefix += "Shift+"
+	}
+	return prefix
+}
+
+// Языки интерфейса
+const (
+	LanguageRU = "ru"
+	LanguageEN = "en"
+)
+
+// Допустимый размер шрифта
+const (
+	MinFontSize = 6
+	MaxFontSize = 72
+)
+
+// Problem - ошибка в одном параметре конфигурации
+type Problem struct {
+	Key     string // параметр через точку, например interface.font_size; пусто - файл целиком
+	Message string
+}
+
+// ValidationError перечисляет все ошибки конфигурации
+type ValidationError struct {
+	Problems []Problem
+}
+
+// Error возвращает ошибки по одной в строке
+func (e *ValidationError) Error() string {
+	lines := []string{i18n.T("неверная конфигурация:")}
+	for _, p := range e.Problems {
+		if p.Key == "" {
+			lines = append(lines, "  "+p.Message)
+		} else {
+			lines = append(lines, "  "+p.Key+": "+p.Message)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// problems собирает ошибки проверки
+type problems []Problem
+
+func (p *problems) add(key, format string, args ...interface{}) {
+	*p = append(*p, Problem{Key: key, Message: i18n.Sprintf(format, args...)})
+}
+
+// err возвращает *ValidationError или nil, если ошибок нет
+func (p problems) err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: p}
+}
+
+// ResolvePath возвращает абсолютный путь к config.json; пустой путь -
+// ~/.jotnal/config.json
+func ResolvePath(configPath string) (string, error) {
+	if configPath == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		configPath = filepath.Join(homeDir, ".jotnal", "config.json")
+	}
+	return filepath.Abs(configPath)
+}
+
+// PeekLanguage возвращает язык интерфейса из config.json, не проверяя файл
+// целиком: язык нужен раньше загрузки конфигурации, чтобы и ее ошибки
+// выводились на нем. Если файла нет или он не разбирается, возвращает "".
+func PeekLanguage(configPath string) string {
+	configPath, err := ResolvePath(configPath)
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return ""
+	}
+
+	var peek struct {
+		Interface struct {
+			Language string `json:"language"`
+		} `json:"interface"`
+	}
+	if json.Unmarshal(data, &peek) != nil {
+		return ""
+	}
+	return peek.Interface.Language
+}
+
+// Parse разбирает config.json из каталога dir: переводит старую схему в
+// текущую, заполняет отсутствующие параметры значениями по умолчанию и
+// проверяет значения. Возвращает версию схемы, в которой записан файл.
+//
+// Если неверны только значения параметров, возвращается и разобранная
+// конфигурация, и *ValidationError - чтобы ее можно было исправить.
+func Parse(data []byte, dir string) (*Config, int, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, 0, &ValidationError{Problems: []Problem{{Message: syntaxMessage(data, err)}}}
+	}
+	if raw == nil {
+		return nil, 0, &ValidationError{Problems: []Problem{{Message: i18n.T("ожидается объект JSON")}}}
+	}
+
+	version := 0
+	if value, ok := raw["version"]; ok {
+		n, isNumber := value.(float64)
+		if !isNumber || n != math.Trunc(n) || n < 0 {
+			return nil, 0, &ValidationError{Problems: []Problem{{Key: "version", Message: i18n.T("ожидается целое неотрицательное число")}}}
+		}
+		version = int(n)
+	}
+	if version > CurrentVersion {
+		return nil, version, &ValidationError{Problems: []Problem{{
+			Key:     "version",
+			Message: i18n.Sprintf("схема версии %d новее поддерживаемой (%d), обновите jotnal", version, CurrentVersion),
+		}}}
+	}
+	for v := version; v < CurrentVersion; v++ {
+		migrations[v](raw)
+	}
+	raw["version"] = float64(CurrentVersion)
+
+	// Неизвестные параметры и значения неверного типа ищем до разбора в
+	// структуру: json.Unmarshal пропускает первые и не называет путь ко вторым.
+	// checkRaw убирает их, чтобы проверить и значения остальных параметров.
+	var found problems
+	checkRaw(raw, reflect.TypeOf(Config{}), "", &found)
+	structural := len(found) > 0
+
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return nil, version, err
+	}
+	cfg := loadDefaults(dir)
+	if err := json.Unmarshal(migrated, cfg); err != nil {
+		return nil, version, err
+	}
+	var invalid *ValidationError
+	if err := cfg.Validate(); errors.As(err, &invalid) {
+		found = append(found, invalid.Problems...)
+	}
+
+	// Конфигурацию без части параметров не возвращаем: ее запись потеряла бы их
+	if structural {
+		return nil, version, found.err()
+	}
+	return cfg, version, found.err()
+}
+
+// syntaxMessage описывает синтаксическую ошибку JSON с номером строки и столбца
+func syntaxMessage(data []byte, err error) string {
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return i18n.Sprintf("ошибка JSON: %v", err)
+	}
+	before := data[:syntaxErr.Offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len([]rune(string(before[bytes.LastIndexByte(before, '\n')+1:])))
+	return i18n.Sprintf("строка %d, столбец %d: ошибка JSON: %v", line, column, err)
+}
+
+// checkRaw проверяет имена и типы параметров разобранного JSON по полям
+// структуры t и удаляет из raw параметры с ошибками
+func checkRaw(raw map[string]interface{}, t reflect.Type, prefix string, found *problems) {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		fields[jsonName(t.Field(i))] = t.Field(i)
+	}
+
+	for _, key := range sortedKeys(raw) {
+		field, ok := fields[key]
+		if !ok {
+			found.add(prefix+key, "неизвестный параметр")
+			delete(raw, key)
+			continue
+		}
+		if !checkRawValue(raw[key], field.Type, prefix+key, found) {
+			delete(raw, key)
+		}
+	}
+}
+
+// checkRawValue проверяет значение параметра key по типу t. Возвращает false,
+// если значение неверного типа и его нужно удалить; неверные записи
+// словарей удаляются из самого словаря.
+func checkRawValue(value interface{}, t reflect.Type, key string, found *problems) bool {
+	if value == nil {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		nested, ok := value.(map[string]interface{})
+		if !ok {
+			found.add(key, "ожидается объект")
+			return false
+		}
+		checkRaw(nested, t, key+".", found)
+	case reflect.Map:
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			found.add(key, "ожидается объект")
+			return false
+		}
+		for _, name := range sortedKeys(entries) {
+			if !checkRawValue(entries[name], t.Elem(), key+"."+name, found) {
+				delete(entries, name)
+			}
+		}
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			found.add(key, "ожидается строка")
+			return false
+		}
+	case reflect.Int:
+		if n, ok := value.(float64); !ok || n != math.Trunc(n) {
+			found.add(key, "ожидается целое число")
+			return false
+		}
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			found.add(key, "ожидается true или false")
+			return false
+		}
+	}
+	return true
+}
+
+// sortedKeys возвращает ключи объекта JSON по алфавиту, чтобы ошибки
+// выводились в одном и том же порядке
+func sortedKeys(raw map[string]interface{}) []string {
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// jsonName возвращает имя ключа JSON поля структуры
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return name
+}
+
+// Validate проверяет значения всех параметров и возвращает *ValidationError
+// со всеми найденными ошибками
+func (c *Config) Validate() error {
+	var found problems
+
+	db := c.Database
+	if strings.TrimSpace(db.Path) == "" {
+		found.add("database.path", "путь к БД не может быть пустым")
+	}
+	switch db.EffectivePasswordMode() {
+	case "", PasswordModePrompt, PasswordModeConfig, PasswordModeFD:
+	case PasswordModeFile:
+		if db.PasswordFile == "" {
+			found.add("database.password_file", "обязателен при password_mode %q", PasswordModeFile)
+		}
+	case PasswordModeKeyFile:
+		if db.KeyFile == "" {
+			found.add("database.key_file", "обязателен при password_mode %q", PasswordModeKeyFile)
+		}
+	default:
+		found.add("database.password_mode", "неверный способ хранения пароля %q (допустимо: prompt, keyfile, file, fd, config)", db.PasswordMode)
+	}
+	switch db.JournalMode {
+	case JournalModeWAL, JournalModeDelete:
+	default:
+		found.add("database.journal_mode", "неверный режим журнала %q (допустимо: wal, delete)", db.JournalMode)
+	}
+	nonNegative(&found, "database.busy_timeout", db.BusyTimeout)
+	nonNegative(&found, "database.write_retries", db.WriteRetries)
+	nonNegative(&found, "database.size_warning_mb", db.SizeWarningMB)
+
+	ui := c.Interface
+	if _, custom := ui.Themes[ui.Theme]; !custom && !IsBuiltinTheme(ui.Theme) {
+		found.add("interface.theme", "неизвестная тема %q (допустимо: dark, light, high-contrast или тема из interface.themes)", ui.Theme)
+	}
+	validateThemes(&found, ui.Themes)
+	validateKeyBindings(&found, ui.KeyBindings)
+	if ui.FontSize < MinFontSize || ui.FontSize > MaxFontSize {
+		found.add("interface.font_size", "размер шрифта %d вне диапазона %d-%d", ui.FontSize, MinFontSize, MaxFontSize)
+	}
+	if ui.WindowSize.Width <= 0 {
+		found.add("interface.window_size.width", "ширина окна должна быть больше нуля, указано %d", ui.WindowSize.Width)
+	}
+	if ui.WindowSize.Height <= 0 {
+		found.add("interface.window_size.height", "высота окна должна быть больше нуля, указано %d", ui.WindowSize.Height)
+	}
+	switch ui.Language {
+	case LanguageRU, LanguageEN:
+	default:
+		found.add("interface.language", "неизвестный язык %q (допустимо: ru, en)", ui.Language)
+	}
+	if err := ValidateUI(ui.UI); err != nil {
+		found.add("interface.ui", "%v", err)
+	}
+
+	if _, err := c.Backup.IntervalDuration(); err != nil {
+		found.add("backup.interval", "%v", err)
+	}
+	if c.Backup.Enabled && strings.TrimSpace(c.Backup.Directory) == "" {
+		found.add("backup.directory", "обязателен, если резервное копирование включено")
+	}
+	nonNegative(&found, "backup.keep_last", c.Backup.KeepLast)
+
+	sec := c.Security
+	nonNegative(&found, "security.min_password_length", sec.MinPasswordLength)
+	if sec.MinCharClasses < 0 || sec.MinCharClasses > 4 {
+		found.add("security.min_char_classes", "число видов символов %d вне диапазона 0-4", sec.MinCharClasses)
+	}
+	nonNegative(&found, "security.lockout_threshold", sec.LockoutThreshold)
+	nonNegative(&found, "security.lockout_base_delay", sec.LockoutBaseDelay)
+	nonNegative(&found, "security.lockout_max_delay", sec.LockoutMaxDelay)
+	nonNegative(&found, "security.idle_lock_minutes", sec.IdleLockMinutes)
+
+	nonNegative(&found, "trash.retention_days", c.Trash.RetentionDays)
+
+	if err := ValidateStrategy(c.Sync.Strategy); err != nil {
+		found.add("sync.strategy", "%v", err)
+	}
+
+	return found.err()
+}
+
+// validateThemes проверяет имена и цвета пользовательских тем
+func validateThemes(found *problems, themes map[string]ThemeColors) {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prefix := "interface.themes." + name
+		switch {
+		case name == "" || strings.Contains(name, "."):
+			found.add(prefix, "имя темы не может быть пустым или содержать точку")
+			continue
+		case IsBuiltinTheme(name):
+			found.add(prefix, "имя совпадает со встроенной темой")
+			continue
+		}
+
+		theme := themes[name]
+		if theme.Base != "" && !IsBuiltinTheme(theme.Base) {
+			found.add(prefix+".base", "неизвестная встроенная тема %q (допустимо: dark, light, high-contrast)", theme.Base)
+		}
+		eachSetting(reflect.ValueOf(theme), prefix+".", func(key string, value reflect.Value, field reflect.StructField) {
+			if jsonName(field) == "base" || value.String() == "" {
+				return
+			}
+			if !ValidColor(value.String()) {
+				found.add(key, "неизвестный цвет %q (ожидается имя цвета, #rrggbb или default)", value.String())
+			}
+		})
+	}
+}
+
+// validateKeyBindings проверяет имена экранов и действий и запись клавиш.
+// Какие экраны и действия существуют, знает интерфейс: это проверяет
+// ui.CheckKeyBindings.
+func validateKeyBindings(found *problems, bindings map[string]map[string]string) {
+	for _, screen := range sortedNames(bindings) {
+		prefix := "interface.key_bindings." + screen
+		if screen == "" || strings.Contains(screen, ".") {
+			found.add(prefix, "имя экрана не может быть пустым или содержать точку")
+			continue
+		}
+		for _, action := range sortedNames(bindings[screen]) {
+			key := bindings[screen][action]
+			switch {
+			case action == "" || strings.Contains(action, "."):
+				found.add(prefix+"."+action, "имя действия не может быть пустым или содержать точку")
+			case key != "":
+				if _, err := NormalizeKey(key); err != nil {
+					found.add(prefix+"."+action, "%v", err)
+				}
+			}
+		}
+	}
+}
+
+// sortedNames возвращает ключи словаря по алфавиту
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// nonNegative проверяет, что числовой параметр не отрицательный
+func nonNegative(found *problems, key string, value int) {
+	if value < 0 {
+		found.add(key, "не может быть отрицательным, указано %d", value)
+	}
+}
+
+// Setting - параметр конфигурации с именем через точку
+type Setting struct {
+	Key    string
+	Value  string
+	Secret bool // пароль или хеш: значение не выводится
+}
+
+// Settings возвращает все параметры конфигурации в порядке полей
+func (c *Config) Settings() []Setting {
+	var settings []Setting
+	eachSetting(reflect.ValueOf(c).Elem(), "", func(key string, value reflect.Value, field reflect.StructField) {
+		settings = append(settings, Setting{
+			Key:    key,
+			Value:  formatSetting(value),
+			Secret: field.Tag.Get("secret") == "true",
+		})
+	})
+	return settings
+}
+
+// Set задает параметр по имени через точку; значение разбирается по типу параметра.
+// Значения других параметров не проверяются - для этого есть Validate.
+func (c *Config) Set(key, value string) error {
+	if key == "version" {
+		return i18n.Errorf("version: версию схемы задает приложение")
+	}
+
+	// Параметр еще не существующей пользовательской темы создает ее
+	var created string
+	if rest, ok := strings.CutPrefix(key, "interface.themes."); ok {
+		if name, _, ok := strings.Cut(rest, "."); ok {
+			if _, exists := c.Interface.Themes[name]; !exists {
+				if c.Interface.Themes == nil {
+					c.Interface.Themes = make(map[string]ThemeColors)
+				}
+				c.Interface.Themes[name] = ThemeColors{}
+				created = name
+			}
+		}
+	}
+
+	// Клавиша еще не назначенного действия создает запись экрана и действия
+	if rest, ok := strings.CutPrefix(key, "interface.key_bindings."); ok {
+		if screen, action, ok := strings.Cut(rest, "."); ok && !strings.Contains(action, ".") {
+			if c.Interface.KeyBindings == nil {
+				c.Interface.KeyBindings = make(map[string]map[string]string)
+			}
+			if c.Interface.KeyBindings[screen] == nil {
+				c.Interface.KeyBindings[screen] = make(map[string]string)
+			}
+			if _, exists := c.Interface.KeyBindings[screen][action]; !exists {
+				c.Interface.KeyBindings[screen][action] = ""
+			}
+		}
+	}
+
+	// Значение задается внутри обхода: запись словаря - копия, которая
+	// записывается обратно после fn
+	var found bool
+	var err error
+	eachSetting(reflect.ValueOf(c).Elem(), "", func(k string, target reflect.Value, _ reflect.StructField) {
+		if k == key {
+			found = true
+			err = setValue(target, key, value)
+		}
+	})
+	if !found {
+		if created != "" {
+			delete(c.Interface.Themes, created)
+		}
+		return i18n.Errorf("%s: неизвестный параметр", key)
+	}
+	return err
+}
+
+// setValue разбирает value по типу параметра key и записывает в target
+func setValue(target reflect.Value, key, value string) error {
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return i18n.Errorf("%s: ожидается целое число, указано %q", key, value)
+		}
+		target.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return i18n.Errorf("%s: ожидается true или false, указано %q", key, value)
+		}
+		target.SetBool(b)
+	}
+	return nil
+}
+
+// eachSetting вызывает fn для каждого параметра структуры v, кроме вложенных
+// структур и словарей: они обходятся вглубь через eachValue
+func eachSetting(v reflect.Value, prefix string, fn func(key string, value reflect.Value, field reflect.StructField)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		eachValue(v.Field(i), prefix+jsonName(t.Field(i)), t.Field(i), fn)
+	}
+}
+
+// eachValue вызывает fn для значения параметра key поля field или обходит его
+// вглубь. Записи словарей (interface.themes, interface.key_bindings) обходятся
+// по имени через копию, которая после обхода записывается обратно: элемент
+// словаря нельзя изменить на месте.
+func eachValue(v reflect.Value, key string, field reflect.StructField, fn func(key string, value reflect.Value, field reflect.StructField)) {
+	switch v.Kind() {
+	case reflect.Struct:
+		eachSetting(v, key+".", fn)
+	case reflect.Map:
+		names := v.MapKeys()
+		sort.Slice(names, func(a, b int) bool { return names[a].String() < names[b].String() })
+		for _, name := range names {
+			entry := reflect.New(v.Type().Elem()).Elem()
+			entry.Set(v.MapIndex(name))
+			eachValue(entry, key+"."+name.String(), field, fn)
+			if v.CanSet() {
+				v.SetMapIndex(name, entry)
+			}
+		}
+	default:
+		fn(key, v, field)
+	}
+}
+
+// formatSetting возвращает значение параметра строкой в том виде, в котором его принимает Set
+func formatSetting(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Int:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	}
+	return v.String()
+}
+
+// EditFile меняет config.json по пути path: разбирает файл (неверные
+// значения допускаются, чтобы их можно было исправить), применяет fn,
+// проверяет результат и записывает файл в текущей схеме. Если файла нет,
+// за основу берется конфигурация по умолчанию.
+func EditFile(path string, fn func(cfg *Config) error) error {
+	dir := filepath.Dir(path)
+	var cfg *Config
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		cfg = defaultConfig(dir)
+	case err != nil:
+		return err
+	default:
+		parsed, version, err := Parse(data, dir)
+		var invalid *ValidationError
+		if parsed == nil || (err != nil && !errors.As(err, &invalid)) {
+			return err
+		}
+		if version < CurrentVersion {
+			if err := backupOldVersion(path, data, version); err != nil {
+				return err
+			}
+		}
+		cfg = parsed
+	}
+
+	if err := fn(cfg); err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	return writeConfig(path, cfg)
+}
+
+// backupOldVersion сохраняет файл старой схемы рядом с config.json перед переводом
+func backupOldVersion(path string, data []byte, version int) error {
+	return os.WriteFile(fmt.Sprintf("%s.v%d.bak", path, version), data, 0600)
+}
+
+// writeConfig записывает конфигурацию в текущей схеме
+func writeConfig(path string, cfg *Config) error {
+	cfg.Version = CurrentVersion
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,43 @@
+package config
+
+import "testing"
+
+func TestNormalizeKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"a", "a", false},
+		{"A", "A", false},
+		{"+", "+", false},
+		{"ф", "ф", false},
+		{"ctrl+k", "Ctrl+K", false},
+		{"Ctrl+k", "Ctrl+K", false},
+		{"alt+ctrl+x", "Ctrl+Alt+X", false},
+		{"Alt+x", "Alt+x", false},
+		{"Ctrl++", "", true},
+		{"enter", "Enter", false},
+		{"pgdn", "PgDn", false},
+		{"shift+f5", "Shift+F5", false},
+		{"Shift+Tab", "Backtab", false},
+		{"Ctrl+1", "", true},
+		{"Ctrl+ф", "", true},
+		{"Shift+a", "", true},
+		{"Super+a", "", true},
+		{"Ctrl-A", "", true},
+		{"", "", true},
+		{"ab", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeKey(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeKey(%q) = %q, %v", tt.name, got, err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeKey(%q) = %q, ожидалось %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
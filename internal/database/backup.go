@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+)
+
+// Backup создает зашифрованную копию БД в файле destPath.
+// Копия снимается через отдельное подключение, поэтому не блокирует
+// основное соединение приложения.
+func (m *Manager) Backup(destPath string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.db == nil {
+		return i18n.Errorf("БД не подключена")
+	}
+
+	return exportDatabase(m.dbPath, m.password, destPath, m.password)
+}
+
+// exportDatabase копирует БД srcPath в файл destPath через sqlcipher_export.
+// Копия шифруется ключом destKey (пустой ключ - без шифрования).
+// Файл сначала пишется во временный и только потом переименовывается,
+// чтобы при сбое не оставить недописанную копию.
+func exportDatabase(srcPath, srcKey, destPath, destKey string) error {
+	if err := os.MkdirAll(filepath.Dir(destPath), 0700); err != nil {
+		return i18n.Errorf("не удалось создать директорию для копии: %w", err)
+	}
+
+	tmpPath := destPath + ".tmp"
+	os.Remove(tmpPath)
+
+	db, err := openDB(srcPath, srcKey, AccessOptions{})
+	if err != nil {
+		return i18n.Errorf("не удалось открыть БД: %w", err)
+	}
+	defer db.Close()
+
+	// ATTACH действует только в пределах одного соединения
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return i18n.Errorf("не удалось подключиться к БД: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS export KEY ?", tmpPath, destKey); err != nil {
+		return i18n.Errorf("не удалось создать файл копии: %w", err)
+	}
+
+	_, err = conn.ExecContext(ctx, "SELECT sqlcipher_export('export')")
+	if _, detachErr := conn.ExecContext(ctx, "DETACH DATABASE export"); err == nil {
+		err = detachErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return i18n.Errorf("не удалось скопировать данные: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		os.Remove(tmpPath)
+		return i18n.Errorf("не удалось сохранить копию: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+)
+
+// ForeignKeyViolation - строка, ссылающаяся на отсутствующую запись
+type ForeignKeyViolation struct {
+	Table  string // таблица со ссылкой
+	RowID  int64
+	Parent string // таблица, на которую ссылается строка
+}
+
+// IntegrityCheck выполняет PRAGMA integrity_check и возвращает найденные
+// повреждения структуры файла; пустой список - ошибок нет
+func (m *Manager) IntegrityCheck() ([]string, error) {
+	problems, err := m.pragmaList("PRAGMA integrity_check")
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) == 1 && problems[0] == "ok" {
+		return nil, nil
+	}
+	return problems, nil
+}
+
+// CipherIntegrityCheck выполняет PRAGMA cipher_integrity_check, проверяющую
+// контрольные суммы зашифрованных страниц; пустой список - ошибок нет
+func (m *Manager) CipherIntegrityCheck() ([]string, error) {
+	return m.pragmaList("PRAGMA cipher_integrity_check")
+}
+
+// ForeignKeyCheck выполняет PRAGMA foreign_key_check. Внешние ключи при записи
+// не проверяются, поэтому нарушения ищутся только этой проверкой.
+func (m *Manager) ForeignKeyCheck() ([]ForeignKeyViolation, error) {
+	rows, err := m.db.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, i18n.Errorf("не удалось проверить внешние ключи: %w", err)
+	}
+	defer rows.Close()
+
+	var violations []ForeignKeyViolation
+	for rows.Next() {
+		var v ForeignKeyViolation
+		var rowID sql.NullInt64
+		var fkID int64
+		if err := rows.Scan(&v.Table, &rowID, &v.Parent, &fkID); err != nil {
+			return nil, err
+		}
+		v.RowID = rowID.Int64
+		violations = append(violations, v)
+	}
+
+	return violations, rows.Err()
+}
+
+// pragmaList выполняет PRAGMA, возвращающую по одной строке текста на результат
+func (m *Manager) pragmaList(pragma string) ([]string, error) {
+	result, err := m.names(pragma)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", pragma, err)
+	}
+	return result, nil
+}
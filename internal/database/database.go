@@ -1,20 +1,49 @@
 package database
 
 import (
+	"crypto/subtle"
 	"database/sql"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
-	_ "github.com/mutecomm/go-sqlcipher/v4"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/instance"
+	sqlite3 "github.com/mutecomm/go-sqlcipher/v4"
 )
 
+// ErrWrongPassword возвращается, если файл БД не удалось расшифровать паролем
+var ErrWrongPassword = i18n.NewError("неверный пароль БД или файл поврежден")
+
+// ErrBusy возвращается, если запись не удалась из-за того, что БД занята другим процессом
+var ErrBusy = i18n.NewError("БД занята другим процессом, повторите попытку позже")
+
+// ErrReadOnly возвращается при попытке записи в БД, открытую только для чтения
+var ErrReadOnly = i18n.NewError("БД открыта только для чтения")
+
+// AccessOptions задает параметры совместного доступа нескольких процессов к БД
+type AccessOptions struct {
+	JournalMode    string        // wal или delete, пусто - не менять режим файла
+	BusyTimeout    time.Duration // сколько ждать освобождения БД, 0 - значение драйвера
+	WriteRetries   int           // сколько раз повторять запись, если БД все еще занята
+	SingleInstance bool          // захватывать эксклюзивную блокировку экземпляра
+	ReadOnly       bool          // открыть файл только для чтения, без миграций
+}
+
 // Manager управляет подключением к базе данных
 type Manager struct {
+	mu       sync.RWMutex // защищает смену ключа от параллельных копирований
 	db       *sql.DB
 	dbPath   string
 	password string
 	version  int
+	access   AccessOptions
+	lock     *instance.Lock
 }
 
 // NewManager создает новый менеджер базы данных
@@ -27,30 +56,70 @@ func NewManager(dbPath, password string) (*Manager, error) {
 	return m, nil
 }
 
+// SetAccessOptions задает параметры совместного доступа; вызывается до Connect
+func (m *Manager) SetAccessOptions(opts AccessOptions) {
+	m.access = opts
+}
+
 // Connect подключается к базе данных
 func (m *Manager) Connect() error {
 	// Создаем директорию для БД если не существует
 	dbDir := filepath.Dir(m.dbPath)
 	if err := os.MkdirAll(dbDir, 0700); err != nil {
-		return fmt.Errorf("не удалось создать директорию для БД: %w", err)
+		return i18n.Errorf("не удалось создать директорию для БД: %w", err)
 	}
 
-	// Проверяем существует ли файл БД
-	isNewDB := !fileExists(m.dbPath)
+	if m.access.SingleInstance && m.lock == nil {
+		lock, err := instance.Acquire(instanceLockPath(m.dbPath))
+		if err != nil {
+			return err
+		}
+		m.lock = lock
+	}
+
+	if err := m.connect(); err != nil {
+		m.lock.Release()
+		m.lock = nil
+		return err
+	}
 
-	// Формируем DSN с параметрами шифрования
-	dsn := fmt.Sprintf("file:%s?_pragma_key=%s&_pragma_cipher_page_size=4096", m.dbPath, m.password)
+	return nil
+}
 
-	// Открываем подключение
-	db, err := sql.Open("sqlite3", dsn)
+// CheckInstance проверяет, что БД dbPath не открыта другим экземпляром приложения.
+// Позволяет сообщить о блокировке до запроса пароля; сама блокировка
+// захватывается в Connect.
+func CheckInstance(dbPath string) error {
+	lock, err := instance.Acquire(instanceLockPath(dbPath))
 	if err != nil {
-		return fmt.Errorf("не удалось открыть БД: %w", err)
+		return err
 	}
+	return lock.Release()
+}
 
-	// Проверяем подключение
-	if err := db.Ping(); err != nil {
-		db.Close()
-		return fmt.Errorf("не удалось подключиться к БД: %w", err)
+// instanceLockPath возвращает путь к файлу блокировки экземпляра
+func instanceLockPath(dbPath string) string {
+	return dbPath + ".lock"
+}
+
+// connect открывает БД и применяет миграции
+func (m *Manager) connect() error {
+	// Проверяем существует ли файл БД
+	isNewDB := !fileExists(m.dbPath)
+	if isNewDB && m.access.ReadOnly {
+		return i18n.Errorf("файл БД %s не найден, а открыть его можно только для чтения", m.dbPath)
+	}
+
+	// Открываем подключение и проверяем ключ
+	db, err := m.open(m.password)
+	if err != nil && isWrongKey(err) && !isNewDB && !m.access.ReadOnly {
+		db, err = m.openLegacy()
+	}
+	if err != nil {
+		if isWrongKey(err) {
+			err = ErrWrongPassword
+		}
+		return i18n.Errorf("не удалось подключиться к БД: %w", err)
 	}
 
 	m.db = db
@@ -59,19 +128,117 @@ func (m *Manager) Connect() error {
 	if isNewDB {
 		if err := m.initialize(); err != nil {
 			m.db.Close()
-			return fmt.Errorf("не удалось инициализировать БД: %w", err)
+			return i18n.Errorf("не удалось инициализировать БД: %w", err)
 		}
 	} else {
 		// Проверяем версию БД
 		if err := m.checkVersion(); err != nil {
 			m.db.Close()
-			return fmt.Errorf("не удалось проверить версию БД: %w", err)
+			return i18n.Errorf("не удалось проверить версию БД: %w", err)
 		}
 	}
 
 	return nil
 }
 
+// open открывает файл БД менеджера с указанным ключом и параметрами доступа
+func (m *Manager) open(password string) (*sql.DB, error) {
+	return openVerified(m.dbPath, password, m.access)
+}
+
+// openDB открывает зашифрованную БД по указанному пути
+func openDB(path, password string, opts AccessOptions) (*sql.DB, error) {
+	// Драйвер подставляет ключ в PRAGMA key = "...", поэтому кавычки
+	// удваиваются, а само значение экранируется как параметр URL
+	key := url.QueryEscape(strings.ReplaceAll(password, `"`, `""`))
+
+	// Формируем DSN с параметрами шифрования. Ключ задается первым:
+	// остальные PRAGMA драйвер выполняет уже после него.
+	dsn := fmt.Sprintf("file:%s?_pragma_key=%s&_pragma_cipher_page_size=4096", path, key)
+	if opts.ReadOnly {
+		// Режим журнала записан в файле, и менять его без права записи нельзя
+		return sql.Open("sqlite3", dsn+"&mode=ro&_query_only=1")
+	}
+	if opts.JournalMode != "" {
+		dsn += "&_journal_mode=" + opts.JournalMode
+	}
+	if opts.BusyTimeout > 0 {
+		dsn += fmt.Sprintf("&_busy_timeout=%d", opts.BusyTimeout.Milliseconds())
+	}
+	// Транзакции сразу берут блокировку записи: ожидание занятой БД
+	// происходит на BEGIN, а не при первой записи, где SQLite не ждет
+	dsn += "&_txlock=immediate"
+
+	return sql.Open("sqlite3", dsn)
+}
+
+// openVerified открывает БД и убеждается, что ключ подходит
+func openVerified(path, password string, opts AccessOptions) (*sql.DB, error) {
+	db, err := openDB(path, password, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ping не читает файл, поэтому ключ проверяем запросом к схеме
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master").Scan(&count); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// isWrongKey сообщает, что файл не удалось расшифровать указанным ключом
+func isWrongKey(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrNotADB
+}
+
+// legacyKey возвращает ключ, который получала БД в старых версиях, когда
+// пароль подставлялся в DSN без экранирования ('+' становился пробелом и т.п.)
+func legacyKey(password string) string {
+	params, err := url.ParseQuery("_pragma_key=" + password)
+	if err != nil {
+		return password
+	}
+	return params.Get("_pragma_key")
+}
+
+// openLegacy открывает БД, зашифрованную старой версией с неэкранированным паролем,
+// и сразу перешифровывает ее настоящим паролем
+func (m *Manager) openLegacy() (*sql.DB, error) {
+	oldKey := legacyKey(m.password)
+	if oldKey == m.password || oldKey == "" || strings.Contains(oldKey, `"`) {
+		return nil, sqlite3.Error{Code: sqlite3.ErrNotADB}
+	}
+
+	if err := rekeyFile(m.dbPath, oldKey, m.password); err != nil {
+		return nil, err
+	}
+
+	return m.open(m.password)
+}
+
+// rekeyFile перешифровывает файл БД новым ключом через PRAGMA rekey
+func rekeyFile(path, oldPassword, newPassword string) error {
+	db, err := openVerified(path, oldPassword, AccessOptions{})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// PRAGMA не поддерживает параметры, поэтому ключ передается
+	// строковым литералом с экранированными кавычками
+	_, err = db.Exec("PRAGMA rekey = " + quoteLiteral(newPassword))
+	return err
+}
+
+// quoteLiteral оформляет строку как SQL литерал
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
 // initialize инициализирует новую базу данных
 func (m *Manager) initialize() error {
 	// Создаем таблицу версий
@@ -119,8 +286,11 @@ func (m *Manager) runMigrations() error {
 
 	for _, migration := range migrations {
 		if migration.Version > m.version {
+			if m.access.ReadOnly {
+				return i18n.Errorf("схему БД нужно обновить до версии %d, откройте БД без режима только для чтения", migrations[len(migrations)-1].Version)
+			}
 			if err := m.applyMigration(migration); err != nil {
-				return fmt.Errorf("не удалось применить миграцию %d: %w", migration.Version, err)
+				return i18n.Errorf("не удалось применить миграцию %d: %w", migration.Version, err)
 			}
 		}
 	}
@@ -154,40 +324,154 @@ func (m *Manager) applyMigration(migration Migration) error {
 	return nil
 }
 
-// GetDB возвращает экземпляр базы данных
+// GetDB возвращает экземпляр базы данных. Во время смены ключа или замены
+// файла ждет, пока новое подключение не будет открыто; nil - БД не подключена.
 func (m *Manager) GetDB() *sql.DB {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	return m.db
 }
 
+// GetPath возвращает путь к файлу БД
+func (m *Manager) GetPath() string {
+	return m.dbPath
+}
+
+// ReadOnly сообщает, что БД открыта только для чтения
+func (m *Manager) ReadOnly() bool {
+	return m.access.ReadOnly
+}
+
 // GetVersion возвращает текущую версию БД
 func (m *Manager) GetVersion() int {
 	return m.version
 }
 
-// Close закрывает подключение к базе данных
+// Close закрывает подключение к базе данных и снимает блокировку экземпляра
 func (m *Manager) Close() error {
+	var err error
 	if m.db != nil {
-		return m.db.Close()
+		err = m.db.Close()
 	}
-	return nil
+	m.lock.Release()
+	m.lock = nil
+	return err
+}
+
+// WithRetry выполняет запись fn, повторяя ее с растущей паузой, пока БД
+// занята другим процессом. fn должна быть целой транзакцией.
+// Если БД открыта только для чтения, fn не вызывается и возвращается ErrReadOnly.
+func (m *Manager) WithRetry(fn func() error) error {
+	if m.access.ReadOnly {
+		return ErrReadOnly
+	}
+
+	delay := 100 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || !IsBusy(err) {
+			return err
+		}
+		if attempt >= m.access.WriteRetries {
+			return fmt.Errorf("%w (%v)", ErrBusy, err)
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// IsBusy сообщает, что операция не удалась из-за блокировки БД другим соединением
+func IsBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
 }
 
-// ChangePassword изменяет пароль базы данных
-func (m *Manager) ChangePassword(newPassword string) error {
+// VerifyPassword проверяет, совпадает ли пароль с текущим ключом БД
+func (m *Manager) VerifyPassword(password string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return subtle.ConstantTimeCompare([]byte(password), []byte(m.password)) == 1
+}
+
+// ChangePassword безопасно меняет ключ шифрования БД.
+//
+// Перед сменой ключа снимается страховочная копия со старым ключом. После
+// PRAGMA rekey БД переоткрывается с новым ключом для проверки, затем вызывается
+// commit, который должен сохранить новый секрет (например, в конфигурации).
+// Если любой из шагов не удался, файл восстанавливается из страховочной копии
+// и БД снова открывается со старым ключом.
+func (m *Manager) ChangePassword(newPassword string, commit func() error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if m.db == nil {
-		return fmt.Errorf("БД не подключена")
+		return i18n.Errorf("БД не подключена")
+	}
+	if m.access.ReadOnly {
+		return ErrReadOnly
 	}
+	if newPassword == "" {
+		return i18n.Errorf("пароль не может быть пустым")
+	}
+
+	oldPassword := m.password
+	safetyPath := m.dbPath + ".rekey-backup"
+	if err := exportDatabase(m.dbPath, oldPassword, safetyPath, oldPassword); err != nil {
+		return i18n.Errorf("не удалось создать страховочную копию: %w", err)
+	}
+
+	// rekey должен выполняться на единственном соединении. Закрытое подключение
+	// остается в m.db до замены: так запрос после неудачного отката вернет
+	// ошибку, а не обратится к nil
+	m.db.Close()
 
-	// Используем PRAGMA rekey для смены пароля
-	_, err := m.db.Exec(fmt.Sprintf("PRAGMA rekey = '%s'", newPassword))
+	if err := rekeyFile(m.dbPath, oldPassword, newPassword); err != nil {
+		return m.rollbackKey(safetyPath, oldPassword, i18n.Errorf("не удалось изменить ключ БД: %w", err))
+	}
+
+	db, err := m.open(newPassword)
 	if err != nil {
-		return fmt.Errorf("не удалось изменить пароль БД: %w", err)
+		return m.rollbackKey(safetyPath, oldPassword, i18n.Errorf("БД не открывается с новым ключом: %w", err))
+	}
+	m.db = db
+
+	if commit != nil {
+		if err := commit(); err != nil {
+			m.db.Close()
+			return m.rollbackKey(safetyPath, oldPassword, i18n.Errorf("не удалось сохранить новый пароль: %w", err))
+		}
 	}
 
 	m.password = newPassword
+	os.Remove(safetyPath)
 	return nil
 }
 
+// rollbackKey восстанавливает БД из страховочной копии и открывает ее старым ключом
+func (m *Manager) rollbackKey(safetyPath, oldPassword string, cause error) error {
+	// Журналы относятся к перешифрованному файлу и должны быть удалены вместе с ним
+	removeJournals(m.dbPath)
+
+	if err := os.Rename(safetyPath, m.dbPath); err != nil {
+		return i18n.Errorf("%w; не удалось восстановить БД, страховочная копия: %s (%v)", cause, safetyPath, err)
+	}
+
+	db, err := m.open(oldPassword)
+	if err != nil {
+		return i18n.Errorf("%w; не удалось открыть восстановленную БД: %v", cause, err)
+	}
+
+	m.db = db
+	m.password = oldPassword
+	return i18n.Errorf("%w; восстановлен прежний ключ", cause)
+}
+
 // fileExists проверяет существование файла
 func fileExists(path string) bool {
 	_, err := os.Stat(path)
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// newTestManager открывает новую БД во временном каталоге теста
+func newTestManager(t *testing.T, password string) *Manager {
+	t.Helper()
+
+	m, err := NewManager(filepath.Join(t.TempDir(), "test.db"), password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+func TestChangePasswordConcurrentReads(t *testing.T) {
+	m := newTestManager(t, "old-password")
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			db := m.GetDB()
+			if db == nil {
+				t.Error("GetDB вернул nil во время смены ключа")
+				return
+			}
+			var version int
+			db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version)
+		}
+	}()
+
+	for _, password := range []string{"new-password", "old-password"} {
+		if err := m.ChangePassword(password, nil); err != nil {
+			t.Fatalf("ChangePassword(%q): %v", password, err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	if !m.VerifyPassword("old-password") {
+		t.Error("пароль не сменился обратно")
+	}
+	var count int
+	if err := m.GetDB().QueryRow("SELECT COUNT(*) FROM sqlite_master").Scan(&count); err != nil {
+		t.Fatalf("БД не читается после смены ключа: %v", err)
+	}
+}
+
+func TestChangePasswordRollback(t *testing.T) {
+	m := newTestManager(t, "old-password")
+
+	err := m.ChangePassword("new-password", func() error {
+		return errors.New("не удалось сохранить конфигурацию")
+	})
+	if err == nil {
+		t.Fatal("ожидалась ошибка сохранения пароля")
+	}
+	if !m.VerifyPassword("old-password") {
+		t.Error("после отката должен остаться прежний пароль")
+	}
+	var count int
+	if err := m.GetDB().QueryRow("SELECT COUNT(*) FROM sqlite_master").Scan(&count); err != nil {
+		t.Fatalf("БД не открывается прежним ключом: %v", err)
+	}
+}
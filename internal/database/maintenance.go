@@ -0,0 +1,229 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+)
+
+// Stats - сведения о размере БД для обслуживания
+type Stats struct {
+	FileSize  int64 // размер основного файла
+	WALSize   int64 // размер файла журнала -wal (0 в режиме delete)
+	PageSize  int64
+	PageCount int64
+	FreePages int64 // свободные страницы, которые вернет VACUUM
+	Tables    []TableStats
+	Indexes   []IndexStats
+}
+
+// FreeSize возвращает объем свободных страниц в байтах
+func (s *Stats) FreeSize() int64 {
+	return s.FreePages * s.PageSize
+}
+
+// TableStats - размер таблицы
+type TableStats struct {
+	Name     string
+	Rows     int64
+	DataSize int64 // объем данных в столбцах, байт (без служебных структур SQLite)
+}
+
+// IndexStats - индекс и его статистика из sqlite_stat1
+type IndexStats struct {
+	Name    string
+	Table   string
+	Columns []string
+	Rows    int64 // строк в индексе по последнему ANALYZE, 0 - статистики нет
+	PerKey  int64 // в среднем строк на значение первого столбца, меньше - избирательнее
+}
+
+// Analyzed сообщает, собрана ли для индекса статистика
+func (i IndexStats) Analyzed() bool {
+	return i.Rows > 0
+}
+
+// FileSize возвращает размер файла БД вместе с журналом -wal
+func (m *Manager) FileSize() int64 {
+	var size int64
+	for _, path := range []string{m.dbPath, m.dbPath + "-wal"} {
+		if info, err := os.Stat(path); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// Stats собирает сведения о размере файла, таблиц и индексах.
+// Размер таблиц считается по данным столбцов, поэтому для больших БД занимает время.
+func (m *Manager) Stats() (*Stats, error) {
+	s := &Stats{}
+
+	if info, err := os.Stat(m.dbPath); err == nil {
+		s.FileSize = info.Size()
+	}
+	if info, err := os.Stat(m.dbPath + "-wal"); err == nil {
+		s.WALSize = info.Size()
+	}
+
+	for pragma, dest := range map[string]*int64{
+		"page_size":      &s.PageSize,
+		"page_count":     &s.PageCount,
+		"freelist_count": &s.FreePages,
+	} {
+		if err := m.db.QueryRow("PRAGMA " + pragma).Scan(dest); err != nil {
+			return nil, fmt.Errorf("PRAGMA %s: %w", pragma, err)
+		}
+	}
+
+	tables, err := m.names("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		t, err := m.tableStats(table)
+		if err != nil {
+			return nil, err
+		}
+		s.Tables = append(s.Tables, t)
+	}
+
+	if s.Indexes, err = m.indexStats(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// tableStats считает строки и объем данных таблицы
+func (m *Manager) tableStats(table string) (TableStats, error) {
+	columns, err := m.names("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return TableStats{}, err
+	}
+
+	sizes := make([]string, len(columns))
+	for i, column := range columns {
+		sizes[i] = fmt.Sprintf("COALESCE(length(CAST(%s AS BLOB)), 0)", quoteIdent(column))
+	}
+
+	t := TableStats{Name: table}
+	query := fmt.Sprintf("SELECT COUNT(*), COALESCE(SUM(%s), 0) FROM %s", strings.Join(sizes, " + "), quoteIdent(table))
+	if err := m.db.QueryRow(query).Scan(&t.Rows, &t.DataSize); err != nil {
+		return TableStats{}, i18n.Errorf("не удалось посчитать размер таблицы %s: %w", table, err)
+	}
+	return t, nil
+}
+
+// indexStats возвращает индексы с их столбцами и статистикой последнего ANALYZE
+func (m *Manager) indexStats() ([]IndexStats, error) {
+	rows, err := m.db.Query("SELECT name, tbl_name FROM sqlite_master WHERE type = 'index' ORDER BY tbl_name, name")
+	if err != nil {
+		return nil, err
+	}
+	var indexes []IndexStats
+	for rows.Next() {
+		var idx IndexStats
+		if err := rows.Scan(&idx.Name, &idx.Table); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		indexes = append(indexes, idx)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// sqlite_stat1 появляется только после первого ANALYZE
+	analyzed, err := m.names("SELECT name FROM sqlite_master WHERE type = 'table' AND name = 'sqlite_stat1'")
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range indexes {
+		idx := &indexes[i]
+		if idx.Columns, err = m.names("SELECT name FROM pragma_index_info(?) ORDER BY seqno", idx.Name); err != nil {
+			return nil, err
+		}
+
+		if len(analyzed) == 0 {
+			continue
+		}
+		stat, err := m.names("SELECT stat FROM sqlite_stat1 WHERE idx = ?", idx.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(stat) > 0 {
+			fmt.Sscanf(stat[0], "%d %d", &idx.Rows, &idx.PerKey)
+		}
+	}
+
+	return indexes, nil
+}
+
+// Vacuum перестраивает файл БД, возвращая свободные страницы файловой системе.
+// Требует монопольного доступа и места на диске под копию БД.
+func (m *Manager) Vacuum() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	err := m.WithRetry(func() error {
+		_, err := m.db.Exec("VACUUM")
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("VACUUM: %w", err)
+	}
+
+	// В режиме WAL перестроенные страницы остаются в журнале до контрольной точки;
+	// в режиме delete команда ничего не делает
+	if _, err := m.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("wal_checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Analyze собирает статистику таблиц и индексов для планировщика запросов
+func (m *Manager) Analyze() error {
+	return m.WithRetry(func() error {
+		_, err := m.db.Exec("ANALYZE")
+		return err
+	})
+}
+
+// Optimize выполняет PRAGMA optimize: ANALYZE только для таблиц,
+// статистика которых устарела
+func (m *Manager) Optimize() error {
+	return m.WithRetry(func() error {
+		_, err := m.db.Exec("PRAGMA optimize")
+		return err
+	})
+}
+
+// names выполняет запрос, возвращающий один текстовый столбец
+func (m *Manager) names(query string, args ...interface{}) ([]string, error) {
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		result = append(result, name)
+	}
+
+	return result, rows.Err()
+}
+
+// quoteIdent экранирует имя таблицы или столбца для подстановки в SQL
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
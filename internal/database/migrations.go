@@ -143,5 +143,167 @@ func GetMigrations() []Migration {
 				CREATE INDEX IF NOT EXISTS idx_employees_full_name ON employees(last_name, first_name);
 			`,
 		},
+		{
+			Version:     6,
+			Description: "Добавление журнала изменений",
+			SQL: `
+				-- Журнал всех созданий, изменений и удалений записей
+				CREATE TABLE IF NOT EXISTS audit_log (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					entity TEXT NOT NULL,
+					entity_id INTEGER NOT NULL,
+					action TEXT NOT NULL,
+					actor TEXT NOT NULL,
+					before_data TEXT,
+					after_data TEXT,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+				);
+
+				-- Индексы
+				CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity, entity_id);
+				CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+			`,
+		},
+		{
+			Version:     7,
+			Description: "Мягкое удаление записей (корзина)",
+			SQL: `
+				-- Время удаления в корзину, NULL - запись не удалена
+				ALTER TABLE projects ADD COLUMN deleted_at TIMESTAMP;
+				ALTER TABLE employees ADD COLUMN deleted_at TIMESTAMP;
+				ALTER TABLE snippets ADD COLUMN deleted_at TIMESTAMP;
+
+				-- Индексы
+				CREATE INDEX IF NOT EXISTS idx_projects_deleted_at ON projects(deleted_at);
+				CREATE INDEX IF NOT EXISTS idx_employees_deleted_at ON employees(deleted_at);
+				CREATE INDEX IF NOT EXISTS idx_snippets_deleted_at ON snippets(deleted_at);
+			`,
+		},
+		{
+			Version:     8,
+			Description: "Версии записей для обнаружения одновременного редактирования",
+			SQL: `
+				-- Версия увеличивается при каждом сохранении записи
+				ALTER TABLE projects ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+				ALTER TABLE employees ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+				ALTER TABLE snippets ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+			`,
+		},
+		{
+			Version:     9,
+			Description: "Счетчики изменений для обновления других экземпляров",
+			SQL: `
+				-- Счетчик увеличивается при каждой записи в журнал изменений по сущности
+				CREATE TABLE IF NOT EXISTS change_feed (
+					entity TEXT PRIMARY KEY,
+					seq INTEGER NOT NULL DEFAULT 0
+				);
+			`,
+		},
+		{
+			Version:     10,
+			Description: "Синхронизация между отдельными БД",
+			SQL: `
+				-- Узел, с которого пришло изменение; NULL - изменение сделано в этой БД
+				ALTER TABLE audit_log ADD COLUMN origin TEXT;
+
+				-- Запись, созданная на другом узле: узел и ID там на момент изменения.
+				-- NULL - запись создана в этой БД. Сохраняется сразу, потому что
+				-- ID может быть занят заново после окончательного удаления записи.
+				ALTER TABLE audit_log ADD COLUMN ref_node TEXT;
+				ALTER TABLE audit_log ADD COLUMN ref_id INTEGER;
+
+				-- Идентификатор узла и ключ подписи пакетов изменений
+				CREATE TABLE IF NOT EXISTS sync_meta (
+					key TEXT PRIMARY KEY,
+					value TEXT NOT NULL
+				);
+
+				-- Узел создается вместе со схемой, чтобы копии файла БД, сделанные
+				-- до первой синхронизации, знали исходный узел
+				INSERT OR IGNORE INTO sync_meta (key, value) VALUES
+					('node', lower(hex(randomblob(6)))),
+					('key', lower(hex(randomblob(32))));
+
+				-- Доверенные узлы: received - последнее примененное изменение узла,
+				-- acked - последнее изменение этой БД, которое узел подтвердил
+				CREATE TABLE IF NOT EXISTS sync_peers (
+					node TEXT PRIMARY KEY,
+					name TEXT NOT NULL,
+					public_key TEXT NOT NULL,
+					received INTEGER NOT NULL DEFAULT 0,
+					acked INTEGER NOT NULL DEFAULT 0,
+					last_import TIMESTAMP
+				);
+
+				-- Соответствие записей, созданных на других узлах, локальным ID
+				CREATE TABLE IF NOT EXISTS sync_map (
+					entity TEXT NOT NULL,
+					local_id INTEGER NOT NULL,
+					origin TEXT NOT NULL,
+					origin_id INTEGER NOT NULL,
+					PRIMARY KEY (entity, origin, origin_id)
+				);
+
+				-- Конфликты одновременного изменения поля на разных узлах
+				CREATE TABLE IF NOT EXISTS sync_conflicts (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					entity TEXT NOT NULL,
+					entity_id INTEGER NOT NULL,
+					field TEXT NOT NULL,
+					local_value TEXT,
+					remote_value TEXT,
+					remote_node TEXT NOT NULL,
+					remote_actor TEXT NOT NULL,
+					remote_time TIMESTAMP NOT NULL,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					resolution TEXT,
+					resolved_at TIMESTAMP
+				);
+
+				-- Индексы
+				CREATE INDEX IF NOT EXISTS idx_audit_log_origin ON audit_log(origin);
+				CREATE INDEX IF NOT EXISTS idx_sync_map_local ON sync_map(entity, local_id);
+				CREATE INDEX IF NOT EXISTS idx_sync_conflicts_resolved_at ON sync_conflicts(resolved_at);
+			`,
+		},
+		{
+			Version:     11,
+			Description: "Смены и журнал смены",
+			SQL: `
+				-- Смены; открытая смена - closed_at IS NULL, одновременно не больше одной
+				CREATE TABLE IF NOT EXISTS shifts (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					opened_by TEXT NOT NULL,
+					opened_at TIMESTAMP NOT NULL,
+					closed_by TEXT,
+					closed_at TIMESTAMP
+				);
+
+				-- Записи журнала смены
+				CREATE TABLE IF NOT EXISTS shift_log (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					shift_id INTEGER NOT NULL,
+					severity TEXT NOT NULL DEFAULT 'info',
+					message TEXT NOT NULL,
+					author TEXT NOT NULL,
+					created_at TIMESTAMP NOT NULL,
+					FOREIGN KEY (shift_id) REFERENCES shifts(id) ON DELETE CASCADE
+				);
+
+				-- Индексы
+				CREATE INDEX IF NOT EXISTS idx_shifts_closed_at ON shifts(closed_at);
+				CREATE INDEX IF NOT EXISTS idx_shift_log_shift_id ON shift_log(shift_id);
+			`,
+		},
+	}
+}
+
+// LatestVersion возвращает версию схемы, которую создает текущая версия приложения
+func LatestVersion() int {
+	latest := 0
+	for _, migration := range GetMigrations() {
+		latest = max(latest, migration.Version)
 	}
+	return latest
 }
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"os"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+)
+
+// ExportPlain выгружает БД в незашифрованный файл SQLite destPath.
+// Файл доступен только владельцу, но данные в нем открыты.
+func (m *Manager) ExportPlain(destPath string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.db == nil {
+		return i18n.Errorf("БД не подключена")
+	}
+
+	if err := exportDatabase(m.dbPath, m.password, destPath, ""); err != nil {
+		return err
+	}
+	return os.Chmod(destPath, 0600)
+}
+
+// PlainSchemaVersion возвращает версию схемы незашифрованного файла SQLite.
+// Ошибка, если файл зашифрован или не является БД приложения.
+func PlainSchemaVersion(path string) (int, error) {
+	if !fileExists(path) {
+		return 0, i18n.Errorf("файл %s не найден", path)
+	}
+
+	db, err := openVerified(path, "", AccessOptions{})
+	if err != nil {
+		if isWrongKey(err) {
+			return 0, i18n.Errorf("%s не является незашифрованной БД SQLite", path)
+		}
+		return 0, err
+	}
+	defer db.Close()
+
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_version'").Scan(&tables); err != nil {
+		return 0, err
+	}
+	if tables == 0 {
+		return 0, i18n.Errorf("в файле %s нет таблицы schema_version, это не БД Jotnal", path)
+	}
+
+	var version int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// ImportPlain заменяет БД данными из незашифрованного файла srcPath,
+// зашифровав их текущим ключом. Файл новее приложения не принимается,
+// к файлу старой версии после импорта применяются недостающие миграции.
+//
+// Новая БД сначала собирается рядом с текущей и проверяется; текущая
+// сохраняется как страховочная копия и возвращается на место, если новую
+// не удалось открыть.
+func (m *Manager) ImportPlain(srcPath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.db == nil {
+		return i18n.Errorf("БД не подключена")
+	}
+	if m.access.ReadOnly {
+		return ErrReadOnly
+	}
+
+	version, err := PlainSchemaVersion(srcPath)
+	if err != nil {
+		return err
+	}
+	if version > LatestVersion() {
+		return i18n.Errorf("версия схемы файла %d новее поддерживаемой %d, обновите приложение", version, LatestVersion())
+	}
+
+	importPath := m.dbPath + ".import"
+	if err := exportDatabase(srcPath, "", importPath, m.password); err != nil {
+		return i18n.Errorf("не удалось зашифровать данные: %w", err)
+	}
+	check, err := openVerified(importPath, m.password, AccessOptions{})
+	if err != nil {
+		os.Remove(importPath)
+		return i18n.Errorf("зашифрованная копия не открывается: %w", err)
+	}
+	check.Close()
+
+	// Последнее соединение переносит журнал WAL в основной файл
+	m.db.Close()
+	m.db = nil
+
+	safetyPath := m.dbPath + ".import-backup"
+	if err := os.Rename(m.dbPath, safetyPath); err != nil {
+		os.Remove(importPath)
+		return m.reopen(i18n.Errorf("не удалось сохранить текущую БД: %w", err))
+	}
+	removeJournals(m.dbPath)
+
+	if err := os.Rename(importPath, m.dbPath); err != nil {
+		return m.rollbackImport(safetyPath, i18n.Errorf("не удалось заменить БД: %w", err))
+	}
+
+	db, err := m.open(m.password)
+	if err != nil {
+		return m.rollbackImport(safetyPath, i18n.Errorf("импортированная БД не открывается: %w", err))
+	}
+	m.db = db
+
+	prevVersion := m.version
+	m.version = version
+	if err := m.runMigrations(); err != nil {
+		m.db.Close()
+		m.db = nil
+		m.version = prevVersion
+		return m.rollbackImport(safetyPath, err)
+	}
+
+	os.Remove(safetyPath)
+	return nil
+}
+
+// rollbackImport возвращает на место БД, сохраненную перед импортом
+func (m *Manager) rollbackImport(safetyPath string, cause error) error {
+	removeJournals(m.dbPath)
+	if err := os.Rename(safetyPath, m.dbPath); err != nil {
+		return i18n.Errorf("%w; не удалось восстановить БД, страховочная копия: %s (%v)", cause, safetyPath, err)
+	}
+	return m.reopen(i18n.Errorf("%w; восстановлена прежняя БД", cause))
+}
+
+// reopen заново открывает БД после неудачной замены файла и возвращает cause
+func (m *Manager) reopen(cause error) error {
+	db, err := m.open(m.password)
+	if err != nil {
+		return i18n.Errorf("%w; не удалось открыть БД: %v", cause, err)
+	}
+	m.db = db
+	return cause
+}
+
+// removeJournals удаляет файлы журналов, относящиеся к файлу БД path
+func removeJournals(path string) {
+	os.Remove(path + "-wal")
+	os.Remove(path + "-shm")
+	os.Remove(path + "-journal")
+}
@@ -0,0 +1,199 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Языки сообщений
+const (
+	RU = "ru"
+	EN = "en"
+)
+
+// Каталоги сообщений. Ключ - исходный текст на русском, как он написан в
+// коде; значение - перевод или формы множественного числа (см. Plural).
+// Для русского каталог содержит только формы множественного числа.
+//
+//go:embed locales/*.json
+var locales embed.FS
+
+// catalog - сообщения одного языка
+type catalog struct {
+	lang     string
+	messages map[string]string
+	plurals  map[string]map[string]string // ключ -> форма (one, few, many, other) -> текст
+	layouts  dateLayouts
+}
+
+// dateLayouts - форматы даты и времени языка
+type dateLayouts struct {
+	date, dateTime, timestamp string
+}
+
+var layouts = map[string]dateLayouts{
+	RU: {date: "02.01.2006", dateTime: "02.01.2006 15:04", timestamp: "02.01.2006 15:04:05"},
+	EN: {date: "2 Jan 2006", dateTime: "2 Jan 2006 15:04", timestamp: "2 Jan 2006 15:04:05"},
+}
+
+var (
+	catalogs = map[string]*catalog{}
+	current  atomic.Pointer[catalog]
+)
+
+func init() {
+	for lang := range layouts {
+		c, err := loadCatalog(lang)
+		if err != nil {
+			panic(err)
+		}
+		catalogs[lang] = c
+	}
+	current.Store(catalogs[RU])
+}
+
+// loadCatalog разбирает встроенный каталог языка lang
+func loadCatalog(lang string) (*catalog, error) {
+	data, err := locales.ReadFile("locales/" + lang + ".json")
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("locales/%s.json: %w", lang, err)
+	}
+
+	c := &catalog{
+		lang:     lang,
+		messages: make(map[string]string),
+		plurals:  make(map[string]map[string]string),
+		layouts:  layouts[lang],
+	}
+	for key, value := range raw {
+		var text string
+		if err := json.Unmarshal(value, &text); err == nil {
+			c.messages[key] = text
+			continue
+		}
+		var forms map[string]string
+		if err := json.Unmarshal(value, &forms); err != nil {
+			return nil, fmt.Errorf("locales/%s.json: %q: ожидается строка или формы множественного числа", lang, key)
+		}
+		c.plurals[key] = forms
+	}
+	return c, nil
+}
+
+// SetLanguage задает язык сообщений; неизвестный язык - русский
+func SetLanguage(lang string) {
+	c, ok := catalogs[lang]
+	if !ok {
+		c = catalogs[RU]
+	}
+	current.Store(c)
+}
+
+// Language возвращает текущий язык сообщений
+func Language() string {
+	return current.Load().lang
+}
+
+// T переводит сообщение на текущий язык; если перевода нет, возвращает исходный текст
+func T(msg string) string {
+	if text, ok := current.Load().messages[msg]; ok {
+		return text
+	}
+	return msg
+}
+
+// Sprintf переводит формат и подставляет в него аргументы
+func Sprintf(format string, args ...interface{}) string {
+	return fmt.Sprintf(T(format), args...)
+}
+
+// Printf переводит формат и выводит сообщение в стандартный вывод
+func Printf(format string, args ...interface{}) (int, error) {
+	return fmt.Printf(T(format), args...)
+}
+
+// Fprintf переводит формат и выводит сообщение в w
+func Fprintf(w io.Writer, format string, args ...interface{}) (int, error) {
+	return fmt.Fprintf(w, T(format), args...)
+}
+
+// Errorf переводит формат и создает ошибку, как fmt.Errorf (в том числе с %w)
+func Errorf(format string, args ...interface{}) error {
+	return fmt.Errorf(T(format), args...)
+}
+
+// Plural выбирает форму сообщения format для числа n и подставляет в нее
+// аргументы: Plural(n, "%d запись", n) - «1 запись», «3 записи», «5 записей».
+// Формы задаются в каталоге языка; если их нет, используется перевод формата.
+func Plural(n int, format string, args ...interface{}) string {
+	c := current.Load()
+	if forms, ok := c.plurals[format]; ok {
+		if text, ok := forms[pluralForm(c.lang, n)]; ok {
+			return fmt.Sprintf(text, args...)
+		}
+		if text, ok := forms["other"]; ok {
+			return fmt.Sprintf(text, args...)
+		}
+	}
+	return Sprintf(format, args...)
+}
+
+// pluralForm возвращает категорию множественного числа CLDR для n
+func pluralForm(lang string, n int) string {
+	if n < 0 {
+		n = -n
+	}
+	if lang == EN {
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	}
+
+	switch mod10, mod100 := n%10, n%100; {
+	case mod10 == 1 && mod100 != 11:
+		return "one"
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return "few"
+	default:
+		return "many"
+	}
+}
+
+// Date форматирует дату по правилам текущего языка
+func Date(t time.Time) string {
+	return t.Format(current.Load().layouts.date)
+}
+
+// DateTime форматирует дату и время с точностью до минуты
+func DateTime(t time.Time) string {
+	return t.Format(current.Load().layouts.dateTime)
+}
+
+// Timestamp форматирует дату и время с секундами
+func Timestamp(t time.Time) string {
+	return t.Format(current.Load().layouts.timestamp)
+}
+
+// localizedError - ошибка, текст которой переводится при выводе, а не при
+// создании: сообщения ошибок-переменных пакетов создаются до выбора языка
+type localizedError struct {
+	msg string
+}
+
+func (e *localizedError) Error() string {
+	return T(e.msg)
+}
+
+// NewError создает ошибку с переводимым текстом, как errors.New
+func NewError(msg string) error {
+	return &localizedError{msg: msg}
+}
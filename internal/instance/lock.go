@@ -0,0 +1,158 @@
+package instance
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+)
+
+// ErrLocked возвращается, если БД уже открыта другим экземпляром приложения
+var ErrLocked = i18n.NewError("БД уже открыта другим экземпляром Jotnal")
+
+// Owner описывает процесс, удерживающий блокировку
+type Owner struct {
+	Host    string    `json:"host"`
+	PID     int       `json:"pid"`
+	User    string    `json:"user"`
+	Started time.Time `json:"started"`
+}
+
+// LockedError сообщает, кто удерживает блокировку
+type LockedError struct {
+	Path  string
+	Owner *Owner // nil, если данные о владельце прочитать не удалось
+}
+
+// Error возвращает описание с именем компьютера и PID владельца
+func (e *LockedError) Error() string {
+	if e.Owner == nil {
+		return i18n.Sprintf("%s (файл блокировки %s)", ErrLocked, e.Path)
+	}
+	return i18n.Sprintf("%s: компьютер %s, PID %d, пользователь %s, запущен %s",
+		ErrLocked, e.Owner.Host, e.Owner.PID, e.Owner.User, i18n.Timestamp(e.Owner.Started.Local()))
+}
+
+// Unwrap позволяет проверять ошибку через errors.Is(err, ErrLocked)
+func (e *LockedError) Unwrap() error {
+	return ErrLocked
+}
+
+// Lock - эксклюзивная блокировка единственного экземпляра. Файл блокировки
+// остается открытым, пока процесс работает; ОС снимает блокировку и при
+// аварийном завершении, поэтому устаревший файл не мешает следующему запуску.
+type Lock struct {
+	path string
+	file *os.File
+}
+
+// Acquire захватывает блокировку path, не дожидаясь ее освобождения
+func Acquire(path string) (*Lock, error) {
+	// Файл могут удалить между открытием и блокировкой (владелец завершился),
+	// тогда блокировка удаленного файла ничего не защищает и попытка повторяется
+	for attempt := 0; attempt < 3; attempt++ {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, i18n.Errorf("не удалось открыть файл блокировки: %w", err)
+		}
+
+		if err := lockFile(f); err != nil {
+			owner := readOwner(f)
+			f.Close()
+			if errors.Is(err, errWouldBlock) {
+				return nil, &LockedError{Path: path, Owner: owner}
+			}
+			return nil, i18n.Errorf("не удалось заблокировать %s: %w", path, err)
+		}
+
+		if !samePath(f, path) {
+			unlockFile(f)
+			f.Close()
+			continue
+		}
+
+		if err := writeOwner(f); err != nil {
+			unlockFile(f)
+			f.Close()
+			return nil, i18n.Errorf("не удалось записать файл блокировки: %w", err)
+		}
+
+		return &Lock{path: path, file: f}, nil
+	}
+
+	return nil, i18n.Errorf("не удалось заблокировать %s: файл постоянно пересоздается", path)
+}
+
+// samePath проверяет, что открытый файл по-прежнему доступен по пути path
+func samePath(f *os.File, path string) bool {
+	opened, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(opened, current)
+}
+
+// Release снимает блокировку и удаляет файл
+func (l *Lock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+
+	// Файл удаляется до снятия блокировки, чтобы другой процесс не успел
+	// захватить его и остаться с удаленным файлом
+	os.Remove(l.path)
+	unlockFile(l.file)
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// readOwner читает данные владельца из файла блокировки
+func readOwner(f *os.File) *Owner {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil
+	}
+	data, err := io.ReadAll(io.LimitReader(f, 4096))
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+
+	var owner Owner
+	if json.Unmarshal(data, &owner) != nil {
+		return nil
+	}
+	return &owner
+}
+
+// writeOwner записывает в файл блокировки данные текущего процесса
+func writeOwner(f *os.File) error {
+	owner := Owner{
+		PID:     os.Getpid(),
+		Started: time.Now(),
+	}
+	owner.Host, _ = os.Hostname()
+	if u, err := user.Current(); err == nil {
+		owner.User = u.Username
+	}
+
+	data, err := json.Marshal(owner)
+	if err != nil {
+		return err
+	}
+
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt(data, 0); err != nil {
+		return err
+	}
+	return f.Sync()
+}
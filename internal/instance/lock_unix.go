@@ -0,0 +1,28 @@
+//go:build !windows
+
+package instance
+
+import (
+	"errors"
+	"os"
+	"syscall"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+)
+
+// errWouldBlock - блокировка уже захвачена другим процессом
+var errWouldBlock = i18n.NewError("блокировка занята")
+
+// lockFile захватывает эксклюзивную блокировку файла без ожидания
+func lockFile(f *os.File) error {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return errWouldBlock
+	}
+	return err
+}
+
+// unlockFile снимает блокировку файла
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,38 @@
+//go:build windows
+
+package instance
+
+import (
+	"errors"
+	"os"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"golang.org/x/sys/windows"
+)
+
+// errWouldBlock - блокировка уже захвачена другим процессом
+var errWouldBlock = i18n.NewError("блокировка занята")
+
+// Блокируется байт далеко за концом файла: заблокированную область Windows
+// не дает читать, а данные владельца должны оставаться доступными
+const (
+	lockOffset = 1 << 30
+	lockLength = 1
+)
+
+// lockFile захватывает эксклюзивную блокировку файла без ожидания
+func lockFile(f *os.File) error {
+	ol := &windows.Overlapped{Offset: lockOffset}
+	err := windows.LockFileEx(windows.Handle(f.Fd()),
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, lockLength, 0, ol)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) || errors.Is(err, windows.ERROR_IO_PENDING) {
+		return errWouldBlock
+	}
+	return err
+}
+
+// unlockFile снимает блокировку файла
+func unlockFile(f *os.File) error {
+	ol := &windows.Overlapped{Offset: lockOffset}
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, lockLength, 0, ol)
+}
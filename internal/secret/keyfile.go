@@ -0,0 +1,192 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"golang.org/x/crypto/argon2"
+)
+
+// ErrWrongPassphrase возвращается, если ключевой файл не удалось расшифровать
+var ErrWrongPassphrase = i18n.NewError("неверная парольная фраза ключевого файла")
+
+// keyFileVersion - текущая версия формата ключевого файла
+const keyFileVersion = 1
+
+// Допустимые параметры ключевого файла. Файл читается до расшифровки, и
+// испорченные значения не должны ронять argon2 и GCM или занимать всю память.
+const (
+	keyFileSaltSize  = 16
+	keyFileNonceSize = 12
+	keyFileMaxTime   = 16
+	keyFileMaxMemory = 1024 * 1024 // КиБ, 1 ГиБ
+)
+
+// keyFileAAD связывает шифртекст с форматом ключевого файла
+var keyFileAAD = []byte("jotnal-keyfile-v1")
+
+// KeyFile - локальный файл с паролем БД, зашифрованным ключом,
+// который выводится из парольной фразы через Argon2id
+type KeyFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Time       uint32 `json:"time"`
+	Memory     uint32 `json:"memory"` // КиБ
+	Threads    uint8  `json:"threads"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// NewKeyFile создает ключевой файл, защищающий secret парольной фразой.
+// Возвращает также выведенный ключ, чтобы позже перезаписать секрет без
+// повторного ввода фразы.
+func NewKeyFile(passphrase, secret string) (*KeyFile, []byte, error) {
+	k := &KeyFile{
+		Version: keyFileVersion,
+		KDF:     "argon2id",
+		Time:    3,
+		Memory:  64 * 1024,
+		Threads: 4,
+		Salt:    make([]byte, keyFileSaltSize),
+	}
+	if _, err := rand.Read(k.Salt); err != nil {
+		return nil, nil, err
+	}
+
+	kek := k.deriveKey(passphrase)
+	if err := k.Seal(kek, secret); err != nil {
+		return nil, nil, err
+	}
+
+	return k, kek, nil
+}
+
+// ReadKeyFile читает ключевой файл с диска
+func ReadKeyFile(path string) (*KeyFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &KeyFile{}
+	if err := json.Unmarshal(data, k); err != nil {
+		return nil, i18n.Errorf("поврежденный ключевой файл %s: %w", path, err)
+	}
+	if k.Version != keyFileVersion || k.KDF != "argon2id" {
+		return nil, i18n.Errorf("неподдерживаемый формат ключевого файла %s", path)
+	}
+	if err := k.check(); err != nil {
+		return nil, i18n.Errorf("поврежденный ключевой файл %s: %w", path, err)
+	}
+
+	return k, nil
+}
+
+// check проверяет параметры Argon2id, соль и nonce ключевого файла
+func (k *KeyFile) check() error {
+	switch {
+	case k.Time == 0 || k.Time > keyFileMaxTime:
+		return i18n.Errorf("число проходов Argon2 должно быть от 1 до %d", keyFileMaxTime)
+	case k.Threads == 0:
+		return i18n.Errorf("число потоков Argon2 должно быть от 1 до 255")
+	case k.Memory == 0 || k.Memory > keyFileMaxMemory:
+		return i18n.Errorf("память Argon2 %d КиБ вне допустимого диапазона 1-%d КиБ", k.Memory, keyFileMaxMemory)
+	case len(k.Salt) != keyFileSaltSize:
+		return i18n.Errorf("соль должна занимать %d байт, а не %d", keyFileSaltSize, len(k.Salt))
+	case len(k.Nonce) != keyFileNonceSize:
+		return i18n.Errorf("nonce должен занимать %d байт, а не %d", keyFileNonceSize, len(k.Nonce))
+	}
+	return nil
+}
+
+// Unlock расшифровывает секрет парольной фразой
+func (k *KeyFile) Unlock(passphrase string) (string, []byte, error) {
+	kek := k.deriveKey(passphrase)
+
+	aead, err := newAEAD(kek)
+	if err != nil {
+		return "", nil, err
+	}
+
+	plain, err := aead.Open(nil, k.Nonce, k.Ciphertext, keyFileAAD)
+	if err != nil {
+		return "", nil, ErrWrongPassphrase
+	}
+
+	return string(plain), kek, nil
+}
+
+// Seal шифрует новый секрет уже выведенным ключом
+func (k *KeyFile) Seal(kek []byte, secret string) error {
+	aead, err := newAEAD(kek)
+	if err != nil {
+		return err
+	}
+
+	k.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(k.Nonce); err != nil {
+		return err
+	}
+
+	k.Ciphertext = aead.Seal(nil, k.Nonce, []byte(secret), keyFileAAD)
+	return nil
+}
+
+// Write атомарно сохраняет ключевой файл
+func (k *KeyFile) Write(path string) error {
+	data, err := json.MarshalIndent(k, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, data)
+}
+
+// deriveKey выводит ключ шифрования из парольной фразы
+func (k *KeyFile) deriveKey(passphrase string) []byte {
+	return argon2.IDKey([]byte(passphrase), k.Salt, k.Time, k.Memory, k.Threads, 32)
+}
+
+// newAEAD создает AES-256-GCM
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// RandomPassword генерирует случайный пароль БД для хранения в ключевом файле
+func RandomPassword() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// writeFileAtomic записывает файл с правами 0600 через временный файл
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
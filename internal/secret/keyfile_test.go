@@ -0,0 +1,68 @@
+package secret
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeyFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.key")
+
+	k, _, err := NewKeyFile("фраза", "пароль-бд")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Write(path); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadKeyFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, _, err := read.Unlock("фраза")
+	if err != nil || secret != "пароль-бд" {
+		t.Fatalf("Unlock() = %q, %v", secret, err)
+	}
+	if _, _, err := read.Unlock("другая фраза"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Unlock() с неверной фразой = %v, ожидалась ErrWrongPassphrase", err)
+	}
+}
+
+func TestReadKeyFileRejectsBadParameters(t *testing.T) {
+	valid, _, err := NewKeyFile("фраза", "пароль-бд")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(k *KeyFile)
+	}{
+		{"нулевое число проходов", func(k *KeyFile) { k.Time = 0 }},
+		{"огромное число проходов", func(k *KeyFile) { k.Time = 1 << 31 }},
+		{"нулевое число потоков", func(k *KeyFile) { k.Threads = 0 }},
+		{"нулевая память", func(k *KeyFile) { k.Memory = 0 }},
+		{"огромная память", func(k *KeyFile) { k.Memory = 1 << 31 }},
+		{"короткая соль", func(k *KeyFile) { k.Salt = k.Salt[:4] }},
+		{"короткий nonce", func(k *KeyFile) { k.Nonce = k.Nonce[:3] }},
+		{"без nonce", func(k *KeyFile) { k.Nonce = nil }},
+		{"другая версия", func(k *KeyFile) { k.Version = 2 }},
+		{"другой KDF", func(k *KeyFile) { k.KDF = "scrypt" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := *valid
+			tt.modify(&k)
+
+			path := filepath.Join(t.TempDir(), "db.key")
+			if err := k.Write(path); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ReadKeyFile(path); err == nil {
+				t.Fatal("ожидалась ошибка чтения ключевого файла")
+			}
+		})
+	}
+}
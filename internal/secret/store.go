@@ -0,0 +1,236 @@
+package secret
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+)
+
+// Options задает источники пароля, переопределяющие конфигурацию
+type Options struct {
+	PasswordFile string // --password-file или JOTNAL_PASSWORD_FILE
+	PasswordFD   int    // --password-fd, -1 если не задан
+
+	// Prompt запрашивает секрет у пользователя без отображения ввода
+	Prompt func(label string) (string, error)
+}
+
+// Store загружает и сохраняет пароль БД выбранным способом
+type Store struct {
+	cfgManager *config.Manager
+	opts       Options
+
+	// Для режима keyfile - открытый ключевой файл и выведенный ключ,
+	// чтобы после смены пароля перешифровать его без повторного ввода фразы
+	keyFile *KeyFile
+	kek     []byte
+}
+
+// NewStore создает хранилище пароля БД
+func NewStore(cfgManager *config.Manager, opts Options) *Store {
+	return &Store{
+		cfgManager: cfgManager,
+		opts:       opts,
+	}
+}
+
+// Mode возвращает действующий способ хранения пароля.
+// Флаги командной строки имеют приоритет над конфигурацией.
+func (s *Store) Mode() string {
+	if s.opts.PasswordFile != "" {
+		return config.PasswordModeFile
+	}
+	if s.opts.PasswordFD >= 0 {
+		return config.PasswordModeFD
+	}
+	return s.cfgManager.Get().Database.EffectivePasswordMode()
+}
+
+// Persistent сообщает, сохраняет ли Save новый пароль.
+// В режимах prompt и fd пароль нигде не хранится, и после смены его нужно
+// передавать приложению вручную.
+func (s *Store) Persistent() bool {
+	switch s.Mode() {
+	case config.PasswordModePrompt, config.PasswordModeFD:
+		return false
+	}
+	return true
+}
+
+// Load возвращает пароль БД
+func (s *Store) Load() (string, error) {
+	cfg := s.cfgManager.Get().Database
+
+	switch s.Mode() {
+	case config.PasswordModeConfig:
+		return cfg.Password, nil
+	case config.PasswordModePrompt:
+		return s.prompt(i18n.T("Введите пароль для базы данных: "))
+	case config.PasswordModeFile:
+		return readPasswordFile(s.passwordFile())
+	case config.PasswordModeFD:
+		return readPasswordFD(s.passwordFD())
+	case config.PasswordModeKeyFile:
+		return s.unlockKeyFile(cfg.KeyFile)
+	case "":
+		return "", i18n.Errorf("способ хранения пароля БД не настроен")
+	}
+
+	return "", i18n.Errorf("неизвестный способ хранения пароля %q", cfg.PasswordMode)
+}
+
+// Save сохраняет новый пароль БД тем же способом, каким он был загружен
+func (s *Store) Save(password string) error {
+	switch s.Mode() {
+	case config.PasswordModeConfig:
+		return s.cfgManager.UpdateDatabasePassword(password)
+	case config.PasswordModeFile:
+		return writeFileAtomic(s.passwordFile(), []byte(password+"\n"))
+	case config.PasswordModeKeyFile:
+		if s.keyFile == nil {
+			return i18n.Errorf("ключевой файл не открыт")
+		}
+		// Перешифровываем копию, чтобы при ошибке записи не испортить открытый файл
+		updated := *s.keyFile
+		if err := updated.Seal(s.kek, password); err != nil {
+			return err
+		}
+		if err := updated.Write(s.cfgManager.Get().Database.KeyFile); err != nil {
+			return err
+		}
+		s.keyFile = &updated
+		return nil
+	}
+
+	// prompt и fd: пароль нигде не хранится
+	return nil
+}
+
+// VerifyPassphrase проверяет парольную фразу открытого ключевого файла
+func (s *Store) VerifyPassphrase(passphrase string) bool {
+	if s.keyFile == nil {
+		return false
+	}
+	_, _, err := s.keyFile.Unlock(passphrase)
+	return err == nil
+}
+
+// SetupKeyFile создает ключевой файл с паролем БД и переключает конфигурацию
+// на режим keyfile. Используется при первом запуске и при миграции.
+func (s *Store) SetupKeyFile(passphrase, password string) error {
+	keyFile, kek, err := NewKeyFile(passphrase, password)
+	if err != nil {
+		return err
+	}
+
+	path := s.cfgManager.Get().Database.KeyFile
+	if path == "" {
+		return i18n.Errorf("не задан путь к ключевому файлу")
+	}
+	if err := keyFile.Write(path); err != nil {
+		return i18n.Errorf("не удалось сохранить ключевой файл: %w", err)
+	}
+
+	if err := s.cfgManager.UpdatePasswordStorage(config.PasswordModeKeyFile, "", path); err != nil {
+		return err
+	}
+
+	s.keyFile, s.kek = keyFile, kek
+	return nil
+}
+
+// unlockKeyFile запрашивает парольную фразу и расшифровывает ключевой файл
+func (s *Store) unlockKeyFile(path string) (string, error) {
+	keyFile, err := ReadKeyFile(path)
+	if err != nil {
+		return "", i18n.Errorf("не удалось прочитать ключевой файл: %w", err)
+	}
+
+	passphrase, err := s.prompt(i18n.T("Парольная фраза ключевого файла: "))
+	if err != nil {
+		return "", err
+	}
+
+	password, kek, err := keyFile.Unlock(passphrase)
+	if err != nil {
+		return "", err
+	}
+
+	s.keyFile, s.kek = keyFile, kek
+	return password, nil
+}
+
+// prompt запрашивает секрет у пользователя
+func (s *Store) prompt(label string) (string, error) {
+	if s.opts.Prompt == nil {
+		return "", i18n.Errorf("интерактивный ввод пароля недоступен")
+	}
+	return s.opts.Prompt(label)
+}
+
+// passwordFile возвращает путь к файлу с паролем
+func (s *Store) passwordFile() string {
+	if s.opts.PasswordFile != "" {
+		return s.opts.PasswordFile
+	}
+	return s.cfgManager.Get().Database.PasswordFile
+}
+
+// passwordFD возвращает номер дескриптора с паролем
+func (s *Store) passwordFD() int {
+	if s.opts.PasswordFD >= 0 {
+		return s.opts.PasswordFD
+	}
+	return s.cfgManager.Get().Database.PasswordFD
+}
+
+// readPasswordFile читает пароль из первой строки файла
+func readPasswordFile(path string) (string, error) {
+	if path == "" {
+		return "", i18n.Errorf("не задан файл с паролем")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", i18n.Errorf("не удалось открыть файл с паролем: %w", err)
+	}
+	defer f.Close()
+
+	return readPassword(f)
+}
+
+// readPasswordFD читает пароль из унаследованного файлового дескриптора
+func readPasswordFD(fd int) (string, error) {
+	if fd < 0 {
+		return "", i18n.Errorf("не задан дескриптор с паролем")
+	}
+
+	f := os.NewFile(uintptr(fd), fmt.Sprintf("fd%d", fd))
+	if f == nil {
+		return "", i18n.Errorf("неверный дескриптор %d", fd)
+	}
+	defer f.Close()
+
+	return readPassword(f)
+}
+
+// readPassword читает первую строку без завершающего перевода строки
+func readPassword(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", i18n.Errorf("не удалось прочитать пароль: %w", err)
+	}
+
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", i18n.Errorf("пароль пуст")
+	}
+
+	return password, nil
+}
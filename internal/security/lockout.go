@@ -0,0 +1,131 @@
+package security
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+)
+
+// lockoutState - содержимое файла состояния блокировки
+type lockoutState struct {
+	Failures    int       `json:"failures"`
+	LastFailure time.Time `json:"last_failure"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// Lockout ограничивает число попыток открыть БД. После LockoutThreshold
+// неудачных попыток подряд вход блокируется, и с каждой следующей ошибкой
+// время блокировки удваивается. Состояние хранится в файле рядом с БД,
+// поэтому блокировка действует для всех, кто открывает эту БД на рабочей станции.
+type Lockout struct {
+	path  string
+	cfg   config.SecurityConfig
+	state lockoutState
+}
+
+// NewLockout загружает состояние блокировки для БД dbPath
+func NewLockout(dbPath string, cfg config.SecurityConfig) *Lockout {
+	l := &Lockout{
+		path: dbPath + ".unlock-state",
+		cfg:  cfg,
+	}
+	l.load()
+	return l
+}
+
+// Remaining возвращает, сколько еще продлится блокировка (0 - вход разрешен)
+func (l *Lockout) Remaining() time.Duration {
+	l.load()
+	if wait := time.Until(l.state.LockedUntil); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// Failures возвращает число неудачных попыток подряд
+func (l *Lockout) Failures() int {
+	return l.state.Failures
+}
+
+// RecordFailure учитывает неудачную попытку и возвращает время новой блокировки
+func (l *Lockout) RecordFailure() (time.Duration, error) {
+	l.load()
+	l.state.Failures++
+	l.state.LastFailure = time.Now()
+
+	delay := l.delay(l.state.Failures)
+	if delay > 0 {
+		l.state.LockedUntil = l.state.LastFailure.Add(delay)
+	}
+
+	return delay, l.save()
+}
+
+// RecordSuccess сбрасывает счетчик после успешного входа
+func (l *Lockout) RecordSuccess() error {
+	l.state = lockoutState{}
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// delay вычисляет время блокировки после failures неудачных попыток
+func (l *Lockout) delay(failures int) time.Duration {
+	if l.cfg.LockoutThreshold <= 0 || failures < l.cfg.LockoutThreshold {
+		return 0
+	}
+
+	base := time.Duration(l.cfg.LockoutBaseDelay) * time.Second
+	max := time.Duration(l.cfg.LockoutMaxDelay) * time.Second
+
+	// Без верхней границы удвоение останавливается до переполнения
+	delay := base
+	for i := l.cfg.LockoutThreshold; i < failures && (max <= 0 || delay < max) && delay <= math.MaxInt64/2; i++ {
+		delay *= 2
+	}
+	if max > 0 && delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// load читает состояние из файла; отсутствующий или поврежденный файл
+// означает отсутствие блокировки
+func (l *Lockout) load() {
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		l.state = lockoutState{}
+		return
+	}
+
+	var state lockoutState
+	if json.Unmarshal(data, &state) == nil {
+		l.state = state
+	}
+}
+
+// save записывает состояние в файл
+func (l *Lockout) save() error {
+	data, err := json.Marshal(l.state)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(l.path, data, 0600); err != nil {
+		return i18n.Errorf("не удалось сохранить состояние блокировки: %w", err)
+	}
+	return nil
+}
+
+// FormatWait форматирует время ожидания для сообщений пользователю
+func FormatWait(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d < time.Minute {
+		return i18n.Sprintf("%d с", int(d.Seconds()))
+	}
+	return i18n.Sprintf("%d мин %d с", int(d.Minutes()), int(d.Seconds())%60)
+}
@@ -0,0 +1,75 @@
+package security
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/config"
+)
+
+func TestLockoutDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.SecurityConfig
+		failures int
+		want     time.Duration
+	}{
+		{"до порога", config.SecurityConfig{LockoutThreshold: 3, LockoutBaseDelay: 30, LockoutMaxDelay: 3600}, 2, 0},
+		{"на пороге", config.SecurityConfig{LockoutThreshold: 3, LockoutBaseDelay: 30, LockoutMaxDelay: 3600}, 3, 30 * time.Second},
+		{"удвоение", config.SecurityConfig{LockoutThreshold: 3, LockoutBaseDelay: 30, LockoutMaxDelay: 3600}, 5, 120 * time.Second},
+		{"верхняя граница", config.SecurityConfig{LockoutThreshold: 3, LockoutBaseDelay: 30, LockoutMaxDelay: 3600}, 20, time.Hour},
+		{"блокировка выключена", config.SecurityConfig{LockoutThreshold: 0, LockoutBaseDelay: 30}, 100, 0},
+		{"без границы", config.SecurityConfig{LockoutThreshold: 1, LockoutBaseDelay: 1}, 11, 1024 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &Lockout{cfg: tt.cfg}
+			if got := l.delay(tt.failures); got != tt.want {
+				t.Errorf("delay(%d) = %v, ожидалось %v", tt.failures, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockoutDelayWithoutMaxNeverOverflows(t *testing.T) {
+	l := &Lockout{cfg: config.SecurityConfig{LockoutThreshold: 1, LockoutBaseDelay: 30}}
+
+	prev := time.Duration(0)
+	for failures := 1; failures <= 200; failures++ {
+		delay := l.delay(failures)
+		if delay < prev {
+			t.Fatalf("delay(%d) = %v меньше предыдущей %v", failures, delay, prev)
+		}
+		prev = delay
+	}
+}
+
+func TestLockoutState(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	cfg := config.SecurityConfig{LockoutThreshold: 2, LockoutBaseDelay: 60, LockoutMaxDelay: 600}
+
+	l := NewLockout(dbPath, cfg)
+	if delay, err := l.RecordFailure(); err != nil || delay != 0 {
+		t.Fatalf("первая ошибка: RecordFailure() = %v, %v", delay, err)
+	}
+	if l.Remaining() != 0 {
+		t.Fatal("до порога вход должен быть разрешен")
+	}
+	if delay, err := l.RecordFailure(); err != nil || delay != time.Minute {
+		t.Fatalf("вторая ошибка: RecordFailure() = %v, %v", delay, err)
+	}
+
+	// Состояние общее для всех, кто открывает эту БД
+	other := NewLockout(dbPath, cfg)
+	if other.Failures() != 2 || other.Remaining() <= 0 {
+		t.Fatalf("состояние не сохранилось: попыток %d, осталось %v", other.Failures(), other.Remaining())
+	}
+
+	if err := other.RecordSuccess(); err != nil {
+		t.Fatal(err)
+	}
+	if l.Remaining() != 0 || NewLockout(dbPath, cfg).Failures() != 0 {
+		t.Error("успешный вход должен снимать блокировку")
+	}
+}
@@ -0,0 +1,85 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"golang.org/x/crypto/argon2"
+)
+
+// Параметры Argon2id для PIN. PIN проверяется при каждой разблокировке,
+// поэтому параметры легче, чем у ключевого файла.
+const (
+	pinTime    = 2
+	pinMemory  = 19 * 1024
+	pinThreads = 1
+)
+
+// Допустимые параметры сохраненного хеша PIN. Хеш берется из config.json,
+// и испорченные значения не должны ронять argon2 или занимать всю память.
+const (
+	pinMaxTime    = 16
+	pinMaxMemory  = 256 * 1024 // КиБ
+	pinMaxThreads = 16
+	pinMinSalt    = 8
+	pinMinHash    = 16
+	pinMaxHash    = 64
+)
+
+// ValidatePIN проверяет формат PIN: от 4 до 12 цифр
+func ValidatePIN(pin string) error {
+	if len(pin) < 4 || len(pin) > 12 {
+		return i18n.Errorf("PIN должен содержать от 4 до 12 цифр")
+	}
+	for _, r := range pin {
+		if !unicode.IsDigit(r) {
+			return i18n.Errorf("PIN может содержать только цифры")
+		}
+	}
+	return nil
+}
+
+// HashPIN возвращает хеш PIN для хранения в конфигурации
+// в формате argon2id$время$память$потоки$соль$хеш
+func HashPIN(pin string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	hash := argon2.IDKey([]byte(pin), salt, pinTime, pinMemory, pinThreads, 32)
+	return fmt.Sprintf("argon2id$%d$%d$%d$%s$%s", pinTime, pinMemory, pinThreads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash)), nil
+}
+
+// VerifyPIN сравнивает PIN с сохраненным хешем. Хеш с параметрами вне
+// допустимых границ считается неверным.
+func VerifyPIN(pin, encoded string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[0] != "argon2id" {
+		return false
+	}
+
+	t, errT := strconv.ParseUint(parts[1], 10, 32)
+	m, errM := strconv.ParseUint(parts[2], 10, 32)
+	p, errP := strconv.ParseUint(parts[3], 10, 8)
+	salt, errS := base64.RawStdEncoding.DecodeString(parts[4])
+	hash, errH := base64.RawStdEncoding.DecodeString(parts[5])
+	if errT != nil || errM != nil || errP != nil || errS != nil || errH != nil {
+		return false
+	}
+	if t < 1 || t > pinMaxTime || p < 1 || p > pinMaxThreads || m < 8*p || m > pinMaxMemory ||
+		len(salt) < pinMinSalt || len(hash) < pinMinHash || len(hash) > pinMaxHash {
+		return false
+	}
+
+	actual := argon2.IDKey([]byte(pin), salt, uint32(t), uint32(m), uint8(p), uint32(len(hash)))
+	return subtle.ConstantTimeCompare(actual, hash) == 1
+}
@@ -0,0 +1,62 @@
+package security
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifyPIN(t *testing.T) {
+	hash, err := HashPIN("1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(hash, "$")
+	salt, sum := parts[4], parts[5]
+
+	tests := []struct {
+		name    string
+		pin     string
+		encoded string
+		want    bool
+	}{
+		{"верный PIN", "1234", hash, true},
+		{"неверный PIN", "4321", hash, false},
+		{"пустой хеш", "1234", "", false},
+		{"другой алгоритм", "1234", "bcrypt$2$19456$1$" + salt + "$" + sum, false},
+		{"нулевое время", "1234", "argon2id$0$19456$1$" + salt + "$" + sum, false},
+		{"огромное время", "1234", "argon2id$4294967295$19456$1$" + salt + "$" + sum, false},
+		{"нулевая память", "1234", "argon2id$2$0$1$" + salt + "$" + sum, false},
+		{"огромная память", "1234", "argon2id$2$4294967295$1$" + salt + "$" + sum, false},
+		{"нулевые потоки", "1234", "argon2id$2$19456$0$" + salt + "$" + sum, false},
+		{"много потоков", "1234", "argon2id$2$19456$255$" + salt + "$" + sum, false},
+		{"короткая соль", "1234", "argon2id$2$19456$1$AAAA$" + sum, false},
+		{"пустой хеш PIN", "1234", "argon2id$2$19456$1$" + salt + "$", false},
+		{"не base64", "1234", "argon2id$2$19456$1$" + salt + "$***", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyPIN(tt.pin, tt.encoded); got != tt.want {
+				t.Errorf("VerifyPIN() = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePIN(t *testing.T) {
+	tests := []struct {
+		pin   string
+		valid bool
+	}{
+		{"1234", true},
+		{"123456789012", true},
+		{"123", false},
+		{"1234567890123", false},
+		{"12a4", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if err := ValidatePIN(tt.pin); (err == nil) != tt.valid {
+			t.Errorf("ValidatePIN(%q) = %v", tt.pin, err)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package security
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+)
+
+// Policy описывает требования к паролям
+type Policy struct {
+	MinLength  int
+	MinClasses int
+}
+
+// NewPolicy создает политику паролей из конфигурации
+func NewPolicy(cfg config.SecurityConfig) Policy {
+	return Policy{
+		MinLength:  cfg.MinPasswordLength,
+		MinClasses: cfg.MinCharClasses,
+	}
+}
+
+// Describe возвращает требования политики в виде текста
+func (p Policy) Describe() string {
+	var parts []string
+	if p.MinLength > 0 {
+		parts = append(parts, i18n.Plural(p.MinLength, "не короче %d символов", p.MinLength))
+	}
+	if p.MinClasses > 1 {
+		parts = append(parts, i18n.Sprintf("символы минимум %d видов из 4 (строчные, прописные, цифры, знаки)", p.MinClasses))
+	}
+	if len(parts) == 0 {
+		return i18n.T("не пустой")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Validate проверяет пароль и перечисляет все невыполненные требования
+func (p Policy) Validate(password string) error {
+	if password == "" {
+		return i18n.Errorf("пароль не может быть пустым")
+	}
+
+	var problems []string
+	if length := len([]rune(password)); length < p.MinLength {
+		problems = append(problems, i18n.Sprintf("длина %d, нужно не меньше %d", length, p.MinLength))
+	}
+	if classes := charClasses(password); classes < p.MinClasses {
+		problems = append(problems, i18n.Sprintf("видов символов %d, нужно не меньше %d", classes, p.MinClasses))
+	}
+
+	if len(problems) > 0 {
+		return i18n.Errorf("пароль не соответствует политике: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Strength - оценка надежности пароля
+type Strength int
+
+const (
+	StrengthVeryWeak Strength = iota
+	StrengthWeak
+	StrengthMedium
+	StrengthStrong
+	StrengthVeryStrong
+)
+
+// String возвращает название оценки
+func (s Strength) String() string {
+	switch s {
+	case StrengthVeryWeak:
+		return i18n.T("очень слабый")
+	case StrengthWeak:
+		return i18n.T("слабый")
+	case StrengthMedium:
+		return i18n.T("средний")
+	case StrengthStrong:
+		return i18n.T("надежный")
+	}
+	return i18n.T("очень надежный")
+}
+
+// Color возвращает цвет для отображения оценки в tview
+func (s Strength) Color() string {
+	switch s {
+	case StrengthVeryWeak, StrengthWeak:
+		return "red"
+	case StrengthMedium:
+		return "yellow"
+	}
+	return "green"
+}
+
+// EstimateStrength грубо оценивает надежность пароля по энтропии с учетом
+// использованных видов символов и штрафом за повторы
+func EstimateStrength(password string) Strength {
+	runes := []rune(password)
+	if len(runes) == 0 {
+		return StrengthVeryWeak
+	}
+
+	alphabet := 0
+	lower, upper, digit, other := classFlags(password)
+	if lower {
+		alphabet += 26
+	}
+	if upper {
+		alphabet += 26
+	}
+	if digit {
+		alphabet += 10
+	}
+	if other {
+		alphabet += 33
+	}
+
+	// Подряд идущие одинаковые символы почти не добавляют энтропии
+	effective := 1
+	for i := 1; i < len(runes); i++ {
+		if runes[i] != runes[i-1] {
+			effective++
+		}
+	}
+
+	bits := float64(effective) * math.Log2(float64(alphabet))
+	switch {
+	case bits < 28:
+		return StrengthVeryWeak
+	case bits < 36:
+		return StrengthWeak
+	case bits < 60:
+		return StrengthMedium
+	case bits < 80:
+		return StrengthStrong
+	}
+	return StrengthVeryStrong
+}
+
+// charClasses возвращает количество использованных видов символов
+func charClasses(password string) int {
+	count := 0
+	lower, upper, digit, other := classFlags(password)
+	for _, used := range []bool{lower, upper, digit, other} {
+		if used {
+			count++
+		}
+	}
+	return count
+}
+
+// classFlags определяет, какие виды символов встречаются в пароле
+func classFlags(password string) (lower, upper, digit, other bool) {
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	return
+}
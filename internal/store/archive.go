@@ -0,0 +1,888 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/archive"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/pkg/models"
+)
+
+// ImportMode - способ загрузки архива
+type ImportMode string
+
+const (
+	// ImportMerge добавляет записи архива к существующим: совпавшие записи
+	// обновляются, если в архиве они новее, остальные добавляются с новыми ID.
+	// Каждая запись БД сопоставляется не больше чем с одной записью архива.
+	ImportMerge ImportMode = "merge"
+	// ImportReplace удаляет все данные и загружает архив с исходными ID,
+	// не сопоставляя записи между собой
+	ImportReplace ImportMode = "replace"
+)
+
+// maxImportWarnings - сколько предупреждений выводить в отчете об импорте
+const maxImportWarnings = 20
+
+// ImportCount - итог загрузки одного набора записей
+type ImportCount struct {
+	Created int
+	Updated int
+	Kept    int // запись уже есть и не новее архивной
+	Skipped int // в архиве нет родительской записи, путь проекта занят или смена уже открыта
+}
+
+// ImportResult - итог загрузки архива
+type ImportResult struct {
+	Counts   map[string]*ImportCount
+	Warnings []string
+}
+
+// String форматирует итог для вывода пользователю
+func (r *ImportResult) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-18s %10s %10s %14s %10s\n", i18n.T("Набор"), i18n.T("Добавлено"), i18n.T("Обновлено"), i18n.T("Без изменений"), i18n.T("Пропущено"))
+	for _, set := range archive.Sets {
+		c := r.Counts[set]
+		fmt.Fprintf(&b, "%-18s %10d %10d %14d %10d\n", set, c.Created, c.Updated, c.Kept, c.Skipped)
+	}
+
+	if len(r.Warnings) > 0 {
+		b.WriteString(i18n.T("\nПредупреждения:\n"))
+		for i, warning := range r.Warnings {
+			if i == maxImportWarnings {
+				i18n.Fprintf(&b, "  ... и еще %d\n", len(r.Warnings)-maxImportWarnings)
+				break
+			}
+			fmt.Fprintf(&b, "  - %s\n", warning)
+		}
+	}
+	return b.String()
+}
+
+// ExportData возвращает все записи, включая находящиеся в корзине, для архива
+func (s *Store) ExportData() (*archive.Data, error) {
+	q := s.DB()
+	data := &archive.Data{}
+	var err error
+
+	if data.Projects, err = queryAll(q, "SELECT "+projectColumns+" FROM projects ORDER BY id", scanProject); err != nil {
+		return nil, err
+	}
+	if data.ProjectSettings, err = queryAll(q, "SELECT "+settingsColumns+" FROM project_settings ORDER BY id", scanSettings); err != nil {
+		return nil, err
+	}
+	if data.Files, err = queryAll(q, "SELECT "+fileColumns+" FROM files ORDER BY id", scanFile); err != nil {
+		return nil, err
+	}
+	if data.FileHistory, err = queryAll(q, "SELECT "+historyColumns+" FROM file_history ORDER BY id", scanHistory); err != nil {
+		return nil, err
+	}
+	if data.Bookmarks, err = queryAll(q, "SELECT "+bookmarkColumns+" FROM bookmarks ORDER BY id", scanBookmark); err != nil {
+		return nil, err
+	}
+	if data.Snippets, err = queryAll(q, "SELECT "+snippetColumns+" FROM snippets ORDER BY id", scanSnippet); err != nil {
+		return nil, err
+	}
+	if data.Employees, err = queryAll(q, "SELECT "+employeeColumns+" FROM employees ORDER BY id", scanEmployee); err != nil {
+		return nil, err
+	}
+	if data.Shifts, err = queryAll(q, "SELECT "+shiftColumns+" FROM shifts ORDER BY id", scanShift); err != nil {
+		return nil, err
+	}
+	if data.ShiftLog, err = queryAll(q, "SELECT "+shiftEntryColumns+" FROM shift_log ORDER BY id", scanShiftEntry); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// ImportData загружает записи архива одной транзакцией. ID записей
+// пересчитываются, связи project_id, file_id и manager_id переводятся на новые
+// ID, а записи журнала смены - на новые ID смен. Проекты, сотрудники и
+// сниппеты записываются в журнал изменений.
+func (s *Store) ImportData(data *archive.Data, mode ImportMode) (*ImportResult, error) {
+	if mode != ImportMerge && mode != ImportReplace {
+		return nil, i18n.Errorf("неизвестный режим импорта %q", mode)
+	}
+
+	var result *ImportResult
+	err := s.inTx(func(tx *sql.Tx) error {
+		imp := &importer{
+			store:         s,
+			tx:            tx,
+			keepIDs:       mode == ImportReplace,
+			result:        &ImportResult{Counts: make(map[string]*ImportCount)},
+			projectIDs:    make(map[int64]int64),
+			fileIDs:       make(map[int64]int64),
+			employeeIDs:   make(map[int64]int64),
+			shiftIDs:      make(map[int64]int64),
+			claimed:       make(map[string]map[int64]bool),
+			skippedShifts: make(map[int64]bool),
+		}
+		for _, set := range archive.Sets {
+			imp.result.Counts[set] = &ImportCount{}
+		}
+
+		if mode == ImportReplace {
+			if err := imp.clear(); err != nil {
+				return err
+			}
+		}
+
+		steps := []func() error{
+			func() error { return imp.projects(data.Projects) },
+			func() error { return imp.settings(data.ProjectSettings) },
+			func() error { return imp.files(data.Files) },
+			func() error { return imp.history(data.FileHistory) },
+			func() error { return imp.bookmarks(data.Bookmarks) },
+			func() error { return imp.snippets(data.Snippets) },
+			func() error { return imp.employees(data.Employees) },
+			func() error { return imp.shifts(data.Shifts) },
+			func() error { return imp.shiftLog(data.ShiftLog) },
+		}
+		for _, step := range steps {
+			if err := step(); err != nil {
+				return err
+			}
+		}
+
+		result = imp.result
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// importer загружает архив в рамках одной транзакции и хранит соответствие
+// ID архива и ID в БД
+type importer struct {
+	store   *Store
+	tx      *sql.Tx
+	keepIDs bool // сохранять ID архива (загрузка в пустую БД)
+	result  *ImportResult
+
+	projectIDs  map[int64]int64
+	fileIDs     map[int64]int64
+	employeeIDs map[int64]int64
+	shiftIDs    map[int64]int64
+
+	// skippedShifts - смены архива, пропущенные вместе с журналом
+	skippedShifts map[int64]bool
+
+	// claimed - ID проектов, сотрудников и сниппетов БД, уже сопоставленных
+	// с записями архива; другая запись архива их не получает
+	claimed map[string]map[int64]bool
+}
+
+// claim отмечает запись БД как сопоставленную с записью архива
+func (imp *importer) claim(entity string, id int64) {
+	if imp.claimed[entity] == nil {
+		imp.claimed[entity] = make(map[int64]bool)
+	}
+	imp.claimed[entity][id] = true
+}
+
+// unclaimed возвращает первую из найденных записей, которая еще не
+// сопоставлена с другой записью архива, или nil
+func unclaimed[T any](records []T, claimed map[int64]bool, id func(*T) int64) *T {
+	for i := range records {
+		if !claimed[id(&records[i])] {
+			return &records[i]
+		}
+	}
+	return nil
+}
+
+// clear удаляет все данные перед загрузкой архива, записывая удаление
+// проектов, сотрудников и сниппетов в журнал. Связи с записями других узлов
+// и конфликты к удаленным записям сбрасываются, а узлы считаются не
+// приславшими ничего: их изменения придут заново и сопоставятся с записями
+// архива.
+func (imp *importer) clear() error {
+	for _, entity := range []string{EntityProject, EntityEmployee, EntitySnippet} {
+		ids, err := queryIDs(imp.tx, "SELECT id FROM "+entityTables[entity])
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			before, err := getState(imp.tx, entity, id)
+			if err != nil {
+				return err
+			}
+			if err := imp.store.audit(imp.tx, entity, id, ActionPurge, before, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, table := range []string{"file_history", "bookmarks", "files", "project_settings", "projects", "snippets", "employees", "shift_log", "shifts"} {
+		if _, err := imp.tx.Exec("DELETE FROM " + table); err != nil {
+			return i18n.Errorf("не удалось очистить %s: %w", table, err)
+		}
+	}
+
+	queries := []string{
+		"DELETE FROM sync_map",
+		"DELETE FROM sync_conflicts",
+		"UPDATE sync_peers SET received = 0, acked = 0",
+	}
+	for _, query := range queries {
+		if _, err := imp.tx.Exec(query); err != nil {
+			return err
+		}
+	}
+	return bumpChanges(imp.tx, EntitySync)
+}
+
+// warn добавляет предупреждение к итогу импорта
+func (imp *importer) warn(format string, args ...interface{}) {
+	imp.result.Warnings = append(imp.result.Warnings, i18n.Sprintf(format, args...))
+}
+
+// insert добавляет строку и возвращает ее ID. При загрузке с сохранением ID
+// строка получает ID из архива.
+func (imp *importer) insert(table string, id int64, columns []string, values ...interface{}) (int64, error) {
+	if imp.keepIDs {
+		columns = append([]string{"id"}, columns...)
+		values = append([]interface{}{id}, values...)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table,
+		strings.Join(columns, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+	res, err := imp.tx.Exec(query, values...)
+	if err != nil {
+		return 0, i18n.Errorf("не удалось добавить запись в %s: %w", table, err)
+	}
+	return res.LastInsertId()
+}
+
+// projects загружает проекты; совпадение определяется по пути
+func (imp *importer) projects(projects []models.Project) error {
+	count := imp.result.Counts[archive.Projects]
+	for _, p := range projects {
+		normalizeTimes(&p.CreatedAt, &p.UpdatedAt)
+		p.Version = max(p.Version, 1)
+
+		var existing *models.Project
+		if !imp.keepIDs {
+			var err error
+			existing, err = findOne(scanProject(imp.tx.QueryRow("SELECT "+projectColumns+" FROM projects WHERE path = ?", p.Path)))
+			if err != nil {
+				return err
+			}
+		}
+
+		// Путь уникален: второй проект архива с тем же путем добавить нельзя
+		if existing != nil && imp.claimed[EntityProject][existing.ID] {
+			imp.warn("проект %s: путь %s уже занят другим проектом архива", p.Name, p.Path)
+			count.Skipped++
+			continue
+		}
+
+		if existing != nil {
+			imp.projectIDs[p.ID] = existing.ID
+			imp.claim(EntityProject, existing.ID)
+			if !p.UpdatedAt.After(existing.UpdatedAt) {
+				count.Kept++
+				continue
+			}
+			_, err := imp.tx.Exec(
+				`UPDATE projects SET name = ?, description = ?, updated_at = ?, deleted_at = ?,
+				 version = version + 1 WHERE id = ?`,
+				p.Name, p.Description, p.UpdatedAt, p.DeletedAt, existing.ID,
+			)
+			if err != nil {
+				return err
+			}
+			if err := imp.auditChange(EntityProject, existing.ID, existing); err != nil {
+				return err
+			}
+			count.Updated++
+			continue
+		}
+
+		id, err := imp.insert("projects", p.ID,
+			[]string{"name", "path", "description", "created_at", "updated_at", "deleted_at", "version"},
+			p.Name, p.Path, p.Description, p.CreatedAt, p.UpdatedAt, p.DeletedAt, p.Version)
+		if err != nil {
+			return err
+		}
+		imp.projectIDs[p.ID] = id
+		imp.claim(EntityProject, id)
+		if err := imp.auditChange(EntityProject, id, nil); err != nil {
+			return err
+		}
+		count.Created++
+	}
+	return nil
+}
+
+// settings загружает настройки проектов; у проекта не больше одной записи настроек
+func (imp *importer) settings(settings []models.ProjectSettings) error {
+	count := imp.result.Counts[archive.ProjectSettings]
+	for _, st := range settings {
+		normalizeTimes(&st.CreatedAt, &st.UpdatedAt)
+
+		projectID, ok := imp.projectIDs[st.ProjectID]
+		if !ok {
+			imp.warn("настройки %d: в архиве нет проекта %d", st.ID, st.ProjectID)
+			count.Skipped++
+			continue
+		}
+
+		var existing *models.ProjectSettings
+		if !imp.keepIDs {
+			var err error
+			existing, err = findOne(scanSettings(imp.tx.QueryRow("SELECT "+settingsColumns+" FROM project_settings WHERE project_id = ?", projectID)))
+			if err != nil {
+				return err
+			}
+		}
+
+		if existing != nil {
+			if !st.UpdatedAt.After(existing.UpdatedAt) {
+				count.Kept++
+				continue
+			}
+			_, err := imp.tx.Exec(
+				`UPDATE project_settings SET language = ?, build_command = ?, run_command = ?,
+				 test_command = ?, linter_command = ?, updated_at = ? WHERE id = ?`,
+				st.Language, st.BuildCommand, st.RunCommand, st.TestCommand, st.LinterCommand,
+				st.UpdatedAt, existing.ID,
+			)
+			if err != nil {
+				return err
+			}
+			count.Updated++
+			continue
+		}
+
+		_, err := imp.insert("project_settings", st.ID,
+			[]string{"project_id", "language", "build_command", "run_command", "test_command", "linter_command", "created_at", "updated_at"},
+			projectID, st.Language, st.BuildCommand, st.RunCommand, st.TestCommand, st.LinterCommand, st.CreatedAt, st.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		count.Created++
+	}
+	return nil
+}
+
+// files загружает файлы проектов; совпадение определяется по проекту и пути
+func (imp *importer) files(files []models.File) error {
+	count := imp.result.Counts[archive.Files]
+	for _, f := range files {
+		normalizeTimes(&f.CreatedAt, &f.UpdatedAt)
+
+		projectID, ok := imp.projectIDs[f.ProjectID]
+		if !ok {
+			imp.warn("файл %s: в архиве нет проекта %d", f.Path, f.ProjectID)
+			count.Skipped++
+			continue
+		}
+
+		var existing *models.File
+		if !imp.keepIDs {
+			var err error
+			existing, err = findOne(scanFile(imp.tx.QueryRow("SELECT "+fileColumns+" FROM files WHERE project_id = ? AND path = ?", projectID, f.Path)))
+			if err != nil {
+				return err
+			}
+		}
+
+		if existing != nil {
+			imp.fileIDs[f.ID] = existing.ID
+			if !f.UpdatedAt.After(existing.UpdatedAt) {
+				count.Kept++
+				continue
+			}
+			_, err := imp.tx.Exec("UPDATE files SET name = ?, content = ?, size = ?, updated_at = ? WHERE id = ?",
+				f.Name, f.Content, f.Size, f.UpdatedAt, existing.ID)
+			if err != nil {
+				return err
+			}
+			count.Updated++
+			continue
+		}
+
+		id, err := imp.insert("files", f.ID,
+			[]string{"project_id", "path", "name", "content", "size", "created_at", "updated_at"},
+			projectID, f.Path, f.Name, f.Content, f.Size, f.CreatedAt, f.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		imp.fileIDs[f.ID] = id
+		count.Created++
+	}
+	return nil
+}
+
+// history загружает историю файлов; при слиянии запись с тем же временем и
+// описанием уже загружена ранее и пропускается
+func (imp *importer) history(history []models.FileHistory) error {
+	count := imp.result.Counts[archive.FileHistory]
+	for _, h := range history {
+		if h.CreatedAt.IsZero() {
+			h.CreatedAt = time.Now()
+		}
+
+		fileID, ok := imp.fileIDs[h.FileID]
+		if !ok {
+			imp.warn("история %d: в архиве нет файла %d", h.ID, h.FileID)
+			count.Skipped++
+			continue
+		}
+
+		if !imp.keepIDs {
+			duplicate, err := imp.historyExists(fileID, h)
+			if err != nil {
+				return err
+			}
+			if duplicate {
+				count.Kept++
+				continue
+			}
+		}
+
+		_, err := imp.insert("file_history", h.ID,
+			[]string{"file_id", "content", "change_description", "created_at"},
+			fileID, h.Content, h.ChangeDescription, h.CreatedAt)
+		if err != nil {
+			return err
+		}
+		count.Created++
+	}
+	return nil
+}
+
+// historyExists сообщает, что у файла fileID уже есть запись истории h
+func (imp *importer) historyExists(fileID int64, h models.FileHistory) (bool, error) {
+	existing, err := queryAll(imp.tx, "SELECT "+historyColumns+" FROM file_history WHERE file_id = ?", scanHistory, fileID)
+	if err != nil {
+		return false, err
+	}
+	for _, e := range existing {
+		// Время хранится строкой с часовым поясом, поэтому сравнивается в Go
+		if e.CreatedAt.Equal(h.CreatedAt) && e.ChangeDescription == h.ChangeDescription {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// bookmarks загружает закладки; в строке файла хранится одна закладка
+func (imp *importer) bookmarks(bookmarks []models.Bookmark) error {
+	count := imp.result.Counts[archive.Bookmarks]
+	for _, bm := range bookmarks {
+		if bm.CreatedAt.IsZero() {
+			bm.CreatedAt = time.Now()
+		}
+
+		fileID, ok := imp.fileIDs[bm.FileID]
+		if !ok {
+			imp.warn("закладка %d: в архиве нет файла %d", bm.ID, bm.FileID)
+			count.Skipped++
+			continue
+		}
+
+		var existing *models.Bookmark
+		if !imp.keepIDs {
+			var err error
+			existing, err = findOne(scanBookmark(imp.tx.QueryRow("SELECT "+bookmarkColumns+" FROM bookmarks WHERE file_id = ? AND line_number = ?", fileID, bm.LineNumber)))
+			if err != nil {
+				return err
+			}
+		}
+
+		if existing != nil {
+			if existing.Description == bm.Description {
+				count.Kept++
+				continue
+			}
+			if _, err := imp.tx.Exec("UPDATE bookmarks SET description = ? WHERE id = ?", bm.Description, existing.ID); err != nil {
+				return err
+			}
+			count.Updated++
+			continue
+		}
+
+		_, err := imp.insert("bookmarks", bm.ID,
+			[]string{"file_id", "line_number", "description", "created_at"},
+			fileID, bm.LineNumber, bm.Description, bm.CreatedAt)
+		if err != nil {
+			return err
+		}
+		count.Created++
+	}
+	return nil
+}
+
+// snippets загружает сниппеты; совпадение определяется по названию и языку
+func (imp *importer) snippets(snippets []models.Snippet) error {
+	count := imp.result.Counts[archive.Snippets]
+	for _, sn := range snippets {
+		normalizeTimes(&sn.CreatedAt, &sn.UpdatedAt)
+		sn.Version = max(sn.Version, 1)
+
+		var existing *models.Snippet
+		if !imp.keepIDs {
+			candidates, err := queryAll(imp.tx, "SELECT "+snippetColumns+" FROM snippets WHERE title = ? AND language = ? ORDER BY id", scanSnippet, sn.Title, sn.Language)
+			if err != nil {
+				return err
+			}
+			existing = unclaimed(candidates, imp.claimed[EntitySnippet], func(sn *models.Snippet) int64 { return sn.ID })
+		}
+
+		if existing != nil {
+			imp.claim(EntitySnippet, existing.ID)
+			if !sn.UpdatedAt.After(existing.UpdatedAt) {
+				count.Kept++
+				continue
+			}
+			_, err := imp.tx.Exec(
+				`UPDATE snippets SET description = ?, code = ?, tags = ?, updated_at = ?, deleted_at = ?,
+				 version = version + 1 WHERE id = ?`,
+				sn.Description, sn.Code, sn.Tags, sn.UpdatedAt, sn.DeletedAt, existing.ID,
+			)
+			if err != nil {
+				return err
+			}
+			if err := imp.auditChange(EntitySnippet, existing.ID, existing); err != nil {
+				return err
+			}
+			count.Updated++
+			continue
+		}
+
+		id, err := imp.insert("snippets", sn.ID,
+			[]string{"title", "description", "language", "code", "tags", "created_at", "updated_at", "deleted_at", "version"},
+			sn.Title, sn.Description, sn.Language, sn.Code, sn.Tags, sn.CreatedAt, sn.UpdatedAt, sn.DeletedAt, sn.Version)
+		if err != nil {
+			return err
+		}
+		imp.claim(EntitySnippet, id)
+		if err := imp.auditChange(EntitySnippet, id, nil); err != nil {
+			return err
+		}
+		count.Created++
+	}
+	return nil
+}
+
+// importedEmployee - сотрудник, добавленный или обновленный при импорте
+type importedEmployee struct {
+	archived models.Employee
+	id       int64
+	before   *models.Employee // nil - сотрудник добавлен
+}
+
+// employees загружает сотрудников; совпадение определяется по email без учета
+// регистра, а у сотрудников без email - по ФИО. Руководители назначаются
+// вторым проходом, когда известны новые ID всех сотрудников архива.
+func (imp *importer) employees(employees []models.Employee) error {
+	count := imp.result.Counts[archive.Employees]
+	var imported []importedEmployee
+
+	for _, e := range employees {
+		normalizeTimes(&e.CreatedAt, &e.UpdatedAt)
+		e.Version = max(e.Version, 1)
+		if e.HireDate.IsZero() {
+			e.HireDate = e.CreatedAt
+		}
+
+		var existing *models.Employee
+		if !imp.keepIDs {
+			var err error
+			if existing, err = imp.findEmployee(e); err != nil {
+				return err
+			}
+		}
+
+		if existing != nil {
+			imp.employeeIDs[e.ID] = existing.ID
+			imp.claim(EntityEmployee, existing.ID)
+			if !e.UpdatedAt.After(existing.UpdatedAt) {
+				count.Kept++
+				continue
+			}
+			_, err := imp.tx.Exec(
+				`UPDATE employees SET first_name = ?, last_name = ?, middle_name = ?, email = ?,
+				 position = ?, department = ?, phone = ?, hire_date = ?, updated_at = ?, deleted_at = ?,
+				 version = version + 1 WHERE id = ?`,
+				e.FirstName, e.LastName, e.MiddleName, nullString(e.Email), e.Position, e.Department,
+				e.Phone, e.HireDate, e.UpdatedAt, e.DeletedAt, existing.ID,
+			)
+			if err != nil {
+				return err
+			}
+			imported = append(imported, importedEmployee{archived: e, id: existing.ID, before: existing})
+			count.Updated++
+			continue
+		}
+
+		id, err := imp.insert("employees", e.ID,
+			[]string{"first_name", "last_name", "middle_name", "email", "position", "department",
+				"phone", "hire_date", "created_at", "updated_at", "deleted_at", "version"},
+			e.FirstName, e.LastName, e.MiddleName, nullString(e.Email), e.Position, e.Department,
+			e.Phone, e.HireDate, e.CreatedAt, e.UpdatedAt, e.DeletedAt, e.Version)
+		if err != nil {
+			return err
+		}
+		imp.employeeIDs[e.ID] = id
+		imp.claim(EntityEmployee, id)
+		imported = append(imported, importedEmployee{archived: e, id: id})
+		count.Created++
+	}
+
+	for _, ie := range imported {
+		var managerID *int64
+		if ie.archived.ManagerID != nil {
+			if id, ok := imp.employeeIDs[*ie.archived.ManagerID]; ok {
+				managerID = &id
+			} else {
+				imp.warn("сотрудник %s %s: в архиве нет руководителя %d, руководитель не назначен",
+					ie.archived.LastName, ie.archived.FirstName, *ie.archived.ManagerID)
+			}
+		}
+		if _, err := imp.tx.Exec("UPDATE employees SET manager_id = ? WHERE id = ?", managerID, ie.id); err != nil {
+			return err
+		}
+
+		var before interface{}
+		if ie.before != nil {
+			before = ie.before
+		}
+		if err := imp.auditChange(EntityEmployee, ie.id, before); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findEmployee ищет в БД сотрудника, соответствующего сотруднику архива и еще
+// не сопоставленного с другим. Email в том же регистре подходит раньше.
+func (imp *importer) findEmployee(e models.Employee) (*models.Employee, error) {
+	var candidates []models.Employee
+	var err error
+	if e.Email != "" {
+		candidates, err = queryAll(imp.tx,
+			"SELECT "+employeeColumns+" FROM employees WHERE lower(email) = lower(?) ORDER BY email = ? DESC, id",
+			scanEmployee, e.Email, e.Email)
+	} else {
+		candidates, err = queryAll(imp.tx,
+			`SELECT `+employeeColumns+` FROM employees
+			 WHERE email IS NULL AND last_name = ? AND first_name = ? AND COALESCE(middle_name, '') = ?
+			 ORDER BY id`,
+			scanEmployee, e.LastName, e.FirstName, e.MiddleName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return unclaimed(candidates, imp.claimed[EntityEmployee], func(e *models.Employee) int64 { return e.ID }), nil
+}
+
+// shifts загружает смены; при слиянии совпадение определяется по тому, кто и
+// когда открыл смену. Открытая смена архива пропускается, если в БД уже
+// открыта другая: одновременно открыта может быть только одна.
+func (imp *importer) shifts(shifts []models.Shift) error {
+	count := imp.result.Counts[archive.Shifts]
+	for _, sh := range shifts {
+		if sh.OpenedAt.IsZero() {
+			sh.OpenedAt = time.Now()
+		}
+
+		var existing *models.Shift
+		if !imp.keepIDs {
+			var err error
+			if existing, err = imp.findShift(sh); err != nil {
+				return err
+			}
+		}
+
+		if existing != nil {
+			imp.shiftIDs[sh.ID] = existing.ID
+			// Смена, открытая в БД, могла быть закрыта уже после выгрузки
+			if existing.ClosedAt != nil || sh.ClosedAt == nil {
+				count.Kept++
+				continue
+			}
+			if _, err := imp.tx.Exec("UPDATE shifts SET closed_by = ?, closed_at = ? WHERE id = ?", sh.ClosedBy, sh.ClosedAt, existing.ID); err != nil {
+				return err
+			}
+			count.Updated++
+			continue
+		}
+
+		if sh.ClosedAt == nil {
+			current, err := openShift(imp.tx)
+			if err == nil {
+				imp.warn("смена %d: уже открыта смена #%d, смена архива и ее журнал пропущены", sh.ID, current.ID)
+				imp.skippedShifts[sh.ID] = true
+				count.Skipped++
+				continue
+			}
+			if !errors.Is(err, ErrNoOpenShift) {
+				return err
+			}
+		}
+
+		id, err := imp.insert("shifts", sh.ID,
+			[]string{"opened_by", "opened_at", "closed_by", "closed_at"},
+			sh.OpenedBy, sh.OpenedAt, nullString(sh.ClosedBy), sh.ClosedAt)
+		if err != nil {
+			return err
+		}
+		imp.shiftIDs[sh.ID] = id
+		count.Created++
+	}
+	return nil
+}
+
+// findShift ищет в БД смену, открытую тем же пользователем в то же время
+func (imp *importer) findShift(sh models.Shift) (*models.Shift, error) {
+	candidates, err := queryAll(imp.tx, "SELECT "+shiftColumns+" FROM shifts WHERE opened_by = ? ORDER BY id", scanShift, sh.OpenedBy)
+	if err != nil {
+		return nil, err
+	}
+	for i := range candidates {
+		// Время хранится строкой с часовым поясом, поэтому сравнивается в Go
+		if candidates[i].OpenedAt.Equal(sh.OpenedAt) {
+			return &candidates[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// shiftLog загружает журнал смен; при слиянии запись с тем же временем,
+// автором и текстом уже загружена ранее и пропускается
+func (imp *importer) shiftLog(entries []models.ShiftEntry) error {
+	count := imp.result.Counts[archive.ShiftLog]
+	for _, e := range entries {
+		if e.CreatedAt.IsZero() {
+			e.CreatedAt = time.Now()
+		}
+		if ValidateSeverity(e.Severity) != nil {
+			e.Severity = SeverityInfo
+		}
+
+		shiftID, ok := imp.shiftIDs[e.ShiftID]
+		if !ok {
+			if !imp.skippedShifts[e.ShiftID] {
+				imp.warn("запись журнала смены %d: в архиве нет смены %d", e.ID, e.ShiftID)
+			}
+			count.Skipped++
+			continue
+		}
+
+		if !imp.keepIDs {
+			duplicate, err := imp.shiftEntryExists(shiftID, e)
+			if err != nil {
+				return err
+			}
+			if duplicate {
+				count.Kept++
+				continue
+			}
+		}
+
+		_, err := imp.insert("shift_log", e.ID,
+			[]string{"shift_id", "severity", "message", "author", "created_at"},
+			shiftID, e.Severity, e.Message, e.Author, e.CreatedAt)
+		if err != nil {
+			return err
+		}
+		count.Created++
+	}
+	return nil
+}
+
+// shiftEntryExists сообщает, что в журнале смены shiftID уже есть запись e
+func (imp *importer) shiftEntryExists(shiftID int64, e models.ShiftEntry) (bool, error) {
+	existing, err := queryAll(imp.tx, "SELECT "+shiftEntryColumns+" FROM shift_log WHERE shift_id = ?", scanShiftEntry, shiftID)
+	if err != nil {
+		return false, err
+	}
+	for _, x := range existing {
+		if x.CreatedAt.Equal(e.CreatedAt) && x.Author == e.Author && x.Message == e.Message {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// auditChange записывает в журнал добавление (before == nil) или изменение записи
+func (imp *importer) auditChange(entity string, id int64, before interface{}) error {
+	after, err := getState(imp.tx, entity, id)
+	if err != nil {
+		return err
+	}
+	action := ActionUpdate
+	if before == nil {
+		action = ActionCreate
+	}
+	return imp.store.audit(imp.tx, entity, id, action, before, after)
+}
+
+// normalizeTimes заполняет время создания и изменения, отсутствующее
+// в архивах старых версий
+func normalizeTimes(createdAt, updatedAt *time.Time) {
+	if createdAt.IsZero() {
+		*createdAt = time.Now()
+	}
+	if updatedAt.IsZero() {
+		*updatedAt = *createdAt
+	}
+}
+
+// findOne возвращает nil без ошибки, если запись не найдена
+func findOne[T any](record *T, err error) (*T, error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return record, err
+}
+
+// queryAll выполняет запрос и читает все строки функцией scan
+func queryAll[T any](q querier, query string, scan func(row interface{ Scan(...interface{}) error }) (*T, error), args ...interface{}) ([]T, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []T
+	for rows.Next() {
+		record, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, *record)
+	}
+	return records, rows.Err()
+}
+
+// queryIDs возвращает ID, выбранные запросом
+func queryIDs(q querier, query string, args ...interface{}) ([]int64, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
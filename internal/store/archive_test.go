@@ -0,0 +1,420 @@
+package store
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/archive"
+	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/deldim-kam/Jotnal/pkg/models"
+)
+
+// archiveTime - время изменения записей тестовых архивов; oldTime - записей
+// архива, которые старше записей БД
+var (
+	archiveTime = time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	oldTime     = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// exportedEmails возвращает email всех сотрудников БД, включая корзину
+func exportedEmails(t *testing.T, s *Store) []string {
+	t.Helper()
+
+	data, err := s.ExportData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var emails []string
+	for _, e := range data.Employees {
+		emails = append(emails, e.Email)
+	}
+	sort.Strings(emails)
+	return emails
+}
+
+func TestImportData(t *testing.T) {
+	deleted := archiveTime
+	manager := int64(10)
+
+	tests := []struct {
+		name  string
+		mode  ImportMode
+		local []models.Employee // сотрудники в БД до импорта
+		data  archive.Data
+		want  map[string]ImportCount
+		check func(t *testing.T, s *Store)
+	}{
+		{
+			name: "замена не сопоставляет email в разном регистре",
+			mode: ImportReplace,
+			local: []models.Employee{
+				{FirstName: "Петр", LastName: "Сидоров", Email: "old@b.c"},
+			},
+			data: archive.Data{Employees: []models.Employee{
+				{ID: 10, FirstName: "Иван", LastName: "Иванов", Email: "a@b.c", UpdatedAt: archiveTime, DeletedAt: &deleted},
+				{ID: 11, FirstName: "Петр", LastName: "Петров", Email: "A@B.C", UpdatedAt: archiveTime, ManagerID: &manager},
+			}},
+			want: map[string]ImportCount{archive.Employees: {Created: 2}},
+			check: func(t *testing.T, s *Store) {
+				if got := exportedEmails(t, s); len(got) != 2 || got[0] != "A@B.C" || got[1] != "a@b.c" {
+					t.Errorf("сотрудники после замены: %v", got)
+				}
+				e, err := s.GetEmployee(11)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if e.ManagerID == nil || *e.ManagerID != 10 {
+					t.Errorf("руководитель сотрудника 11 = %v, ожидался 10", e.ManagerID)
+				}
+				ivanov, err := s.GetEmployee(10)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if ivanov.DeletedAt == nil {
+					t.Error("сотрудник 10 должен остаться в корзине")
+				}
+			},
+		},
+		{
+			name: "слияние не отдает одного сотрудника двум записям архива",
+			mode: ImportMerge,
+			local: []models.Employee{
+				{FirstName: "Иван", LastName: "Иванов", Email: "a@b.c"},
+			},
+			data: archive.Data{Employees: []models.Employee{
+				{ID: 1, FirstName: "Иван", LastName: "Иванов", Email: "a@b.c", UpdatedAt: oldTime},
+				{ID: 2, FirstName: "Петр", LastName: "Петров", Email: "A@B.C", UpdatedAt: archiveTime},
+			}},
+			want: map[string]ImportCount{archive.Employees: {Created: 1, Kept: 1}},
+			check: func(t *testing.T, s *Store) {
+				if got := exportedEmails(t, s); len(got) != 2 {
+					t.Errorf("сотрудники после слияния: %v", got)
+				}
+			},
+		},
+		{
+			name: "слияние предпочитает email в том же регистре",
+			mode: ImportMerge,
+			local: []models.Employee{
+				{FirstName: "Иван", LastName: "Иванов", Email: "a@b.c"},
+				{FirstName: "Петр", LastName: "Петров", Email: "A@B.C"},
+			},
+			data: archive.Data{Employees: []models.Employee{
+				{ID: 1, FirstName: "Петр", LastName: "Петров", Email: "A@B.C", UpdatedAt: oldTime},
+				{ID: 2, FirstName: "Иван", LastName: "Иванов", Email: "a@b.c", UpdatedAt: oldTime},
+			}},
+			want: map[string]ImportCount{archive.Employees: {Kept: 2}},
+		},
+		{
+			name: "слияние одинаковых сниппетов архива",
+			mode: ImportMerge,
+			data: archive.Data{Snippets: []models.Snippet{
+				{ID: 1, Title: "hello", Language: "go", Code: "fmt.Println(1)"},
+				{ID: 2, Title: "hello", Language: "go", Code: "fmt.Println(2)"},
+			}},
+			want: map[string]ImportCount{archive.Snippets: {Created: 2}},
+		},
+		{
+			name: "слияние пропускает второй проект с тем же путем",
+			mode: ImportMerge,
+			data: archive.Data{
+				Projects: []models.Project{
+					{ID: 1, Name: "Альфа", Path: "/srv/alpha"},
+					{ID: 2, Name: "Альфа 2", Path: "/srv/alpha"},
+				},
+				Files: []models.File{
+					{ID: 1, ProjectID: 1, Path: "main.go", Name: "main.go"},
+					{ID: 2, ProjectID: 2, Path: "main.go", Name: "main.go"},
+				},
+			},
+			want: map[string]ImportCount{
+				archive.Projects: {Created: 1, Skipped: 1},
+				archive.Files:    {Created: 1, Skipped: 1},
+			},
+		},
+		{
+			name: "слияние переводит связи на новые ID",
+			mode: ImportMerge,
+			local: []models.Employee{
+				{FirstName: "Анна", LastName: "Смирнова", Email: "anna@b.c"},
+				{FirstName: "Олег", LastName: "Орлов", Email: "oleg@b.c"},
+			},
+			data: archive.Data{Employees: []models.Employee{
+				{ID: 1, FirstName: "Иван", LastName: "Иванов", Email: "ivan@b.c"},
+				{ID: 2, FirstName: "Петр", LastName: "Петров", Email: "petr@b.c", ManagerID: ptr(int64(1))},
+			}},
+			want: map[string]ImportCount{archive.Employees: {Created: 2}},
+			check: func(t *testing.T, s *Store) {
+				employees, err := s.ListEmployees()
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids := make(map[string]int64)
+				for _, e := range employees {
+					ids[e.Email] = e.ID
+				}
+				petr, err := s.GetEmployee(ids["petr@b.c"])
+				if err != nil {
+					t.Fatal(err)
+				}
+				if petr.ManagerID == nil || *petr.ManagerID != ids["ivan@b.c"] {
+					t.Errorf("руководитель = %v, ожидался %d", petr.ManagerID, ids["ivan@b.c"])
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t, "archive")
+			for _, e := range tt.local {
+				if _, err := s.CreateEmployee(e); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := s.ImportData(&tt.data, tt.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for set, want := range tt.want {
+				if got := *result.Counts[set]; got != want {
+					t.Errorf("%s: %+v, ожидалось %+v", set, got, want)
+				}
+			}
+			if tt.check != nil {
+				tt.check(t, s)
+			}
+		})
+	}
+}
+
+func TestImportDataRoundTrip(t *testing.T) {
+	src := newTestStore(t, "source")
+	projectID, err := src.CreateProject(models.Project{Name: "Альфа", Path: "/srv/alpha"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.CreateSnippet(models.Snippet{Title: "hello", Language: "go"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.DeleteProject(projectID); err != nil {
+		t.Fatal(err)
+	}
+	data, err := src.ExportData()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := newTestStore(t, "target")
+	if _, err := dst.ImportData(data, ImportReplace); err != nil {
+		t.Fatal(err)
+	}
+	// Повторное слияние того же архива ничего не меняет
+	result, err := dst.ImportData(data, ImportMerge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, set := range []string{archive.Projects, archive.Snippets} {
+		if got := *result.Counts[set]; got != (ImportCount{Kept: 1}) {
+			t.Errorf("%s: %+v, ожидалось {Kept: 1}", set, got)
+		}
+	}
+
+	trash, err := dst.ListTrash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trash) != 1 || trash[0].ID != projectID {
+		t.Errorf("корзина после импорта: %+v", trash)
+	}
+}
+
+func TestImportReplaceThenSync(t *testing.T) {
+	src := newTestStore(t, "source")
+	dst := newTestStore(t, "target")
+
+	srcID, err := src.CreateProject(models.Project{Name: "Пи", Path: "/srv/p"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncTo(t, src, dst, config.SyncStrategyField)
+
+	// В архиве нет проекта источника: прежняя связь указывала бы на
+	// удаленную запись, и изменения источника к ней пропускались бы
+	data := &archive.Data{Projects: []models.Project{{ID: 10, Name: "Икс", Path: "/srv/x", UpdatedAt: archiveTime}}}
+	if _, err := dst.ImportData(data, ImportReplace); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := src.GetProject(srcID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Name = "Пи 2"
+	if err := src.UpdateProject(*p); err != nil {
+		t.Fatal(err)
+	}
+	result := syncTo(t, src, dst, config.SyncStrategyField)
+	if result.Conflicts != 0 {
+		t.Errorf("конфликтов %d, ожидалось 0", result.Conflicts)
+	}
+
+	projects, err := dst.ListProjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]string)
+	for _, p := range projects {
+		names[p.Path] = p.Name
+	}
+	if len(names) != 2 || names["/srv/x"] != "Икс" || names["/srv/p"] != "Пи 2" {
+		t.Errorf("проекты после синхронизации: %v", names)
+	}
+}
+
+// shiftArchive выгружает БД с двумя сменами: закрытой и открытой, в журнале
+// каждой по записи
+func shiftArchive(t *testing.T) (*Store, *archive.Data) {
+	t.Helper()
+
+	s := newTestStore(t, "shifts")
+	for i, message := range []string{"насос 3 перезапущен", "давление в норме"} {
+		if _, err := s.OpenShift(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.AddShiftEntry(SeverityWarn, message); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			if _, err := s.CloseShift(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	data, err := s.ExportData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Shifts) != 2 || len(data.ShiftLog) != 2 {
+		t.Fatalf("выгружено смен %d, записей журнала %d", len(data.Shifts), len(data.ShiftLog))
+	}
+	return s, data
+}
+
+func TestImportDataShifts(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    ImportMode
+		prepare func(t *testing.T, s *Store, data *archive.Data) // подготовка целевой БД
+		want    map[string]ImportCount
+		open    bool // после импорта открыта смена из архива
+	}{
+		{
+			name: "замена сохраняет журнал смен",
+			mode: ImportReplace,
+			prepare: func(t *testing.T, s *Store, data *archive.Data) {
+				if _, err := s.OpenShift(); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: map[string]ImportCount{
+				archive.Shifts:   {Created: 2},
+				archive.ShiftLog: {Created: 2},
+			},
+			open: true,
+		},
+		{
+			name: "повторное слияние ничего не меняет",
+			mode: ImportMerge,
+			prepare: func(t *testing.T, s *Store, data *archive.Data) {
+				if _, err := s.ImportData(data, ImportMerge); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: map[string]ImportCount{
+				archive.Shifts:   {Kept: 2},
+				archive.ShiftLog: {Kept: 2},
+			},
+			open: true,
+		},
+		{
+			name: "слияние не открывает вторую смену",
+			mode: ImportMerge,
+			prepare: func(t *testing.T, s *Store, data *archive.Data) {
+				if _, err := s.OpenShift(); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: map[string]ImportCount{
+				archive.Shifts:   {Created: 1, Skipped: 1},
+				archive.ShiftLog: {Created: 1, Skipped: 1},
+			},
+		},
+		{
+			name: "слияние закрывает смену, закрытую после выгрузки",
+			mode: ImportMerge,
+			prepare: func(t *testing.T, s *Store, data *archive.Data) {
+				open := data.Shifts[1]
+				closedAt := open.OpenedAt.Add(time.Hour)
+				data.Shifts[1].ClosedBy, data.Shifts[1].ClosedAt = "test", &closedAt
+
+				// В БД смена еще открыта, как в момент выгрузки
+				before := *data
+				before.Shifts = []models.Shift{data.Shifts[0], open}
+				if _, err := s.ImportData(&before, ImportMerge); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: map[string]ImportCount{
+				archive.Shifts:   {Updated: 1, Kept: 1},
+				archive.ShiftLog: {Kept: 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, data := shiftArchive(t)
+			srcOpen, err := src.CurrentShift()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dst := newTestStore(t, "target")
+			tt.prepare(t, dst, data)
+			result, err := dst.ImportData(data, tt.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for set, want := range tt.want {
+				if got := *result.Counts[set]; got != want {
+					t.Errorf("%s: %+v, ожидалось %+v", set, got, want)
+				}
+			}
+			if len(result.Warnings) > 1 {
+				t.Errorf("предупреждения: %v", result.Warnings)
+			}
+
+			current, err := dst.CurrentShift()
+			if tt.open {
+				if err != nil || !current.OpenedAt.Equal(srcOpen.OpenedAt) {
+					t.Fatalf("открытая смена после импорта: %+v, %v", current, err)
+				}
+				entries, err := dst.ListShiftEntries(current.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(entries) != 1 || entries[0].Message != "давление в норме" {
+					t.Errorf("журнал открытой смены: %+v", entries)
+				}
+			} else if err == nil && current.OpenedAt.Equal(srcOpen.OpenedAt) {
+				t.Errorf("смена архива открыта: %+v", current)
+			}
+		})
+	}
+}
+
+// ptr возвращает указатель на значение
+func ptr[T any](v T) *T {
+	return &v
+}
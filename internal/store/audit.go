@@ -0,0 +1,146 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/pkg/models"
+)
+
+// AuditFilter ограничивает выборку журнала изменений
+type AuditFilter struct {
+	Entity   string // пусто - все сущности
+	EntityID int64  // 0 - все записи
+	Limit    int    // 0 - без ограничения
+}
+
+// ListAudit возвращает записи журнала, начиная с последних
+func (s *Store) ListAudit(filter AuditFilter) ([]models.AuditEntry, error) {
+	query := `SELECT id, entity, entity_id, action, actor, before_data, after_data, created_at
+			  FROM audit_log`
+
+	var where []string
+	var args []interface{}
+	if filter.Entity != "" {
+		where = append(where, "entity = ?")
+		args = append(args, filter.Entity)
+	}
+	if filter.EntityID != 0 {
+		where = append(where, "entity_id = ?")
+		args = append(args, filter.EntityID)
+	}
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := s.DB().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var e models.AuditEntry
+		var before, after sql.NullString
+		err := rows.Scan(&e.ID, &e.Entity, &e.EntityID, &e.Action, &e.Actor,
+			&before, &after, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		e.Before, e.After = before.String, after.String
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// History возвращает историю изменений одной записи, начиная с последних
+func (s *Store) History(entity string, id int64) ([]models.AuditEntry, error) {
+	return s.ListAudit(AuditFilter{Entity: entity, EntityID: id})
+}
+
+// FieldChange - изменение одного поля записи
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// ignoredFields не показываются в различиях: они меняются при каждой записи
+var ignoredFields = map[string]bool{
+	"updated_at": true,
+	"version":    true,
+}
+
+// Diff возвращает изменившиеся поля записи журнала в алфавитном порядке.
+// Для создания старые значения пусты, для удаления - новые.
+func Diff(entry models.AuditEntry) ([]FieldChange, error) {
+	before, err := decodeState(entry.Before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := decodeState(entry.After)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]bool)
+	for field := range before {
+		fields[field] = true
+	}
+	for field := range after {
+		fields[field] = true
+	}
+
+	var changes []FieldChange
+	for field := range fields {
+		if ignoredFields[field] {
+			continue
+		}
+		oldValue, newValue := formatValue(before[field]), formatValue(after[field])
+		if oldValue == newValue {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: field, Old: oldValue, New: newValue})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes, nil
+}
+
+// decodeState разбирает JSON состояния записи
+func decodeState(data string) (map[string]interface{}, error) {
+	state := make(map[string]interface{})
+	if data == "" {
+		return state, nil
+	}
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		return nil, i18n.Errorf("поврежденная запись журнала: %w", err)
+	}
+	return state, nil
+}
+
+// formatValue приводит значение поля к строке для сравнения и вывода
+func formatValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", v)
+}
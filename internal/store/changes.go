@@ -0,0 +1,60 @@
+package store
+
+import (
+	"database/sql"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+)
+
+// Changes - счетчики изменений по сущностям. Счетчик растет при каждой записи
+// в журнал, поэтому другой экземпляр приложения может по разнице счетчиков
+// понять, какие списки нужно перечитать.
+type Changes map[string]int64
+
+// bumpChanges увеличивает счетчик изменений сущности в транзакции записи
+func bumpChanges(tx *sql.Tx, entity string) error {
+	_, err := tx.Exec(
+		`INSERT INTO change_feed (entity, seq) VALUES (?, 1)
+		 ON CONFLICT(entity) DO UPDATE SET seq = seq + 1`,
+		entity,
+	)
+	return err
+}
+
+// Changes возвращает текущие счетчики изменений. Вызывается из фоновой
+// проверки, поэтому отсутствие подключения - ошибка, а не паника.
+func (s *Store) Changes() (Changes, error) {
+	db := s.DB()
+	if db == nil {
+		return nil, i18n.Errorf("БД не подключена")
+	}
+
+	rows, err := db.Query("SELECT entity, seq FROM change_feed")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make(Changes)
+	for rows.Next() {
+		var entity string
+		var seq int64
+		if err := rows.Scan(&entity, &seq); err != nil {
+			return nil, err
+		}
+		changes[entity] = seq
+	}
+
+	return changes, rows.Err()
+}
+
+// Changed возвращает сущности, счетчики которых отличаются от prev
+func (c Changes) Changed(prev Changes) []string {
+	var entities []string
+	for entity, seq := range c {
+		if prev[entity] != seq {
+			entities = append(entities, entity)
+		}
+	}
+	return entities
+}
@@ -0,0 +1,40 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/deldim-kam/Jotnal/internal/database"
+	"github.com/deldim-kam/Jotnal/pkg/models"
+)
+
+func TestChangesWithoutDB(t *testing.T) {
+	m, err := database.NewManager("unused.db", "test-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := New(m, "test").Changes(); err == nil {
+		t.Fatal("ожидалась ошибка без подключения к БД")
+	}
+}
+
+func TestChangesCountWrites(t *testing.T) {
+	s := newTestStore(t, "changes")
+
+	before, err := s.Changes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateProject(models.Project{Name: "Альфа", Path: "/tmp/alpha"}); err != nil {
+		t.Fatal(err)
+	}
+	after, err := s.Changes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed := after.Changed(before)
+	if len(changed) != 1 || changed[0] != EntityProject {
+		t.Errorf("Changed() = %v, ожидалось [%s]", changed, EntityProject)
+	}
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+)
+
+// ErrConflict возвращается, если запись изменили после того, как ее открыли для редактирования
+var ErrConflict = i18n.NewError("запись изменена другим пользователем")
+
+// ConflictError описывает конфликт одновременного редактирования
+type ConflictError struct {
+	Entity  string
+	ID      int64
+	Current interface{} // текущее состояние записи в БД (*models.Project и т.п.)
+	Deleted bool        // запись перемещена в корзину
+
+	// Кто и когда последним изменил запись (по журналу изменений)
+	Actor     string
+	ChangedAt time.Time
+}
+
+// Error возвращает описание конфликта
+func (e *ConflictError) Error() string {
+	what := ErrConflict.Error()
+	if e.Deleted {
+		what = i18n.T("запись перемещена в корзину другим пользователем")
+	}
+	if e.Actor == "" {
+		return what
+	}
+	return fmt.Sprintf("%s (%s, %s)", what, e.Actor, i18n.Timestamp(e.ChangedAt.Local()))
+}
+
+// Unwrap позволяет проверять конфликт через errors.Is(err, ErrConflict)
+func (e *ConflictError) Unwrap() error {
+	return ErrConflict
+}
+
+// conflict формирует ошибку конфликта с данными о последнем изменении записи
+func (s *Store) conflict(tx *sql.Tx, entity string, id int64, current interface{}, deleted bool) error {
+	cerr := &ConflictError{
+		Entity:  entity,
+		ID:      id,
+		Current: current,
+		Deleted: deleted,
+	}
+
+	err := tx.QueryRow(
+		"SELECT actor, created_at FROM audit_log WHERE entity = ? AND entity_id = ? ORDER BY id DESC LIMIT 1",
+		entity, id,
+	).Scan(&cerr.Actor, &cerr.ChangedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	return cerr
+}
@@ -0,0 +1,143 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/pkg/models"
+)
+
+const employeeColumns = `id, first_name, last_name, middle_name, email, position,
+	department, manager_id, phone, hire_date, created_at, updated_at, deleted_at, version`
+
+// scanEmployee читает сотрудника из строки результата
+func scanEmployee(row interface{ Scan(...interface{}) error }) (*models.Employee, error) {
+	var e models.Employee
+	var middleName, email, department, phone sql.NullString
+	var deletedAt sql.NullTime
+	err := row.Scan(&e.ID, &e.FirstName, &e.LastName, &middleName, &email,
+		&e.Position, &department, &e.ManagerID, &phone, &e.HireDate,
+		&e.CreatedAt, &e.UpdatedAt, &deletedAt, &e.Version)
+	if err != nil {
+		return nil, err
+	}
+	e.MiddleName, e.Email = middleName.String, email.String
+	e.Department, e.Phone = department.String, phone.String
+	e.DeletedAt = nullTime(deletedAt)
+	return &e, nil
+}
+
+// getEmployee загружает сотрудника по ID
+func getEmployee(q querier, id int64) (*models.Employee, error) {
+	return scanEmployee(q.QueryRow("SELECT "+employeeColumns+" FROM employees WHERE id = ?", id))
+}
+
+// GetEmployee возвращает сотрудника по ID
+func (s *Store) GetEmployee(id int64) (*models.Employee, error) {
+	return getEmployee(s.DB(), id)
+}
+
+// ListEmployees возвращает всех сотрудников по алфавиту
+func (s *Store) ListEmployees() ([]models.Employee, error) {
+	rows, err := s.DB().Query("SELECT " + employeeColumns + " FROM employees WHERE deleted_at IS NULL ORDER BY last_name, first_name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var employees []models.Employee
+	for rows.Next() {
+		e, err := scanEmployee(rows)
+		if err != nil {
+			return nil, err
+		}
+		employees = append(employees, *e)
+	}
+
+	return employees, rows.Err()
+}
+
+// CreateEmployee создает сотрудника и возвращает его ID
+func (s *Store) CreateEmployee(e models.Employee) (int64, error) {
+	var id int64
+	err := s.inTx(func(tx *sql.Tx) error {
+		if err := checkTrashed(tx, EntityEmployee, e.Email, 0); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		hireDate := e.HireDate
+		if hireDate.IsZero() {
+			hireDate = now
+		}
+
+		res, err := tx.Exec(
+			`INSERT INTO employees (first_name, last_name, middle_name, email, position,
+			 department, manager_id, phone, hire_date, created_at, updated_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			e.FirstName, e.LastName, e.MiddleName, nullString(e.Email), e.Position,
+			e.Department, e.ManagerID, e.Phone, hireDate, now, now,
+		)
+		if err != nil {
+			return err
+		}
+		if id, err = res.LastInsertId(); err != nil {
+			return err
+		}
+
+		after, err := getEmployee(tx, id)
+		if err != nil {
+			return err
+		}
+		return s.audit(tx, EntityEmployee, id, ActionCreate, nil, after)
+	})
+	return id, err
+}
+
+// UpdateEmployee сохраняет ФИО, контакты, должность, отдел, руководителя и дату найма сотрудника.
+// Если сотрудника изменили после загрузки версии e.Version, возвращает *ConflictError.
+func (s *Store) UpdateEmployee(e models.Employee) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		before, err := getEmployee(tx, e.ID)
+		if err != nil {
+			return err
+		}
+		if before.Version != e.Version || before.DeletedAt != nil {
+			return s.conflict(tx, EntityEmployee, e.ID, before, before.DeletedAt != nil)
+		}
+		if err := checkTrashed(tx, EntityEmployee, e.Email, e.ID); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(
+			`UPDATE employees SET first_name = ?, last_name = ?, middle_name = ?,
+			 email = ?, position = ?, department = ?, manager_id = ?, phone = ?, hire_date = ?,
+			 updated_at = ?, version = version + 1
+			 WHERE id = ? AND version = ?`,
+			e.FirstName, e.LastName, e.MiddleName, nullString(e.Email), e.Position,
+			e.Department, e.ManagerID, e.Phone, e.HireDate, time.Now(), e.ID, e.Version,
+		)
+		if err != nil {
+			return err
+		}
+
+		after, err := getEmployee(tx, e.ID)
+		if err != nil {
+			return err
+		}
+		return s.audit(tx, EntityEmployee, e.ID, ActionUpdate, before, after)
+	})
+}
+
+// DeleteEmployee перемещает сотрудника в корзину
+func (s *Store) DeleteEmployee(id int64) error {
+	return s.softDelete(EntityEmployee, id)
+}
+
+// nullString сохраняет пустую строку как NULL, чтобы не нарушать UNIQUE у email
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
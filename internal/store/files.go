@@ -0,0 +1,62 @@
+package store
+
+import (
+	"database/sql"
+
+	"github.com/deldim-kam/Jotnal/pkg/models"
+)
+
+const (
+	fileColumns     = "id, project_id, path, name, content, size, created_at, updated_at"
+	settingsColumns = `id, project_id, language, build_command, run_command, test_command,
+	linter_command, created_at, updated_at`
+	historyColumns  = "id, file_id, content, change_description, created_at"
+	bookmarkColumns = "id, file_id, line_number, description, created_at"
+)
+
+// scanFile читает файл проекта из строки результата
+func scanFile(row interface{ Scan(...interface{}) error }) (*models.File, error) {
+	var f models.File
+	var content sql.NullString
+	var size sql.NullInt64
+	if err := row.Scan(&f.ID, &f.ProjectID, &f.Path, &f.Name, &content, &size, &f.CreatedAt, &f.UpdatedAt); err != nil {
+		return nil, err
+	}
+	f.Content, f.Size = content.String, size.Int64
+	return &f, nil
+}
+
+// scanSettings читает настройки проекта из строки результата
+func scanSettings(row interface{ Scan(...interface{}) error }) (*models.ProjectSettings, error) {
+	var st models.ProjectSettings
+	var language, build, run, test, linter sql.NullString
+	err := row.Scan(&st.ID, &st.ProjectID, &language, &build, &run, &test, &linter, &st.CreatedAt, &st.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	st.Language, st.BuildCommand, st.RunCommand = language.String, build.String, run.String
+	st.TestCommand, st.LinterCommand = test.String, linter.String
+	return &st, nil
+}
+
+// scanHistory читает запись истории файла из строки результата
+func scanHistory(row interface{ Scan(...interface{}) error }) (*models.FileHistory, error) {
+	var h models.FileHistory
+	var content, description sql.NullString
+	if err := row.Scan(&h.ID, &h.FileID, &content, &description, &h.CreatedAt); err != nil {
+		return nil, err
+	}
+	h.Content, h.ChangeDescription = content.String, description.String
+	return &h, nil
+}
+
+// scanBookmark читает закладку из строки результата
+func scanBookmark(row interface{ Scan(...interface{}) error }) (*models.Bookmark, error) {
+	var bm models.Bookmark
+	var description sql.NullString
+	if err := row.Scan(&bm.ID, &bm.FileID, &bm.LineNumber, &description, &bm.CreatedAt); err != nil {
+		return nil, err
+	}
+	bm.Description = description.String
+	return &bm, nil
+}
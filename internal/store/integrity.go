@@ -0,0 +1,421 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+)
+
+// Проверки целостности БД в порядке выполнения
+const (
+	CheckStructure  = "integrity_check"
+	CheckCipher     = "cipher_integrity_check"
+	CheckForeignKey = "foreign_key_check"
+	CheckFiles      = "orphan_files"
+	CheckManagers   = "manager_hierarchy"
+	CheckEmails     = "duplicate_emails"
+)
+
+// checkOrder - порядок проверок в отчете
+var checkOrder = []string{CheckStructure, CheckCipher, CheckForeignKey, CheckFiles, CheckManagers, CheckEmails}
+
+// checkNames - названия проверок для отчета; переводятся при выводе
+var checkNames = map[string]string{
+	CheckStructure:  "Структура файла (integrity_check)",
+	CheckCipher:     "Шифрование страниц (cipher_integrity_check)",
+	CheckForeignKey: "Внешние ключи (foreign_key_check)",
+	CheckFiles:      "Файлы без проекта",
+	CheckManagers:   "Иерархия сотрудников",
+	CheckEmails:     "Повторяющиеся email",
+}
+
+// orphanCleanup - строки, ссылающиеся на отсутствующие записи, которые можно
+// безопасно удалить: это данные проектов и файлов, не видимые без родителя.
+// Порядок важен: сначала удаляются файлы без проекта, затем их история и закладки.
+var orphanCleanup = []struct {
+	table, parent, query string
+}{
+	{"files", "projects", "DELETE FROM files WHERE project_id NOT IN (SELECT id FROM projects)"},
+	{"file_history", "files", "DELETE FROM file_history WHERE file_id NOT IN (SELECT id FROM files)"},
+	{"bookmarks", "files", "DELETE FROM bookmarks WHERE file_id NOT IN (SELECT id FROM files)"},
+	{"project_settings", "projects", "DELETE FROM project_settings WHERE project_id NOT IN (SELECT id FROM projects)"},
+}
+
+// Finding - проблема, найденная проверкой целостности
+type Finding struct {
+	Check   string
+	Message string
+	Fixable bool // исправляется автоматически (Repair)
+}
+
+// IntegrityReport - результат проверки целостности БД
+type IntegrityReport struct {
+	Checked  time.Time
+	Findings []Finding
+}
+
+// OK сообщает, что проблем не найдено
+func (r *IntegrityReport) OK() bool {
+	return len(r.Findings) == 0
+}
+
+// Fixable возвращает число проблем, которые можно исправить автоматически
+func (r *IntegrityReport) Fixable() int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Fixable {
+			n++
+		}
+	}
+	return n
+}
+
+// String форматирует отчет для вывода в терминал
+func (r *IntegrityReport) String() string {
+	var b strings.Builder
+	i18n.Fprintf(&b, "Проверка целостности БД (%s)\n\n", i18n.Timestamp(r.Checked.Local()))
+
+	for _, check := range checkOrder {
+		var findings []Finding
+		for _, f := range r.Findings {
+			if f.Check == check {
+				findings = append(findings, f)
+			}
+		}
+
+		if len(findings) == 0 {
+			fmt.Fprintf(&b, "  [OK]  %s\n", i18n.T(checkNames[check]))
+			continue
+		}
+		fmt.Fprintf(&b, "  [%2d]  %s\n", len(findings), i18n.T(checkNames[check]))
+		for _, f := range findings {
+			mark := ""
+			if f.Fixable {
+				mark = i18n.T(" (исправимо)")
+			}
+			fmt.Fprintf(&b, "        - %s%s\n", f.Message, mark)
+		}
+	}
+
+	if r.OK() {
+		b.WriteString(i18n.T("\nПроблем не найдено\n"))
+	} else {
+		i18n.Fprintf(&b, "\nНайдено проблем: %d, из них исправимых автоматически: %d\n", len(r.Findings), r.Fixable())
+	}
+	return b.String()
+}
+
+// CheckIntegrity проверяет файл БД средствами SQLite и SQLCipher, а также
+// инварианты приложения: файлы без проекта, циклы и ссылки на отсутствующих
+// руководителей, повторяющиеся без учета регистра email сотрудников
+func (s *Store) CheckIntegrity() (*IntegrityReport, error) {
+	report := &IntegrityReport{Checked: time.Now()}
+	add := func(check, message string, fixable bool) {
+		report.Findings = append(report.Findings, Finding{Check: check, Message: message, Fixable: fixable})
+	}
+
+	problems, err := s.db.IntegrityCheck()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range problems {
+		add(CheckStructure, p, false)
+	}
+
+	problems, err = s.db.CipherIntegrityCheck()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range problems {
+		add(CheckCipher, p, false)
+	}
+
+	violations, err := s.db.ForeignKeyCheck()
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[[2]string]int)
+	for _, v := range violations {
+		// Файлы и руководители проверяются отдельно с подробностями
+		if v.Table == "files" || v.Table == "employees" {
+			continue
+		}
+		counts[[2]string{v.Table, v.Parent}]++
+	}
+	keys := make([][2]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i][0]+keys[i][1] < keys[j][0]+keys[j][1] })
+	for _, key := range keys {
+		add(CheckForeignKey, i18n.Sprintf("%s: строк со ссылкой на отсутствующую запись в %s: %d", key[0], key[1], counts[key]),
+			isCleanable(key[0], key[1]))
+	}
+
+	files, err := orphanFiles(s.DB())
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		add(CheckFiles, i18n.Sprintf("файл #%d %s ссылается на отсутствующий проект #%d", f.id, f.path, f.projectID), true)
+	}
+
+	dangling, cycles, err := managerProblems(s.DB())
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range dangling {
+		add(CheckManagers, i18n.Sprintf("у сотрудника #%d указан отсутствующий руководитель", id), true)
+	}
+	for _, cycle := range cycles {
+		add(CheckManagers, i18n.Sprintf("цикл подчинения: %s", formatCycle(cycle)), true)
+	}
+
+	duplicates, err := duplicateEmails(s.DB())
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range duplicates {
+		add(CheckEmails, i18n.Sprintf("%s: сотрудники %s", d.email, formatIDs(d.ids)), false)
+	}
+
+	return report, nil
+}
+
+// Repair исправляет проблемы, которые можно исправить без потери значимых данных:
+// удаляет файлы, историю, закладки и настройки без родительской записи, снимает
+// отсутствующих руководителей и разрывает циклы подчинения, снимая руководителя
+// у последнего добавленного сотрудника цикла. Изменения сотрудников пишутся в
+// журнал. Перед вызовом следует создать резервную копию. Возвращает число исправлений.
+func (s *Store) Repair() (int, error) {
+	var fixed int
+	err := s.inTx(func(tx *sql.Tx) error {
+		fixed = 0
+
+		for _, c := range orphanCleanup {
+			res, err := tx.Exec(c.query)
+			if err != nil {
+				return err
+			}
+			n, _ := res.RowsAffected()
+			fixed += int(n)
+		}
+
+		dangling, cycles, err := managerProblems(tx)
+		if err != nil {
+			return err
+		}
+		for _, cycle := range cycles {
+			dangling = append(dangling, maxID(cycle))
+		}
+		for _, id := range dangling {
+			if err := s.clearManager(tx, id); err != nil {
+				return err
+			}
+			fixed++
+		}
+
+		return nil
+	})
+	return fixed, err
+}
+
+// clearManager снимает руководителя у сотрудника с записью в журнал
+func (s *Store) clearManager(tx *sql.Tx, id int64) error {
+	before, err := getEmployee(tx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE employees SET manager_id = NULL, updated_at = ?, version = version + 1 WHERE id = ?",
+		time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	after, err := getEmployee(tx, id)
+	if err != nil {
+		return err
+	}
+	return s.audit(tx, EntityEmployee, id, ActionUpdate, before, after)
+}
+
+// isCleanable сообщает, удаляет ли Repair строки table без записи в parent
+func isCleanable(table, parent string) bool {
+	for _, c := range orphanCleanup {
+		if c.table == table && c.parent == parent {
+			return true
+		}
+	}
+	return false
+}
+
+// orphanFile - файл, проект которого отсутствует
+type orphanFile struct {
+	id, projectID int64
+	path          string
+}
+
+// orphanFiles находит файлы без проекта
+func orphanFiles(q querier) ([]orphanFile, error) {
+	rows, err := q.Query("SELECT id, project_id, path FROM files WHERE project_id NOT IN (SELECT id FROM projects) ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []orphanFile
+	for rows.Next() {
+		var f orphanFile
+		if err := rows.Scan(&f.id, &f.projectID, &f.path); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	return files, rows.Err()
+}
+
+// managerProblems находит сотрудников с отсутствующим руководителем и циклы
+// подчинения. Цикл перечисляется по цепочке руководителей начиная с меньшего ID.
+func managerProblems(q querier) (dangling []int64, cycles [][]int64, err error) {
+	rows, err := q.Query("SELECT id, manager_id FROM employees ORDER BY id")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	managers := make(map[int64]int64)
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var manager sql.NullInt64
+		if err := rows.Scan(&id, &manager); err != nil {
+			return nil, nil, err
+		}
+		ids = append(ids, id)
+		if manager.Valid {
+			managers[id] = manager.Int64
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	exists := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		exists[id] = true
+	}
+	for _, id := range ids {
+		if manager, ok := managers[id]; ok && !exists[manager] {
+			dangling = append(dangling, id)
+			delete(managers, id)
+		}
+	}
+
+	// Обход цепочек руководителей: 1 - сотрудник в текущей цепочке, 2 - цепочка проверена
+	state := make(map[int64]int, len(ids))
+	for _, start := range ids {
+		var path []int64
+		id, ok := start, true
+		for ok && state[id] == 0 {
+			state[id] = 1
+			path = append(path, id)
+			id, ok = managers[id]
+		}
+		if ok && state[id] == 1 {
+			for i, member := range path {
+				if member == id {
+					cycles = append(cycles, rotateToMin(path[i:]))
+					break
+				}
+			}
+		}
+		for _, member := range path {
+			state[member] = 2
+		}
+	}
+
+	return dangling, cycles, nil
+}
+
+// duplicateEmail - email, повторяющийся у нескольких сотрудников
+type duplicateEmail struct {
+	email string
+	ids   []int64
+}
+
+// duplicateEmails находит email, совпадающие без учета регистра. UNIQUE в схеме
+// учитывает регистр, поэтому такие повторы могли попасть в БД.
+func duplicateEmails(q querier) ([]duplicateEmail, error) {
+	rows, err := q.Query("SELECT id, email FROM employees WHERE email IS NOT NULL AND email <> '' ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byEmail := make(map[string][]int64)
+	var order []string
+	for rows.Next() {
+		var id int64
+		var email string
+		if err := rows.Scan(&id, &email); err != nil {
+			return nil, err
+		}
+		key := strings.ToLower(strings.TrimSpace(email))
+		if _, seen := byEmail[key]; !seen {
+			order = append(order, key)
+		}
+		byEmail[key] = append(byEmail[key], id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var duplicates []duplicateEmail
+	for _, key := range order {
+		if len(byEmail[key]) > 1 {
+			duplicates = append(duplicates, duplicateEmail{email: key, ids: byEmail[key]})
+		}
+	}
+	return duplicates, nil
+}
+
+// rotateToMin возвращает копию цикла, начинающуюся с меньшего ID
+func rotateToMin(cycle []int64) []int64 {
+	start := 0
+	for i, id := range cycle {
+		if id < cycle[start] {
+			start = i
+		}
+	}
+	return append(append([]int64(nil), cycle[start:]...), cycle[:start]...)
+}
+
+// maxID возвращает больший ID - последнего добавленного сотрудника
+func maxID(ids []int64) int64 {
+	result := ids[0]
+	for _, id := range ids[1:] {
+		result = max(result, id)
+	}
+	return result
+}
+
+// formatCycle форматирует цикл подчинения: #1 → #2 → #1
+func formatCycle(cycle []int64) string {
+	closed := append(append([]int64(nil), cycle...), cycle[0])
+	return strings.ReplaceAll(formatIDs(closed), ", ", " → ")
+}
+
+// formatIDs форматирует список ID: #1, #2
+func formatIDs(ids []int64) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = fmt.Sprintf("#%d", id)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/pkg/models"
+)
+
+const projectColumns = "id, name, path, description, created_at, updated_at, deleted_at, version"
+
+// scanProject читает проект из строки результата
+func scanProject(row interface{ Scan(...interface{}) error }) (*models.Project, error) {
+	var p models.Project
+	var description sql.NullString
+	var deletedAt sql.NullTime
+	if err := row.Scan(&p.ID, &p.Name, &p.Path, &description, &p.CreatedAt, &p.UpdatedAt, &deletedAt, &p.Version); err != nil {
+		return nil, err
+	}
+	p.Description = description.String
+	p.DeletedAt = nullTime(deletedAt)
+	return &p, nil
+}
+
+// getProject загружает проект по ID
+func getProject(q querier, id int64) (*models.Project, error) {
+	return scanProject(q.QueryRow("SELECT "+projectColumns+" FROM projects WHERE id = ?", id))
+}
+
+// GetProject возвращает проект по ID
+func (s *Store) GetProject(id int64) (*models.Project, error) {
+	return getProject(s.DB(), id)
+}
+
+// ListProjects возвращает все проекты, начиная с новых
+func (s *Store) ListProjects() ([]models.Project, error) {
+	rows, err := s.DB().Query("SELECT " + projectColumns + " FROM projects WHERE deleted_at IS NULL ORDER BY created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []models.Project
+	for rows.Next() {
+		p, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, *p)
+	}
+
+	return projects, rows.Err()
+}
+
+// CreateProject создает проект и возвращает его ID
+func (s *Store) CreateProject(p models.Project) (int64, error) {
+	var id int64
+	err := s.inTx(func(tx *sql.Tx) error {
+		if err := checkTrashed(tx, EntityProject, p.Path, 0); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		res, err := tx.Exec(
+			"INSERT INTO projects (name, path, description, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+			p.Name, p.Path, p.Description, now, now,
+		)
+		if err != nil {
+			return err
+		}
+		if id, err = res.LastInsertId(); err != nil {
+			return err
+		}
+
+		after, err := getProject(tx, id)
+		if err != nil {
+			return err
+		}
+		return s.audit(tx, EntityProject, id, ActionCreate, nil, after)
+	})
+	return id, err
+}
+
+// UpdateProject сохраняет название, путь и описание проекта.
+// Если проект изменили после загрузки версии p.Version, возвращает *ConflictError.
+func (s *Store) UpdateProject(p models.Project) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		before, err := getProject(tx, p.ID)
+		if err != nil {
+			return err
+		}
+		if before.Version != p.Version || before.DeletedAt != nil {
+			return s.conflict(tx, EntityProject, p.ID, before, before.DeletedAt != nil)
+		}
+		if err := checkTrashed(tx, EntityProject, p.Path, p.ID); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(
+			`UPDATE projects SET name = ?, path = ?, description = ?, updated_at = ?, version = version + 1
+			 WHERE id = ? AND version = ?`,
+			p.Name, p.Path, p.Description, time.Now(), p.ID, p.Version,
+		)
+		if err != nil {
+			return err
+		}
+
+		after, err := getProject(tx, p.ID)
+		if err != nil {
+			return err
+		}
+		return s.audit(tx, EntityProject, p.ID, ActionUpdate, before, after)
+	})
+}
+
+// DeleteProject перемещает проект в корзину
+func (s *Store) DeleteProject(id int64) error {
+	return s.softDelete(EntityProject, id)
+}
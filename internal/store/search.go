@@ -0,0 +1,64 @@
+package store
+
+import (
+	"strings"
+)
+
+// SearchResult - запись, найденная поиском
+type SearchResult struct {
+	Entity string
+	ID     int64
+	Title  string
+	Match  string // строка поля, в которой найден текст
+}
+
+// Search ищет строку без учета регистра в текстовых полях проектов,
+// сотрудников и сниппетов; записи в корзине не учитываются.
+//
+// Сравнение выполняется в Go: LIKE в SQLite не различает регистр
+// только для латиницы.
+func (s *Store) Search(query string) ([]SearchResult, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil, nil
+	}
+
+	var results []SearchResult
+	match := func(entity string, id int64, title string, fields ...string) {
+		for _, field := range fields {
+			for _, line := range strings.Split(field, "\n") {
+				if strings.Contains(strings.ToLower(line), query) {
+					results = append(results, SearchResult{Entity: entity, ID: id, Title: title, Match: strings.TrimSpace(line)})
+					return
+				}
+			}
+		}
+	}
+
+	projects, err := s.ListProjects()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range projects {
+		match(EntityProject, p.ID, p.Name, p.Name, p.Path, p.Description)
+	}
+
+	employees, err := s.ListEmployees()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range employees {
+		match(EntityEmployee, e.ID, e.LastName+" "+e.FirstName,
+			e.LastName, e.FirstName, e.MiddleName, e.Email, e.Position, e.Department, e.Phone)
+	}
+
+	snippets, err := s.ListSnippets()
+	if err != nil {
+		return nil, err
+	}
+	for _, sn := range snippets {
+		match(EntitySnippet, sn.ID, sn.Title, sn.Title, sn.Description, sn.Language, sn.Tags, sn.Code)
+	}
+
+	return results, nil
+}
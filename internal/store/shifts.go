@@ -0,0 +1,172 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/pkg/models"
+)
+
+// Важность записей журнала смены
+const (
+	SeverityInfo  = "info"
+	SeverityWarn  = "warn"
+	SeverityError = "error"
+)
+
+// ErrNoOpenShift возвращается, если действие требует открытой смены, а ее нет
+var ErrNoOpenShift = i18n.NewError("нет открытой смены")
+
+const (
+	shiftColumns      = "id, opened_by, opened_at, closed_by, closed_at"
+	shiftEntryColumns = "id, shift_id, severity, message, author, created_at"
+)
+
+// ValidateSeverity проверяет важность записи журнала смены
+func ValidateSeverity(severity string) error {
+	switch severity {
+	case SeverityInfo, SeverityWarn, SeverityError:
+		return nil
+	}
+	return i18n.Errorf("неверная важность %q (допустимо: info, warn, error)", severity)
+}
+
+// scanShift читает смену из строки результата
+func scanShift(row interface{ Scan(...interface{}) error }) (*models.Shift, error) {
+	var sh models.Shift
+	var closedBy sql.NullString
+	var closedAt sql.NullTime
+	if err := row.Scan(&sh.ID, &sh.OpenedBy, &sh.OpenedAt, &closedBy, &closedAt); err != nil {
+		return nil, err
+	}
+	sh.ClosedBy = closedBy.String
+	sh.ClosedAt = nullTime(closedAt)
+	return &sh, nil
+}
+
+// scanShiftEntry читает запись журнала смены из строки результата
+func scanShiftEntry(row interface{ Scan(...interface{}) error }) (*models.ShiftEntry, error) {
+	var e models.ShiftEntry
+	if err := row.Scan(&e.ID, &e.ShiftID, &e.Severity, &e.Message, &e.Author, &e.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// openShift загружает открытую смену; если ее нет, возвращает ErrNoOpenShift
+func openShift(q querier) (*models.Shift, error) {
+	sh, err := scanShift(q.QueryRow("SELECT " + shiftColumns + " FROM shifts WHERE closed_at IS NULL ORDER BY id DESC LIMIT 1"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoOpenShift
+	}
+	return sh, err
+}
+
+// CurrentShift возвращает открытую смену или ErrNoOpenShift
+func (s *Store) CurrentShift() (*models.Shift, error) {
+	return openShift(s.DB())
+}
+
+// OpenShift открывает смену от имени текущего пользователя.
+// Если смена уже открыта, возвращает ошибку.
+func (s *Store) OpenShift() (*models.Shift, error) {
+	var shift *models.Shift
+	err := s.inTx(func(tx *sql.Tx) error {
+		current, err := openShift(tx)
+		if err == nil {
+			return i18n.Errorf("смена #%d уже открыта (%s, %s)",
+				current.ID, current.OpenedBy, i18n.DateTime(current.OpenedAt.Local()))
+		}
+		if !errors.Is(err, ErrNoOpenShift) {
+			return err
+		}
+
+		res, err := tx.Exec("INSERT INTO shifts (opened_by, opened_at) VALUES (?, ?)", s.actor, time.Now())
+		if err != nil {
+			return err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		shift, err = scanShift(tx.QueryRow("SELECT "+shiftColumns+" FROM shifts WHERE id = ?", id))
+		return err
+	})
+	return shift, err
+}
+
+// CloseShift закрывает открытую смену от имени текущего пользователя
+func (s *Store) CloseShift() (*models.Shift, error) {
+	var shift *models.Shift
+	err := s.inTx(func(tx *sql.Tx) error {
+		current, err := openShift(tx)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec("UPDATE shifts SET closed_by = ?, closed_at = ? WHERE id = ?", s.actor, time.Now(), current.ID); err != nil {
+			return err
+		}
+		shift, err = scanShift(tx.QueryRow("SELECT "+shiftColumns+" FROM shifts WHERE id = ?", current.ID))
+		return err
+	})
+	return shift, err
+}
+
+// AddShiftEntry добавляет запись в журнал открытой смены от имени текущего
+// пользователя. Если смена не открыта, возвращает ErrNoOpenShift.
+func (s *Store) AddShiftEntry(severity, message string) (*models.ShiftEntry, error) {
+	if err := ValidateSeverity(severity); err != nil {
+		return nil, err
+	}
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return nil, i18n.Errorf("запись журнала не может быть пустой")
+	}
+
+	var entry *models.ShiftEntry
+	err := s.inTx(func(tx *sql.Tx) error {
+		shift, err := openShift(tx)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.Exec(
+			"INSERT INTO shift_log (shift_id, severity, message, author, created_at) VALUES (?, ?, ?, ?, ?)",
+			shift.ID, severity, message, s.actor, time.Now(),
+		)
+		if err != nil {
+			return err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		entry, err = scanShiftEntry(tx.QueryRow("SELECT "+shiftEntryColumns+" FROM shift_log WHERE id = ?", id))
+		return err
+	})
+	return entry, err
+}
+
+// ListShiftEntries возвращает записи журнала смены в порядке добавления
+func (s *Store) ListShiftEntries(shiftID int64) ([]models.ShiftEntry, error) {
+	rows, err := s.DB().Query("SELECT "+shiftEntryColumns+" FROM shift_log WHERE shift_id = ? ORDER BY id", shiftID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.ShiftEntry
+	for rows.Next() {
+		e, err := scanShiftEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *e)
+	}
+
+	return entries, rows.Err()
+}
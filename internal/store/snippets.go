@@ -0,0 +1,116 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/pkg/models"
+)
+
+const snippetColumns = "id, title, description, language, code, tags, created_at, updated_at, deleted_at, version"
+
+// scanSnippet читает сниппет из строки результата
+func scanSnippet(row interface{ Scan(...interface{}) error }) (*models.Snippet, error) {
+	var sn models.Snippet
+	var description, tags sql.NullString
+	var deletedAt sql.NullTime
+	err := row.Scan(&sn.ID, &sn.Title, &description, &sn.Language, &sn.Code, &tags,
+		&sn.CreatedAt, &sn.UpdatedAt, &deletedAt, &sn.Version)
+	if err != nil {
+		return nil, err
+	}
+	sn.Description, sn.Tags = description.String, tags.String
+	sn.DeletedAt = nullTime(deletedAt)
+	return &sn, nil
+}
+
+// getSnippet загружает сниппет по ID
+func getSnippet(q querier, id int64) (*models.Snippet, error) {
+	return scanSnippet(q.QueryRow("SELECT "+snippetColumns+" FROM snippets WHERE id = ?", id))
+}
+
+// GetSnippet возвращает сниппет по ID
+func (s *Store) GetSnippet(id int64) (*models.Snippet, error) {
+	return getSnippet(s.DB(), id)
+}
+
+// ListSnippets возвращает все сниппеты, начиная с новых
+func (s *Store) ListSnippets() ([]models.Snippet, error) {
+	rows, err := s.DB().Query("SELECT " + snippetColumns + " FROM snippets WHERE deleted_at IS NULL ORDER BY created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snippets []models.Snippet
+	for rows.Next() {
+		sn, err := scanSnippet(rows)
+		if err != nil {
+			return nil, err
+		}
+		snippets = append(snippets, *sn)
+	}
+
+	return snippets, rows.Err()
+}
+
+// CreateSnippet создает сниппет и возвращает его ID
+func (s *Store) CreateSnippet(sn models.Snippet) (int64, error) {
+	var id int64
+	err := s.inTx(func(tx *sql.Tx) error {
+		now := time.Now()
+		res, err := tx.Exec(
+			`INSERT INTO snippets (title, description, language, code, tags, created_at, updated_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			sn.Title, sn.Description, sn.Language, sn.Code, sn.Tags, now, now,
+		)
+		if err != nil {
+			return err
+		}
+		if id, err = res.LastInsertId(); err != nil {
+			return err
+		}
+
+		after, err := getSnippet(tx, id)
+		if err != nil {
+			return err
+		}
+		return s.audit(tx, EntitySnippet, id, ActionCreate, nil, after)
+	})
+	return id, err
+}
+
+// UpdateSnippet сохраняет сниппет.
+// Если сниппет изменили после загрузки версии sn.Version, возвращает *ConflictError.
+func (s *Store) UpdateSnippet(sn models.Snippet) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		before, err := getSnippet(tx, sn.ID)
+		if err != nil {
+			return err
+		}
+		if before.Version != sn.Version || before.DeletedAt != nil {
+			return s.conflict(tx, EntitySnippet, sn.ID, before, before.DeletedAt != nil)
+		}
+
+		_, err = tx.Exec(
+			`UPDATE snippets SET title = ?, description = ?, language = ?, code = ?, tags = ?, updated_at = ?,
+			 version = version + 1
+			 WHERE id = ? AND version = ?`,
+			sn.Title, sn.Description, sn.Language, sn.Code, sn.Tags, time.Now(), sn.ID, sn.Version,
+		)
+		if err != nil {
+			return err
+		}
+
+		after, err := getSnippet(tx, sn.ID)
+		if err != nil {
+			return err
+		}
+		return s.audit(tx, EntitySnippet, sn.ID, ActionUpdate, before, after)
+	})
+}
+
+// DeleteSnippet перемещает сниппет в корзину
+func (s *Store) DeleteSnippet(id int64) error {
+	return s.softDelete(EntitySnippet, id)
+}
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/database"
+)
+
+// Сущности, изменения которых записываются в журнал
+const (
+	EntityProject  = "project"
+	EntityEmployee = "employee"
+	EntitySnippet  = "snippet"
+)
+
+// Действия в журнале изменений
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"  // перемещение в корзину
+	ActionRestore = "restore" // восстановление из корзины
+	ActionPurge   = "purge"   // окончательное удаление
+)
+
+// Store - единая точка записи в БД. Каждое создание, изменение и удаление
+// выполняется в транзакции вместе с записью в журнал изменений.
+type Store struct {
+	db    *database.Manager
+	actor string
+}
+
+// New создает хранилище; actor - имя пользователя, записываемое в журнал
+func New(db *database.Manager, actor string) *Store {
+	if actor == "" {
+		actor = DefaultActor()
+	}
+	return &Store{
+		db:    db,
+		actor: actor,
+	}
+}
+
+// DefaultActor возвращает имя пользователя ОС
+func DefaultActor() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	if name := os.Getenv("USERNAME"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+// Actor возвращает имя пользователя, от которого выполняются изменения
+func (s *Store) Actor() string {
+	return s.actor
+}
+
+// DB возвращает подключение к БД для чтения.
+// Подключение берется каждый раз заново, так как после смены ключа оно меняется.
+func (s *Store) DB() *sql.DB {
+	return s.db.GetDB()
+}
+
+// querier - общий интерфейс *sql.DB и *sql.Tx для чтения
+type querier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// inTx выполняет fn в транзакции. Если БД занята другим процессом,
+// транзакция целиком повторяется по политике повторов менеджера БД.
+func (s *Store) inTx(fn func(tx *sql.Tx) error) error {
+	return s.db.WithRetry(func() error {
+		tx, err := s.DB().Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	})
+}
+
+// audit добавляет запись в журнал изменений; before и after сохраняются в JSON
+func (s *Store) audit(tx *sql.Tx, entity string, id int64, action string, before, after interface{}) error {
+	return s.auditFrom(tx, s.actor, "", entity, id, action, before, after)
+}
+
+// auditFrom добавляет запись в журнал от имени actor. origin - узел, с которого
+// пришло изменение при синхронизации, пусто - изменение сделано в этой БД.
+func (s *Store) auditFrom(tx *sql.Tx, actor, origin, entity string, id int64, action string, before, after interface{}) error {
+	beforeData, err := marshalState(before)
+	if err != nil {
+		return err
+	}
+	afterData, err := marshalState(after)
+	if err != nil {
+		return err
+	}
+
+	refNode, refID, err := auditRef(tx, entity, id, action, origin)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO audit_log (entity, entity_id, action, actor, before_data, after_data, created_at, origin, ref_node, ref_id)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entity, id, action, actor, beforeData, afterData, time.Now(), nullString(origin), refNode, refID,
+	)
+	if err != nil {
+		return err
+	}
+	return bumpChanges(tx, entity)
+}
+
+// marshalState сериализует состояние записи; nil сохраняется как NULL
+func marshalState(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
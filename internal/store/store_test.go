@@ -0,0 +1,23 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/deldim-kam/Jotnal/internal/database"
+)
+
+// newTestStore открывает хранилище над новой БД во временном каталоге теста
+func newTestStore(t *testing.T, name string) *Store {
+	t.Helper()
+
+	m, err := database.NewManager(filepath.Join(t.TempDir(), name+".db"), "test-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return New(m, "test")
+}
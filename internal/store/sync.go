@@ -0,0 +1,1211 @@
+package store
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/changeset"
+	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+)
+
+// EntitySync - счетчик изменений синхронизации (узлы и конфликты)
+const EntitySync = "sync"
+
+// Решения по конфликтам синхронизации
+const (
+	ResolutionLocal  = "local"  // оставлено значение этой БД
+	ResolutionRemote = "remote" // принято значение другого узла
+)
+
+// syncFields - поля сущностей, которые передаются при синхронизации
+var syncFields = map[string][]string{
+	EntityProject:  {"name", "path", "description", "deleted_at"},
+	EntitySnippet:  {"title", "description", "language", "code", "tags", "deleted_at"},
+	EntityEmployee: {"first_name", "last_name", "middle_name", "email", "position", "department", "manager_id", "phone", "hire_date", "deleted_at"},
+}
+
+// syncTimeFields - поля со временем, в JSON они хранятся строкой RFC 3339
+var syncTimeFields = map[string]bool{"deleted_at": true, "hire_date": true}
+
+// syncRefFields - поля со ссылкой на другую запись и сущность этой записи.
+// ID в них различаются между БД, поэтому в пакете они передаются как changeset.Ref.
+var syncRefFields = map[string]string{"manager_id": EntityEmployee}
+
+// syncUniqueFields - поля с ограничением UNIQUE. Значение может оказаться
+// занято другой локальной записью, например оставленным при конфликте.
+var syncUniqueFields = map[string]string{EntityProject: "path", EntityEmployee: "email"}
+
+// SyncNode - эта БД как узел синхронизации
+type SyncNode struct {
+	ID   string
+	Name string
+	Key  ed25519.PrivateKey
+}
+
+// PublicKey возвращает открытый ключ подписи узла
+func (n *SyncNode) PublicKey() ed25519.PublicKey {
+	return n.Key.Public().(ed25519.PublicKey)
+}
+
+// SyncPeer - другой узел, пакеты которого принимаются этой БД
+type SyncPeer struct {
+	Node       string
+	Name       string
+	PublicKey  ed25519.PublicKey
+	Received   int64 // последнее примененное изменение узла
+	Acked      int64 // последнее изменение этой БД, которое узел подтвердил
+	LastImport *time.Time
+}
+
+// SyncStatus - состояние синхронизации
+type SyncStatus struct {
+	Node          *SyncNode
+	Peers         []SyncPeer
+	Pending       int // изменений этой БД, еще не подтвержденных всеми узлами
+	OpenConflicts int
+}
+
+// SyncResult - итог применения пакета
+type SyncResult struct {
+	Applied   int
+	Skipped   int // уже применено или запись удалена окончательно
+	Conflicts int
+	Notes     []string
+}
+
+// SyncConflict - одновременное изменение поля в этой БД и на другом узле
+type SyncConflict struct {
+	ID          int64
+	Entity      string
+	EntityID    int64
+	Title       string
+	Field       string
+	Local       string
+	Remote      string
+	Peer        string // имя узла
+	RemoteActor string
+	RemoteTime  time.Time
+	CreatedAt   time.Time
+	Resolution  string // пусто - не решен
+	ResolvedAt  *time.Time
+}
+
+// UnknownPeerError возвращается, если пакет пришел от узла, которому еще не доверяют
+type UnknownPeerError struct {
+	Node        string
+	Name        string
+	Fingerprint string
+}
+
+func (e *UnknownPeerError) Error() string {
+	return i18n.Sprintf("узел %s (%s) не входит в доверенные, отпечаток ключа %s", e.Name, e.Node, e.Fingerprint)
+}
+
+// SyncNode возвращает узел этой БД. Узел создается вместе со схемой, поэтому
+// обычно он только читается, без транзакции записи: так статус и выгрузка
+// работают и с БД только для чтения. Имя узла по умолчанию сохраняется
+// позже, при первой записи.
+func (s *Store) SyncNode() (*SyncNode, error) {
+	node, err := loadSyncNode(s.DB())
+	if err != nil || node != nil {
+		if node != nil && node.Name == "" {
+			node.Name = hostName()
+		}
+		return node, err
+	}
+
+	err = s.inTx(func(tx *sql.Tx) error {
+		var err error
+		node, err = syncNode(tx)
+		return err
+	})
+	return node, err
+}
+
+// loadSyncNode читает узел из sync_meta; nil без ошибки - узла еще нет
+func loadSyncNode(q querier) (*SyncNode, error) {
+	meta := make(map[string]string)
+	rows, err := q.Query("SELECT key, value FROM sync_meta")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		meta[key] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if meta["node"] == "" {
+		return nil, nil
+	}
+	seed, err := hex.DecodeString(meta["key"])
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, i18n.Errorf("поврежден ключ узла синхронизации")
+	}
+	return &SyncNode{ID: meta["node"], Name: meta["name"], Key: ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+// syncNode загружает или создает узел в транзакции записи и сохраняет имя
+// узла по умолчанию
+func syncNode(tx *sql.Tx) (*SyncNode, error) {
+	node, err := loadSyncNode(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if node != nil {
+		if node.Name == "" {
+			node.Name = hostName()
+			_, err := tx.Exec("INSERT OR REPLACE INTO sync_meta (key, value) VALUES ('name', ?)", node.Name)
+			return node, err
+		}
+		return node, nil
+	}
+
+	if node, err = newSyncNode(); err != nil {
+		return nil, err
+	}
+	return node, saveSyncNode(tx, node)
+}
+
+// newSyncNode создает новый идентификатор узла и ключ подписи
+func newSyncNode() (*SyncNode, error) {
+	id := make([]byte, 6)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SyncNode{ID: hex.EncodeToString(id), Name: hostName(), Key: key}, nil
+}
+
+// hostName возвращает имя компьютера - имя узла по умолчанию
+func hostName() string {
+	name, _ := os.Hostname()
+	if name == "" {
+		name = "jotnal"
+	}
+	return name
+}
+
+// saveSyncNode сохраняет узел в sync_meta
+func saveSyncNode(tx *sql.Tx, node *SyncNode) error {
+	for key, value := range map[string]string{
+		"node": node.ID,
+		"name": node.Name,
+		"key":  hex.EncodeToString(node.Key.Seed()),
+	} {
+		_, err := tx.Exec(`INSERT INTO sync_meta (key, value) VALUES (?, ?)
+			ON CONFLICT(key) DO UPDATE SET value = excluded.value`, key, value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResetSyncNode дает БД новый идентификатор узла. Нужен, если файл БД
+// скопирован на другой пост: иначе обе копии будут одним узлом. Записи и
+// журнал копии остаются связаны с прежним узлом, а прежний узел сразу
+// становится доверенным и считается полностью полученным.
+func (s *Store) ResetSyncNode() (*SyncNode, error) {
+	var node *SyncNode
+	err := s.inTx(func(tx *sql.Tx) error {
+		old, err := syncNode(tx)
+		if err != nil {
+			return err
+		}
+		if node, err = newSyncNode(); err != nil {
+			return err
+		}
+
+		for entity, table := range entityTables {
+			_, err := tx.Exec(`INSERT OR IGNORE INTO sync_map (entity, local_id, origin, origin_id)
+				SELECT ?, id, ?, id FROM `+table+`
+				WHERE id NOT IN (SELECT local_id FROM sync_map WHERE entity = ?)`,
+				entity, old.ID, entity)
+			if err != nil {
+				return err
+			}
+		}
+
+		var received int64
+		if err := tx.QueryRow("SELECT COALESCE(MAX(id), 0) FROM audit_log WHERE origin IS NULL").Scan(&received); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE audit_log SET origin = ? WHERE origin IS NULL", old.ID); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO sync_peers (node, name, public_key, received) VALUES (?, ?, ?, ?)
+			ON CONFLICT(node) DO UPDATE SET received = excluded.received`,
+			old.ID, old.Name, hex.EncodeToString(old.PublicKey()), received)
+		if err != nil {
+			return err
+		}
+
+		if err := saveSyncNode(tx, node); err != nil {
+			return err
+		}
+		return bumpChanges(tx, EntitySync)
+	})
+	return node, err
+}
+
+// SyncStatus возвращает узел, доверенные узлы и число неотправленных изменений
+func (s *Store) SyncStatus() (*SyncStatus, error) {
+	node, err := s.SyncNode()
+	if err != nil {
+		return nil, err
+	}
+	status := &SyncStatus{Node: node}
+	if status.Peers, err = syncPeers(s.DB()); err != nil {
+		return nil, err
+	}
+
+	err = s.DB().QueryRow("SELECT COUNT(*) FROM audit_log WHERE origin IS NULL AND id > ? AND entity IN (?, ?, ?)",
+		exportSince(status.Peers), EntityProject, EntityEmployee, EntitySnippet).Scan(&status.Pending)
+	if err != nil {
+		return nil, err
+	}
+	err = s.DB().QueryRow("SELECT COUNT(*) FROM sync_conflicts WHERE resolved_at IS NULL").Scan(&status.OpenConflicts)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// syncPeers возвращает доверенные узлы
+func syncPeers(q querier) ([]SyncPeer, error) {
+	rows, err := q.Query("SELECT node, name, public_key, received, acked, last_import FROM sync_peers ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var peers []SyncPeer
+	for rows.Next() {
+		var p SyncPeer
+		var key string
+		var lastImport sql.NullTime
+		if err := rows.Scan(&p.Node, &p.Name, &key, &p.Received, &p.Acked, &lastImport); err != nil {
+			return nil, err
+		}
+		p.PublicKey, _ = hex.DecodeString(key)
+		p.LastImport = nullTime(lastImport)
+		peers = append(peers, p)
+	}
+	return peers, rows.Err()
+}
+
+// exportSince возвращает последнее изменение, подтвержденное всеми узлами
+func exportSince(peers []SyncPeer) int64 {
+	if len(peers) == 0 {
+		return 0
+	}
+	since := peers[0].Acked
+	for _, p := range peers[1:] {
+		since = min(since, p.Acked)
+	}
+	return since
+}
+
+// ExportChanges собирает пакет изменений этой БД, которые еще не подтвердили
+// все доверенные узлы; full - все изменения с начала журнала
+func (s *Store) ExportChanges(full bool) (*changeset.Changeset, error) {
+	node, err := s.SyncNode()
+	if err != nil {
+		return nil, err
+	}
+	q := s.DB()
+	peers, err := syncPeers(q)
+	if err != nil {
+		return nil, err
+	}
+
+	cs := &changeset.Changeset{
+		Node:      node.ID,
+		Name:      node.Name,
+		CreatedAt: time.Now(),
+		Ack:       make(map[string]int64),
+	}
+	if !full {
+		cs.Since = exportSince(peers)
+	}
+	for _, p := range peers {
+		cs.Ack[p.Node] = p.Received
+	}
+
+	rows, err := q.Query(`SELECT id, entity, entity_id, ref_node, ref_id, action, actor, before_data, after_data, created_at
+		FROM audit_log WHERE origin IS NULL AND id > ? AND entity IN (?, ?, ?) ORDER BY id`,
+		cs.Since, EntityProject, EntityEmployee, EntitySnippet)
+	if err != nil {
+		return nil, err
+	}
+	type entry struct {
+		change        changeset.Change
+		before, after sql.NullString
+	}
+	var entries []entry
+	for rows.Next() {
+		var e entry
+		var refNode sql.NullString
+		var refID sql.NullInt64
+		err := rows.Scan(&e.change.Seq, &e.change.Entity, &e.change.Ref.ID, &refNode, &refID,
+			&e.change.Action, &e.change.Actor, &e.before, &e.after, &e.change.Time)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		e.change.Ref.Node = node.ID
+		if refNode.Valid {
+			e.change.Ref = changeset.Ref{Node: refNode.String, ID: refID.Int64}
+		}
+		entries = append(entries, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		ch := e.change
+		if ch.Before, err = exportState(q, e.before, node.ID); err != nil {
+			return nil, err
+		}
+		if ch.After, err = exportState(q, e.after, node.ID); err != nil {
+			return nil, err
+		}
+		cs.Changes = append(cs.Changes, ch)
+	}
+
+	return cs, nil
+}
+
+// exportState разбирает состояние записи из журнала и заменяет ID в ссылках
+// глобальными ссылками
+func exportState(q querier, data sql.NullString, self string) (map[string]interface{}, error) {
+	if !data.Valid || data.String == "" {
+		return nil, nil
+	}
+	state, err := decodeState(data.String)
+	if err != nil {
+		return nil, err
+	}
+	for field, entity := range syncRefFields {
+		id, ok := state[field].(float64)
+		if !ok {
+			continue
+		}
+		ref, err := globalRef(q, entity, int64(id), self)
+		if err != nil {
+			return nil, err
+		}
+		state[field] = ref
+	}
+	return state, nil
+}
+
+// globalRef возвращает глобальную ссылку на локальную запись
+func globalRef(q querier, entity string, id int64, self string) (changeset.Ref, error) {
+	ref := changeset.Ref{Node: self, ID: id}
+	err := q.QueryRow("SELECT origin, origin_id FROM sync_map WHERE entity = ? AND local_id = ?", entity, id).
+		Scan(&ref.Node, &ref.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return ref, err
+	}
+	return ref, nil
+}
+
+// auditRef возвращает глобальную ссылку на запись для журнала изменений,
+// nil - запись создана в этой БД. Запись, заново созданная в этой БД, теряет
+// связь с записью другого узла: ее ID мог остаться от удаленной записи.
+func auditRef(tx *sql.Tx, entity string, id int64, action, origin string) (interface{}, interface{}, error) {
+	if _, ok := syncFields[entity]; !ok {
+		return nil, nil, nil
+	}
+	if action == ActionCreate && origin == "" {
+		_, err := tx.Exec("DELETE FROM sync_map WHERE entity = ? AND local_id = ?", entity, id)
+		return nil, nil, err
+	}
+
+	var node string
+	var refID int64
+	err := tx.QueryRow("SELECT origin, origin_id FROM sync_map WHERE entity = ? AND local_id = ? LIMIT 1", entity, id).
+		Scan(&node, &refID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return node, refID, nil
+}
+
+// localID находит локальную запись по глобальной ссылке
+func localID(q querier, entity string, ref changeset.Ref, self string) (int64, bool, error) {
+	if ref.Node == self {
+		return ref.ID, true, nil
+	}
+	var id int64
+	err := q.QueryRow("SELECT local_id FROM sync_map WHERE entity = ? AND origin = ? AND origin_id = ?",
+		entity, ref.Node, ref.ID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	return id, err == nil, err
+}
+
+// ImportChanges применяет пакет другого узла одной транзакцией. Уже
+// примененные изменения пропускаются, поэтому один пакет можно загрузить
+// повторно. Пакет от неизвестного узла принимается только с trust.
+func (s *Store) ImportChanges(cs *changeset.Changeset, key ed25519.PublicKey, strategy string, trust bool) (*SyncResult, error) {
+	if err := config.ValidateStrategy(strategy); err != nil {
+		return nil, err
+	}
+
+	var result *SyncResult
+	err := s.inTx(func(tx *sql.Tx) error {
+		node, err := syncNode(tx)
+		if err != nil {
+			return err
+		}
+		peers, err := syncPeers(tx)
+		if err != nil {
+			return err
+		}
+
+		peer, err := checkPeer(node, peers, cs, key)
+		var unknown *UnknownPeerError
+		if errors.As(err, &unknown) && trust {
+			peer = &SyncPeer{Node: cs.Node, Name: cs.Name, PublicKey: key}
+			_, err = tx.Exec("INSERT INTO sync_peers (node, name, public_key) VALUES (?, ?, ?)",
+				cs.Node, cs.Name, hex.EncodeToString(key))
+		}
+		if err != nil {
+			return err
+		}
+
+		sy := &syncer{
+			store:    s,
+			tx:       tx,
+			self:     node.ID,
+			peer:     cs.Node,
+			strategy: strategy,
+			result:   &SyncResult{},
+			claimed:  make(map[string]map[int64]bool),
+			emails:   packageEmails(cs),
+		}
+		received := peer.Received
+		for _, ch := range cs.Changes {
+			if ch.Seq <= received {
+				sy.result.Skipped++
+				continue
+			}
+			if err := sy.apply(ch); err != nil {
+				return i18n.Errorf("изменение %d (%s %d): %w", ch.Seq, ch.Entity, ch.Ref.ID, err)
+			}
+			received = ch.Seq
+		}
+
+		_, err = tx.Exec("UPDATE sync_peers SET name = ?, received = ?, acked = MAX(acked, ?), last_import = ? WHERE node = ?",
+			cs.Name, received, cs.Ack[node.ID], time.Now(), cs.Node)
+		if err != nil {
+			return err
+		}
+		if err := bumpChanges(tx, EntitySync); err != nil {
+			return err
+		}
+
+		result = sy.result
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CheckSyncPeer проверяет отправителя пакета до загрузки: для неизвестного
+// узла возвращает *UnknownPeerError, чтобы спросить, доверять ли ему
+func (s *Store) CheckSyncPeer(cs *changeset.Changeset, key ed25519.PublicKey) error {
+	node, err := s.SyncNode()
+	if err != nil {
+		return err
+	}
+	peers, err := syncPeers(s.DB())
+	if err != nil {
+		return err
+	}
+	_, err = checkPeer(node, peers, cs, key)
+	return err
+}
+
+// checkPeer находит отправителя пакета среди доверенных узлов и сверяет его ключ
+func checkPeer(node *SyncNode, peers []SyncPeer, cs *changeset.Changeset, key ed25519.PublicKey) (*SyncPeer, error) {
+	if cs.Node == node.ID {
+		return nil, i18n.Errorf("пакет создан этой же БД; если файл БД скопирован на другой пост, выполните на копии jotnal sync init")
+	}
+	for _, p := range peers {
+		if p.Node != cs.Node {
+			continue
+		}
+		if !p.PublicKey.Equal(key) {
+			return nil, i18n.Errorf("ключ узла %s (%s) не совпадает с сохраненным: пакет подписан другим ключом",
+				cs.Name, cs.Node)
+		}
+		return &p, nil
+	}
+	return nil, &UnknownPeerError{Node: cs.Node, Name: cs.Name, Fingerprint: changeset.Fingerprint(key)}
+}
+
+// syncer применяет изменения пакета в транзакции
+type syncer struct {
+	store    *Store
+	tx       *sql.Tx
+	self     string // узел этой БД
+	peer     string // узел-отправитель
+	strategy string
+	result   *SyncResult
+
+	// claimed - локальные записи, созданные или связанные при загрузке этого
+	// пакета; другая запись пакета с ними уже не сопоставляется
+	claimed map[string]map[int64]bool
+	// emails - email сотрудников пакета в исходном регистре
+	emails map[string]bool
+}
+
+// packageEmails собирает email сотрудников, которые есть в пакете
+func packageEmails(cs *changeset.Changeset) map[string]bool {
+	emails := make(map[string]bool)
+	for _, ch := range cs.Changes {
+		if email, _ := ch.After["email"].(string); ch.Entity == EntityEmployee && email != "" {
+			emails[email] = true
+		}
+	}
+	return emails
+}
+
+// claim отмечает локальную запись как созданную или связанную этим пакетом
+func (sy *syncer) claim(entity string, id int64) {
+	if sy.claimed[entity] == nil {
+		sy.claimed[entity] = make(map[int64]bool)
+	}
+	sy.claimed[entity][id] = true
+}
+
+// note добавляет замечание к итогу синхронизации
+func (sy *syncer) note(format string, args ...interface{}) {
+	sy.result.Notes = append(sy.result.Notes, i18n.Sprintf(format, args...))
+}
+
+// apply применяет одно изменение
+func (sy *syncer) apply(ch changeset.Change) error {
+	table, ok := entityTables[ch.Entity]
+	if !ok {
+		sy.note("изменение %d: неизвестный тип записи %q", ch.Seq, ch.Entity)
+		sy.result.Skipped++
+		return nil
+	}
+
+	id, known, err := localID(sy.tx, ch.Entity, ch.Ref, sy.self)
+	if err != nil {
+		return err
+	}
+	exists := false
+	if known {
+		var count int
+		if err := sy.tx.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE id = ?", id).Scan(&count); err != nil {
+			return err
+		}
+		exists = count > 0
+	}
+
+	switch {
+	case known && !exists:
+		// Запись удалена здесь окончательно, изменения к ней не применяются
+		sy.result.Skipped++
+		return nil
+	case ch.Action == ActionPurge:
+		if !exists {
+			sy.result.Skipped++
+			return nil
+		}
+		return sy.purge(ch, id)
+	case !known:
+		if ch.After == nil {
+			sy.result.Skipped++
+			return nil
+		}
+		match, err := sy.match(ch)
+		if err != nil {
+			return err
+		}
+		if match == 0 {
+			return sy.create(ch, table)
+		}
+		// Такая запись уже заведена здесь независимо: связываем и сливаем поля,
+		// не зная исходных значений
+		if err := sy.mapRef(ch, match); err != nil {
+			return err
+		}
+		sy.claim(ch.Entity, match)
+		ch.Before = nil
+		return sy.merge(ch, table, match)
+	}
+	return sy.merge(ch, table, id)
+}
+
+// match ищет запись, заведенную независимо на обоих узлах: проект по пути,
+// сотрудника по email, сниппет по названию и языку. Запись, уже связанная с
+// другой записью того же узла или занятая этим пакетом, не подходит.
+// Сотрудник с email в другом регистре подходит, только если в пакете нет
+// сотрудника ровно с его email.
+func (sy *syncer) match(ch changeset.Change) (int64, error) {
+	var query string
+	var args []interface{}
+	switch ch.Entity {
+	case EntityProject:
+		query, args = "SELECT id, path FROM projects WHERE path = ?", []interface{}{ch.After["path"]}
+	case EntityEmployee:
+		email, _ := ch.After["email"].(string)
+		if email == "" {
+			return 0, nil
+		}
+		query, args = "SELECT id, email FROM employees WHERE lower(email) = lower(?)", []interface{}{email}
+	case EntitySnippet:
+		query, args = "SELECT id, title FROM snippets WHERE title = ? AND language = ?", []interface{}{ch.After["title"], ch.After["language"]}
+	}
+
+	query += " AND id NOT IN (SELECT local_id FROM sync_map WHERE entity = ? AND origin = ?) ORDER BY id"
+	args = append(args, ch.Entity, ch.Ref.Node)
+	rows, err := sy.tx.Query(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var match int64
+	for rows.Next() {
+		var id int64
+		var key string
+		if err := rows.Scan(&id, &key); err != nil {
+			return 0, err
+		}
+		if sy.claimed[ch.Entity][id] {
+			continue
+		}
+		if ch.Entity != EntityEmployee || key == ch.After["email"] {
+			return id, rows.Err()
+		}
+		if match == 0 && !sy.emails[key] {
+			match = id
+		}
+	}
+	return match, rows.Err()
+}
+
+// mapRef связывает запись другого узла с локальной записью
+func (sy *syncer) mapRef(ch changeset.Change, id int64) error {
+	if ch.Ref.Node == sy.self {
+		return nil
+	}
+	_, err := sy.tx.Exec("INSERT OR REPLACE INTO sync_map (entity, local_id, origin, origin_id) VALUES (?, ?, ?, ?)",
+		ch.Entity, id, ch.Ref.Node, ch.Ref.ID)
+	return err
+}
+
+// create добавляет запись, созданную на другом узле
+func (sy *syncer) create(ch changeset.Change, table string) error {
+	after, err := sy.localState(ch.After)
+	if err != nil {
+		return err
+	}
+	field, taken, err := sy.freeUnique(ch.Entity, after)
+	if err != nil {
+		return err
+	}
+
+	columns := []string{"created_at", "updated_at", "version"}
+	values := []interface{}{sy.timeValue(ch.After["created_at"], ch.Time), sy.timeValue(ch.After["updated_at"], ch.Time), 1}
+	for _, field := range syncFields[ch.Entity] {
+		if value, ok := after[field]; ok {
+			columns = append(columns, field)
+			values = append(values, columnValue(field, value))
+		}
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table,
+		strings.Join(columns, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+	res, err := sy.tx.Exec(query, values...)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if err := sy.mapRef(ch, id); err != nil {
+		return err
+	}
+	sy.claim(ch.Entity, id)
+
+	state, err := getState(sy.tx, ch.Entity, id)
+	if err != nil {
+		return err
+	}
+	sy.result.Applied++
+	if err := sy.store.auditFrom(sy.tx, ch.Actor, sy.peer, ch.Entity, id, ActionCreate, nil, state); err != nil {
+		return err
+	}
+
+	if field == "" {
+		return nil
+	}
+	sy.note("запись %d: значение %s %q уже занято другой записью и заменено, конфликт ждет решения", id, field, taken)
+	return sy.conflict(ch, id, field, after[field], taken, "")
+}
+
+// freeUnique проверяет, не занято ли значение поля UNIQUE новой записи
+// другой локальной записью. Занятое значение заменяется свободным: у
+// сотрудника email очищается, к пути проекта добавляется номер. Возвращает
+// поле и исходное значение или пустую строку, если замена не нужна.
+func (sy *syncer) freeUnique(entity string, after map[string]interface{}) (string, interface{}, error) {
+	field, ok := syncUniqueFields[entity]
+	value, _ := after[field].(string)
+	if !ok || value == "" {
+		return "", nil, nil
+	}
+
+	isTaken := func(value string) (bool, error) {
+		var count int
+		err := sy.tx.QueryRow("SELECT COUNT(*) FROM "+entityTables[entity]+" WHERE "+field+" = ?", value).Scan(&count)
+		return count > 0, err
+	}
+	taken, err := isTaken(value)
+	if err != nil || !taken {
+		return "", nil, err
+	}
+
+	if entity == EntityEmployee {
+		after[field] = nil
+		return field, value, nil
+	}
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)", value, n)
+		taken, err := isTaken(candidate)
+		if err != nil {
+			return "", nil, err
+		}
+		if !taken {
+			after[field] = candidate
+			return field, value, nil
+		}
+	}
+}
+
+// purge окончательно удаляет запись, удаленную на другом узле
+func (sy *syncer) purge(ch changeset.Change, id int64) error {
+	before, err := getState(sy.tx, ch.Entity, id)
+	if err != nil {
+		return err
+	}
+	if err := sy.store.purgeRecord(sy.tx, ch.Entity, id); err != nil {
+		return err
+	}
+	if err := sy.store.auditFrom(sy.tx, ch.Actor, sy.peer, ch.Entity, id, ActionPurge, before, nil); err != nil {
+		return err
+	}
+	sy.result.Applied++
+
+	// Узел, создавший запись, может занять ее ID заново: следующая запись с
+	// этой ссылкой - уже другая
+	if ch.Ref.Node == sy.peer {
+		_, err := sy.tx.Exec("DELETE FROM sync_map WHERE entity = ? AND origin = ? AND origin_id = ?",
+			ch.Entity, ch.Ref.Node, ch.Ref.ID)
+		return err
+	}
+	return nil
+}
+
+// merge применяет к локальной записи поля, измененные на другом узле.
+// Поле, измененное и здесь, и там, - конфликт: по стратегии field остается
+// локальное значение и конфликт ждет решения, по стратегии lww побеждает
+// более позднее изменение, а конфликт записывается уже решенным.
+func (sy *syncer) merge(ch changeset.Change, table string, id int64) error {
+	current, err := getState(sy.tx, ch.Entity, id)
+	if err != nil {
+		return err
+	}
+	local, err := stateMap(current)
+	if err != nil {
+		return err
+	}
+	before, err := sy.localState(ch.Before)
+	if err != nil {
+		return err
+	}
+	after, err := sy.localState(ch.After)
+	if err != nil {
+		return err
+	}
+	localTime, _ := parseTime(local["updated_at"])
+
+	set := make(map[string]interface{})
+	for _, field := range syncFields[ch.Entity] {
+		remote, ok := after[field]
+		if !ok {
+			continue
+		}
+		if valuesEqual(local[field], remote) {
+			// Другой узел принял значение этой БД
+			if err := sy.settle(ch.Entity, id, field, ResolutionLocal); err != nil {
+				return err
+			}
+			continue
+		}
+		if ch.Before != nil {
+			if valuesEqual(before[field], remote) {
+				continue // на другом узле поле не менялось
+			}
+			if valuesEqual(local[field], before[field]) {
+				set[field] = remote // здесь поле не менялось
+				if err := sy.settle(ch.Entity, id, field, ResolutionRemote); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		resolution := ""
+		if sy.strategy == config.SyncStrategyLWW {
+			resolution = ResolutionLocal
+			if ch.Time.After(localTime) {
+				resolution = ResolutionRemote
+				set[field] = remote
+			}
+		}
+		if err := sy.conflict(ch, id, field, local[field], remote, resolution); err != nil {
+			return err
+		}
+	}
+
+	sy.result.Applied++
+	if len(set) == 0 {
+		return nil
+	}
+
+	updatedAt := localTime
+	if ch.Time.After(updatedAt) {
+		updatedAt = ch.Time
+	}
+	if err := updateFields(sy.tx, table, id, set, updatedAt); err != nil {
+		return err
+	}
+
+	state, err := getState(sy.tx, ch.Entity, id)
+	if err != nil {
+		return err
+	}
+	action := ch.Action
+	if action == ActionCreate {
+		action = ActionUpdate
+	}
+	return sy.store.auditFrom(sy.tx, ch.Actor, sy.peer, ch.Entity, id, action, current, state)
+}
+
+// conflict записывает конфликт поля; resolution пусто - конфликт ждет решения
+func (sy *syncer) conflict(ch changeset.Change, id int64, field string, local, remote interface{}, resolution string) error {
+	localData, err := json.Marshal(local)
+	if err != nil {
+		return err
+	}
+	remoteData, err := json.Marshal(remote)
+	if err != nil {
+		return err
+	}
+
+	var resolvedAt interface{}
+	if resolution != "" {
+		resolvedAt = time.Now()
+	}
+	_, err = sy.tx.Exec(
+		`INSERT INTO sync_conflicts (entity, entity_id, field, local_value, remote_value, remote_node,
+		 remote_actor, remote_time, created_at, resolution, resolved_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		ch.Entity, id, field, string(localData), string(remoteData), sy.peer,
+		ch.Actor, ch.Time, time.Now(), nullString(resolution), resolvedAt,
+	)
+	if err != nil {
+		return err
+	}
+	sy.result.Conflicts++
+	return nil
+}
+
+// settle закрывает открытые конфликты поля, когда узлы пришли к одному
+// значению: конфликт решен на другом узле
+func (sy *syncer) settle(entity string, id int64, field, resolution string) error {
+	_, err := sy.tx.Exec(`UPDATE sync_conflicts SET resolution = ?, resolved_at = ?
+		WHERE entity = ? AND entity_id = ? AND field = ? AND resolved_at IS NULL`,
+		resolution, time.Now(), entity, id, field)
+	return err
+}
+
+// localState заменяет в состоянии из пакета глобальные ссылки на локальные ID.
+// Ссылка на запись, которой здесь нет, становится пустой.
+func (sy *syncer) localState(state map[string]interface{}) (map[string]interface{}, error) {
+	if state == nil {
+		return nil, nil
+	}
+	result := make(map[string]interface{}, len(state))
+	for field, value := range state {
+		result[field] = value
+		entity, isRef := syncRefFields[field]
+		if !isRef || value == nil {
+			continue
+		}
+
+		var ref changeset.Ref
+		data, _ := json.Marshal(value)
+		if err := json.Unmarshal(data, &ref); err != nil || ref.Node == "" {
+			result[field] = nil
+			continue
+		}
+		id, known, err := localID(sy.tx, entity, ref, sy.self)
+		if err != nil {
+			return nil, err
+		}
+		if !known {
+			sy.note("ссылка %s на запись %d узла %s: записи нет в этой БД, ссылка очищена", field, ref.ID, ref.Node)
+			result[field] = nil
+			continue
+		}
+		result[field] = float64(id)
+	}
+	return result, nil
+}
+
+// timeValue возвращает время из состояния записи или fallback
+func (sy *syncer) timeValue(value interface{}, fallback time.Time) time.Time {
+	if t, ok := parseTime(value); ok {
+		return t
+	}
+	return fallback
+}
+
+// updateFields записывает поля записи, увеличивая ее версию
+func updateFields(tx *sql.Tx, table string, id int64, set map[string]interface{}, updatedAt time.Time) error {
+	assignments := []string{"updated_at = ?", "version = version + 1"}
+	values := []interface{}{updatedAt}
+	for field, value := range set {
+		assignments = append(assignments, field+" = ?")
+		values = append(values, columnValue(field, value))
+	}
+	values = append(values, id)
+
+	_, err := tx.Exec("UPDATE "+table+" SET "+strings.Join(assignments, ", ")+" WHERE id = ?", values...)
+	return err
+}
+
+// columnValue приводит значение поля из JSON к значению столбца
+func columnValue(field string, value interface{}) interface{} {
+	switch {
+	case value == nil:
+		return nil
+	case syncTimeFields[field]:
+		if t, ok := parseTime(value); ok {
+			return t
+		}
+		return nil
+	case syncRefFields[field] != "":
+		if id, ok := value.(float64); ok {
+			return int64(id)
+		}
+		return nil
+	case field == "email":
+		s, _ := value.(string)
+		return nullString(s)
+	}
+	return value
+}
+
+// stateMap приводит запись к виду состояния в журнале изменений
+func stateMap(record interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	return decodeState(string(data))
+}
+
+// valuesEqual сравнивает значения полей; время сравнивается как момент,
+// а пустая строка равна NULL
+func valuesEqual(a, b interface{}) bool {
+	if ta, ok := parseTime(a); ok {
+		if tb, ok := parseTime(b); ok {
+			return ta.Equal(tb)
+		}
+	}
+	return formatValue(a) == formatValue(b)
+}
+
+// parseTime разбирает время в формате JSON
+func parseTime(value interface{}) (time.Time, bool) {
+	s, ok := value.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	return t, err == nil
+}
+
+// ListConflicts возвращает конфликты синхронизации, начиная с новых;
+// all - вместе с решенными
+func (s *Store) ListConflicts(all bool) ([]SyncConflict, error) {
+	query := `SELECT c.id, c.entity, c.entity_id, c.field, COALESCE(c.local_value, ''), COALESCE(c.remote_value, ''),
+		COALESCE(p.name, c.remote_node), c.remote_actor, c.remote_time, c.created_at,
+		COALESCE(c.resolution, ''), c.resolved_at
+		FROM sync_conflicts c LEFT JOIN sync_peers p ON p.node = c.remote_node`
+	if !all {
+		query += " WHERE c.resolved_at IS NULL"
+	}
+	rows, err := s.DB().Query(query + " ORDER BY c.id DESC")
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []SyncConflict
+	for rows.Next() {
+		var c SyncConflict
+		var resolvedAt sql.NullTime
+		err := rows.Scan(&c.ID, &c.Entity, &c.EntityID, &c.Field, &c.Local, &c.Remote,
+			&c.Peer, &c.RemoteActor, &c.RemoteTime, &c.CreatedAt, &c.Resolution, &resolvedAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		c.ResolvedAt = nullTime(resolvedAt)
+		conflicts = append(conflicts, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range conflicts {
+		c := &conflicts[i]
+		c.Local, c.Remote = conflictValue(c.Local), conflictValue(c.Remote)
+		c.Title = i18n.Sprintf("%s #%d (удалена)", c.Entity, c.EntityID)
+		if state, err := getState(s.DB(), c.Entity, c.EntityID); err == nil {
+			c.Title = recordTitle(state)
+		}
+	}
+	return conflicts, nil
+}
+
+// conflictValue форматирует значение поля, сохраненное в JSON
+func conflictValue(data string) string {
+	var value interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return data
+	}
+	return formatValue(value)
+}
+
+// recordTitle возвращает название записи для вывода
+func recordTitle(record interface{}) string {
+	if state, err := stateMap(record); err == nil {
+		for _, field := range []string{"name", "title"} {
+			if title, ok := state[field].(string); ok {
+				return title
+			}
+		}
+		return strings.TrimSpace(fmt.Sprintf("%v %v", state["last_name"], state["first_name"]))
+	}
+	return ""
+}
+
+// ResolveConflict решает конфликт: takeRemote - принять значение другого узла,
+// иначе оставить текущее. Решение записывается в журнал как обычное изменение
+// и уходит на другой узел со следующим пакетом, чтобы узлы пришли к одному значению.
+func (s *Store) ResolveConflict(id int64, takeRemote bool) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		var entity, field, remoteData string
+		var entityID int64
+		err := tx.QueryRow(`SELECT entity, entity_id, field, COALESCE(remote_value, 'null') FROM sync_conflicts
+			WHERE id = ? AND resolved_at IS NULL`, id).Scan(&entity, &entityID, &field, &remoteData)
+		if errors.Is(err, sql.ErrNoRows) {
+			return i18n.Errorf("конфликт уже решен")
+		}
+		if err != nil {
+			return err
+		}
+
+		var remote interface{}
+		if err := json.Unmarshal([]byte(remoteData), &remote); err != nil {
+			return i18n.Errorf("поврежденное значение конфликта: %w", err)
+		}
+
+		resolution := ResolutionLocal
+		if takeRemote {
+			resolution = ResolutionRemote
+		}
+		_, err = tx.Exec("UPDATE sync_conflicts SET resolution = ?, resolved_at = ? WHERE id = ?", resolution, time.Now(), id)
+		if err != nil {
+			return err
+		}
+		if err := bumpChanges(tx, EntitySync); err != nil {
+			return err
+		}
+
+		current, err := getState(tx, entity, entityID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil // запись удалена окончательно, решать нечего
+		}
+		if err != nil {
+			return err
+		}
+
+		// Выбранное значение записывается как изменение поля со значения
+		// другого варианта: так узел, получивший пакет, применит его без конфликта
+		var before interface{} = current
+		set := map[string]interface{}{}
+		if takeRemote {
+			set[field] = remote
+		} else {
+			state, err := stateMap(current)
+			if err != nil {
+				return err
+			}
+			state[field] = remote
+			before = state
+		}
+		if err := updateFields(tx, entityTables[entity], entityID, set, time.Now()); err != nil {
+			return err
+		}
+
+		after, err := getState(tx, entity, entityID)
+		if err != nil {
+			return err
+		}
+		return s.audit(tx, entity, entityID, ActionUpdate, before, after)
+	})
+}
@@ -0,0 +1,377 @@
+package store
+
+import (
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/deldim-kam/Jotnal/internal/database"
+	"github.com/deldim-kam/Jotnal/pkg/models"
+)
+
+// syncTo выгружает изменения src и загружает их в dst
+func syncTo(t *testing.T, src, dst *Store, strategy string) *SyncResult {
+	t.Helper()
+
+	node, err := src.SyncNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs, err := src.ExportChanges(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := dst.ImportChanges(cs, node.Key.Public().(ed25519.PublicKey), strategy, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// employeeEmails возвращает email сотрудников, не находящихся в корзине
+func employeeEmails(t *testing.T, s *Store) map[string]models.Employee {
+	t.Helper()
+
+	employees, err := s.ListEmployees()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byEmail := make(map[string]models.Employee)
+	for _, e := range employees {
+		byEmail[e.Email] = e
+	}
+	return byEmail
+}
+
+func TestSyncMatchesLocalRecordOnce(t *testing.T) {
+	// Дата приема задана явно: иначе у независимо заведенных сотрудников
+	// она различается и дает конфликт
+	hired := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		local []models.Employee // сотрудники, заведенные в целевой БД независимо
+	}{
+		{"пустая БД", nil},
+		{"сотрудник уже заведен", []models.Employee{{FirstName: "Иван", LastName: "Иванов", Email: "a@b.c", HireDate: hired}}},
+		{"второй сотрудник уже заведен", []models.Employee{{FirstName: "Петр", LastName: "Петров", Email: "A@B.C", HireDate: hired}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := newTestStore(t, "source")
+			dst := newTestStore(t, "target")
+			for _, e := range []models.Employee{
+				{FirstName: "Иван", LastName: "Иванов", Email: "a@b.c", HireDate: hired},
+				{FirstName: "Петр", LastName: "Петров", Email: "A@B.C", HireDate: hired},
+			} {
+				if _, err := src.CreateEmployee(e); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, e := range tt.local {
+				if _, err := dst.CreateEmployee(e); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result := syncTo(t, src, dst, config.SyncStrategyField)
+			if result.Conflicts != 0 {
+				t.Errorf("конфликтов %d, ожидалось 0", result.Conflicts)
+			}
+
+			got := employeeEmails(t, dst)
+			if len(got) != 2 || got["a@b.c"].LastName != "Иванов" || got["A@B.C"].LastName != "Петров" {
+				t.Errorf("сотрудники после синхронизации: %+v", got)
+			}
+		})
+	}
+}
+
+func TestSyncConflicts(t *testing.T) {
+	tests := []struct {
+		name         string
+		strategy     string
+		wantName     string // название проекта в целевой БД после синхронизации
+		wantOpen     int    // открытых конфликтов
+		resolveTaken string // название после принятия входящего значения
+	}{
+		{"по полям", config.SyncStrategyField, "Бета (здесь)", 1, "Бета (там)"},
+		{"последнее изменение", config.SyncStrategyLWW, "Бета (там)", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := newTestStore(t, "source")
+			dst := newTestStore(t, "target")
+
+			srcID, err := src.CreateProject(models.Project{Name: "Бета", Path: "/srv/beta"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			syncTo(t, src, dst, tt.strategy)
+			projects, err := dst.ListProjects()
+			if err != nil || len(projects) != 1 {
+				t.Fatalf("проекты после первой синхронизации: %v, %v", projects, err)
+			}
+			dstProject := projects[0]
+
+			// Поле меняется на обоих узлах; изменение источника позже
+			dstProject.Name = "Бета (здесь)"
+			if err := dst.UpdateProject(dstProject); err != nil {
+				t.Fatal(err)
+			}
+			srcProject, err := src.GetProject(srcID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			srcProject.Name = "Бета (там)"
+			if err := src.UpdateProject(*srcProject); err != nil {
+				t.Fatal(err)
+			}
+
+			result := syncTo(t, src, dst, tt.strategy)
+			if result.Conflicts != 1 {
+				t.Errorf("конфликтов %d, ожидался 1", result.Conflicts)
+			}
+			if p, _ := dst.GetProject(dstProject.ID); p.Name != tt.wantName {
+				t.Errorf("название = %q, ожидалось %q", p.Name, tt.wantName)
+			}
+
+			open, err := dst.ListConflicts(false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(open) != tt.wantOpen {
+				t.Fatalf("открытых конфликтов %d, ожидалось %d", len(open), tt.wantOpen)
+			}
+			if tt.wantOpen == 0 {
+				return
+			}
+			if err := dst.ResolveConflict(open[0].ID, true); err != nil {
+				t.Fatal(err)
+			}
+			if p, _ := dst.GetProject(dstProject.ID); p.Name != tt.resolveTaken {
+				t.Errorf("название после решения = %q, ожидалось %q", p.Name, tt.resolveTaken)
+			}
+		})
+	}
+}
+
+func TestSyncImportIsIdempotent(t *testing.T) {
+	src := newTestStore(t, "source")
+	dst := newTestStore(t, "target")
+	if _, err := src.CreateSnippet(models.Snippet{Title: "hello", Language: "go"}); err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := src.SyncNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs, err := src.ExportChanges(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := node.Key.Public().(ed25519.PublicKey)
+	if _, err := dst.ImportChanges(cs, key, config.SyncStrategyField, true); err != nil {
+		t.Fatal(err)
+	}
+	result, err := dst.ImportChanges(cs, key, config.SyncStrategyField, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Applied != 0 || result.Skipped != len(cs.Changes) {
+		t.Errorf("повторная загрузка: %+v", result)
+	}
+
+	// Ключ узла не меняется, пакет с другим ключом не принимается
+	_, other, _ := ed25519.GenerateKey(nil)
+	if _, err := dst.ImportChanges(cs, other.Public().(ed25519.PublicKey), config.SyncStrategyField, true); err == nil {
+		t.Error("пакет с чужим ключом принят")
+	}
+}
+
+func TestSyncPurgeClearsSubordinates(t *testing.T) {
+	src := newTestStore(t, "source")
+	dst := newTestStore(t, "target")
+
+	srcID, err := src.CreateEmployee(models.Employee{FirstName: "Анна", LastName: "Руководитель", Email: "boss@b.c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncTo(t, src, dst, config.SyncStrategyField)
+
+	// Подчиненный заведен только в целевой БД и о нем источник не знает
+	managerID := employeeEmails(t, dst)["boss@b.c"].ID
+	localID, err := dst.CreateEmployee(models.Employee{FirstName: "Иван", LastName: "Подчиненный", ManagerID: &managerID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := src.DeleteEmployee(srcID); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.Purge(EntityEmployee, srcID); err != nil {
+		t.Fatal(err)
+	}
+	syncTo(t, src, dst, config.SyncStrategyField)
+
+	if _, ok := employeeEmails(t, dst)["boss@b.c"]; ok {
+		t.Error("руководитель не удален")
+	}
+	e, err := dst.GetEmployee(localID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.ManagerID != nil {
+		t.Errorf("у подчиненного остался руководитель %d", *e.ManagerID)
+	}
+	report, err := dst.CheckIntegrity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Errorf("проверка целостности нашла проблемы: %+v", report.Findings)
+	}
+}
+
+func TestSyncCreateWithTakenUniqueValue(t *testing.T) {
+	projects := func(t *testing.T, s *Store) map[string]int64 {
+		list, err := s.ListProjects()
+		if err != nil {
+			t.Fatal(err)
+		}
+		byPath := make(map[string]int64)
+		for _, p := range list {
+			byPath[p.Path] = p.ID
+		}
+		return byPath
+	}
+	employees := func(t *testing.T, s *Store) map[string]int64 {
+		byEmail := make(map[string]int64)
+		for email, e := range employeeEmails(t, s) {
+			byEmail[email] = e.ID
+		}
+		return byEmail
+	}
+
+	tests := []struct {
+		name   string
+		create func(s *Store, key string) (int64, error)
+		update func(s *Store, id int64, key string) error
+		list   func(t *testing.T, s *Store) map[string]int64
+		want   string // значение новой записи в целевой БД
+	}{
+		{"путь проекта",
+			func(s *Store, key string) (int64, error) {
+				return s.CreateProject(models.Project{Name: key, Path: key})
+			},
+			func(s *Store, id int64, key string) error {
+				p, err := s.GetProject(id)
+				if err != nil {
+					return err
+				}
+				p.Path = key
+				return s.UpdateProject(*p)
+			},
+			projects, "/srv/b (2)"},
+		{"email сотрудника",
+			func(s *Store, key string) (int64, error) {
+				return s.CreateEmployee(models.Employee{FirstName: key, LastName: key, Email: key})
+			},
+			func(s *Store, id int64, key string) error {
+				e, err := s.GetEmployee(id)
+				if err != nil {
+					return err
+				}
+				e.Email = key
+				return s.UpdateEmployee(*e)
+			},
+			employees, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := newTestStore(t, "source")
+			dst := newTestStore(t, "target")
+
+			srcID, err := tt.create(src, "/srv/a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			syncTo(t, src, dst, config.SyncStrategyField)
+
+			// Конфликт оставляет здесь значение /srv/b, а источник заводит
+			// новую запись с этим же значением
+			if err := tt.update(dst, tt.list(t, dst)["/srv/a"], "/srv/b"); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.update(src, srcID, "/srv/c"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tt.create(src, "/srv/b"); err != nil {
+				t.Fatal(err)
+			}
+
+			result := syncTo(t, src, dst, config.SyncStrategyField)
+			if result.Conflicts != 2 {
+				t.Errorf("конфликтов %d, ожидалось 2", result.Conflicts)
+			}
+			got := tt.list(t, dst)
+			if len(got) != 2 || got["/srv/b"] == 0 {
+				t.Fatalf("записи после синхронизации: %v", got)
+			}
+			if _, ok := got[tt.want]; !ok {
+				t.Errorf("новая запись не получила значение %q: %v", tt.want, got)
+			}
+
+			open, err := dst.ListConflicts(false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var taken *SyncConflict
+			for i := range open {
+				if open[i].EntityID == got[tt.want] {
+					taken = &open[i]
+				}
+			}
+			if taken == nil || taken.Remote != "/srv/b" {
+				t.Errorf("конфликт новой записи не записан: %+v", open)
+			}
+		})
+	}
+}
+
+func TestSyncNodeReadOnly(t *testing.T) {
+	s := newTestStore(t, "readonly")
+	node, err := s.SyncNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := s.db.GetPath()
+	s.db.Close()
+
+	m, err := database.NewManager(path, "test-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetAccessOptions(database.AccessOptions{ReadOnly: true})
+	if err := m.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	ro := New(m, "test")
+
+	got, err := ro.SyncNode()
+	if err != nil {
+		t.Fatalf("SyncNode() только для чтения: %v", err)
+	}
+	if got.ID != node.ID {
+		t.Errorf("узел %s, ожидался %s", got.ID, node.ID)
+	}
+	if _, err := ro.SyncStatus(); err != nil {
+		t.Errorf("SyncStatus() только для чтения: %v", err)
+	}
+	if _, err := ro.ExportChanges(true); err != nil {
+		t.Errorf("ExportChanges() только для чтения: %v", err)
+	}
+}
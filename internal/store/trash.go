@@ -0,0 +1,263 @@
+package store
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+)
+
+// entityTables - таблицы сущностей, поддерживающих корзину
+var entityTables = map[string]string{
+	EntityProject:  "projects",
+	EntityEmployee: "employees",
+	EntitySnippet:  "snippets",
+}
+
+// TrashItem - запись в корзине
+type TrashItem struct {
+	Entity    string
+	ID        int64
+	Title     string
+	DeletedAt time.Time
+}
+
+// getState загружает запись любой сущности для журнала изменений
+func getState(q querier, entity string, id int64) (interface{}, error) {
+	switch entity {
+	case EntityProject:
+		return getProject(q, id)
+	case EntityEmployee:
+		return getEmployee(q, id)
+	case EntitySnippet:
+		return getSnippet(q, id)
+	}
+	return nil, i18n.Errorf("неизвестный тип записи %q", entity)
+}
+
+// softDelete помечает запись удаленной
+func (s *Store) softDelete(entity string, id int64) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		before, err := getState(tx, entity, id)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.Exec("UPDATE "+entityTables[entity]+" SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL",
+			time.Now(), id)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return i18n.Errorf("запись уже находится в корзине")
+		}
+
+		after, err := getState(tx, entity, id)
+		if err != nil {
+			return err
+		}
+		return s.audit(tx, entity, id, ActionDelete, before, after)
+	})
+}
+
+// Restore восстанавливает запись из корзины
+func (s *Store) Restore(entity string, id int64) error {
+	table, ok := entityTables[entity]
+	if !ok {
+		return i18n.Errorf("неизвестный тип записи %q", entity)
+	}
+
+	return s.inTx(func(tx *sql.Tx) error {
+		before, err := getState(tx, entity, id)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.Exec("UPDATE "+table+" SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL", id)
+		if err != nil {
+			return i18n.Errorf("не удалось восстановить запись: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return i18n.Errorf("запись не находится в корзине")
+		}
+
+		after, err := getState(tx, entity, id)
+		if err != nil {
+			return err
+		}
+		return s.audit(tx, entity, id, ActionRestore, before, after)
+	})
+}
+
+// Purge окончательно удаляет запись из корзины вместе с зависимыми данными
+func (s *Store) Purge(entity string, id int64) error {
+	table, ok := entityTables[entity]
+	if !ok {
+		return i18n.Errorf("неизвестный тип записи %q", entity)
+	}
+
+	return s.inTx(func(tx *sql.Tx) error {
+		before, err := getState(tx, entity, id)
+		if err != nil {
+			return err
+		}
+
+		var inTrash bool
+		if err := tx.QueryRow("SELECT deleted_at IS NOT NULL FROM "+table+" WHERE id = ?", id).Scan(&inTrash); err != nil {
+			return err
+		}
+		if !inTrash {
+			return i18n.Errorf("запись не находится в корзине")
+		}
+
+		if err := s.purgeRecord(tx, entity, id); err != nil {
+			return err
+		}
+		return s.audit(tx, entity, id, ActionPurge, before, nil)
+	})
+}
+
+// checkTrashed возвращает понятную ошибку, если путь проекта или email
+// сотрудника value занят записью в корзине: иначе SQLite сообщит только о
+// нарушении UNIQUE. id - ID изменяемой записи, 0 - новой.
+func checkTrashed(q querier, entity, value string, id int64) error {
+	if value == "" {
+		return nil
+	}
+
+	var query string
+	switch entity {
+	case EntityProject:
+		query = "SELECT COUNT(*) FROM projects WHERE path = ? AND id != ? AND deleted_at IS NOT NULL"
+	case EntityEmployee:
+		query = "SELECT COUNT(*) FROM employees WHERE email = ? AND id != ? AND deleted_at IS NOT NULL"
+	}
+	var count int
+	if err := q.QueryRow(query, value, id).Scan(&count); err != nil || count == 0 {
+		return err
+	}
+
+	if entity == EntityEmployee {
+		return i18n.Errorf("в корзине есть сотрудник с таким email: восстановите его или удалите окончательно")
+	}
+	return i18n.Errorf("в корзине есть проект с таким путем: восстановите его или удалите окончательно")
+}
+
+// purgeRecord удаляет запись вместе с зависимыми данными, а у подчиненных
+// удаляемого сотрудника снимает руководителя. Запись в журнал о самом удалении
+// делает вызывающий.
+func (s *Store) purgeRecord(tx *sql.Tx, entity string, id int64) error {
+	switch entity {
+	case EntityProject:
+		if err := purgeProjectData(tx, id); err != nil {
+			return err
+		}
+	case EntityEmployee:
+		if err := s.clearSubordinates(tx, id); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.Exec("DELETE FROM "+entityTables[entity]+" WHERE id = ?", id)
+	return err
+}
+
+// clearSubordinates снимает руководителя managerID у всех его подчиненных,
+// включая находящихся в корзине, чтобы не оставить висячих ссылок
+func (s *Store) clearSubordinates(tx *sql.Tx, managerID int64) error {
+	ids, err := queryIDs(tx, "SELECT id FROM employees WHERE manager_id = ? ORDER BY id", managerID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := s.clearManager(tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeProjectData удаляет файлы проекта, их историю, закладки и настройки.
+// Внешние ключи в SQLite по умолчанию не проверяются, поэтому ON DELETE CASCADE
+// не срабатывает и зависимые строки удаляются явно.
+func purgeProjectData(tx *sql.Tx, projectID int64) error {
+	queries := []string{
+		"DELETE FROM file_history WHERE file_id IN (SELECT id FROM files WHERE project_id = ?)",
+		"DELETE FROM bookmarks WHERE file_id IN (SELECT id FROM files WHERE project_id = ?)",
+		"DELETE FROM files WHERE project_id = ?",
+		"DELETE FROM project_settings WHERE project_id = ?",
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(query, projectID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListTrash возвращает записи всех типов, находящиеся в корзине, начиная с последних удаленных
+func (s *Store) ListTrash() ([]TrashItem, error) {
+	rows, err := s.DB().Query(`
+		SELECT 'project', id, name, deleted_at FROM projects WHERE deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'employee', id, last_name || ' ' || first_name, deleted_at FROM employees WHERE deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'snippet', id, title, deleted_at FROM snippets WHERE deleted_at IS NOT NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []TrashItem
+	for rows.Next() {
+		var item TrashItem
+		if err := rows.Scan(&item.Entity, &item.ID, &item.Title, &item.DeletedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Сортируем в Go: время хранится строкой с часовым поясом и не сравнивается в SQL
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+// PurgeExpired окончательно удаляет записи, пролежавшие в корзине дольше retention.
+// Возвращает число удаленных записей.
+func (s *Store) PurgeExpired(retention time.Duration) (int, error) {
+	if retention <= 0 {
+		return 0, nil
+	}
+
+	items, err := s.ListTrash()
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	deadline := time.Now().Add(-retention)
+	for _, item := range items {
+		if item.DeletedAt.After(deadline) {
+			continue
+		}
+		if err := s.Purge(item.Entity, item.ID); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// nullTime преобразует sql.NullTime в указатель
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
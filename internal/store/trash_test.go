@@ -0,0 +1,147 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/pkg/models"
+)
+
+func TestPurgeManagerClearsSubordinates(t *testing.T) {
+	tests := []struct {
+		name  string
+		purge func(s *Store, id int64) error
+		kept  int // сколько подчиненных остается после удаления
+	}{
+		{"удаление из корзины", func(s *Store, id int64) error {
+			return s.Purge(EntityEmployee, id)
+		}, 2},
+		// Срок хранения истекает у всей корзины, и подчиненный из нее
+		// удаляется вместе с руководителем
+		{"очистка по сроку хранения", func(s *Store, id int64) error {
+			_, err := s.PurgeExpired(time.Nanosecond)
+			return err
+		}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t, "purge")
+
+			managerID, err := s.CreateEmployee(models.Employee{FirstName: "Анна", LastName: "Руководитель"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var subordinates []int64
+			for _, name := range []string{"Иван", "Петр"} {
+				id, err := s.CreateEmployee(models.Employee{FirstName: name, LastName: "Подчиненный", ManagerID: &managerID})
+				if err != nil {
+					t.Fatal(err)
+				}
+				subordinates = append(subordinates, id)
+			}
+			// Подчиненный в корзине тоже не должен ссылаться на удаленного
+			if err := s.DeleteEmployee(subordinates[1]); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.DeleteEmployee(managerID); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(time.Millisecond)
+
+			if err := tt.purge(s, managerID); err != nil {
+				t.Fatal(err)
+			}
+
+			report, err := s.CheckIntegrity()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !report.OK() {
+				t.Errorf("проверка целостности нашла проблемы: %+v", report.Findings)
+			}
+
+			for _, id := range subordinates[:tt.kept] {
+				e, err := s.GetEmployee(id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if e.ManagerID != nil {
+					t.Errorf("сотрудник %d: руководитель %d не снят", id, *e.ManagerID)
+				}
+				history, err := s.History(EntityEmployee, id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(history) == 0 || history[0].Action != ActionUpdate {
+					t.Errorf("сотрудник %d: снятие руководителя не записано в журнал", id)
+				}
+			}
+		})
+	}
+}
+
+func TestUniqueValueInTrash(t *testing.T) {
+	tests := []struct {
+		name   string
+		entity string
+		create func(s *Store, key string) (int64, error)
+		update func(s *Store, id int64, key string) error
+		want   string
+	}{
+		{"путь проекта", EntityProject,
+			func(s *Store, key string) (int64, error) {
+				return s.CreateProject(models.Project{Name: key, Path: key})
+			},
+			func(s *Store, id int64, key string) error {
+				p, err := s.GetProject(id)
+				if err != nil {
+					return err
+				}
+				p.Path = key
+				return s.UpdateProject(*p)
+			},
+			"в корзине есть проект с таким путем: восстановите его или удалите окончательно"},
+		{"email сотрудника", EntityEmployee,
+			func(s *Store, key string) (int64, error) {
+				return s.CreateEmployee(models.Employee{FirstName: key, LastName: key, Email: key})
+			},
+			func(s *Store, id int64, key string) error {
+				e, err := s.GetEmployee(id)
+				if err != nil {
+					return err
+				}
+				e.Email = key
+				return s.UpdateEmployee(*e)
+			},
+			"в корзине есть сотрудник с таким email: восстановите его или удалите окончательно"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t, "unique")
+
+			trashed, err := tt.create(s, "a@b.c")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.softDelete(tt.entity, trashed); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tt.create(s, "a@b.c"); err == nil || err.Error() != tt.want {
+				t.Errorf("создание: ошибка %v, ожидалась %q", err, tt.want)
+			}
+			id, err := tt.create(s, "d@e.f")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.update(s, id, "a@b.c"); err == nil || err.Error() != tt.want {
+				t.Errorf("изменение: ошибка %v, ожидалась %q", err, tt.want)
+			}
+
+			// Значение осталось за записью в корзине
+			if err := s.Restore(tt.entity, trashed); err != nil {
+				t.Errorf("восстановление: %v", err)
+			}
+		})
+	}
+}
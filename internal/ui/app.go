@@ -2,8 +2,17 @@ package ui
 
 import (
 	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
+	"github.com/deldim-kam/Jotnal/internal/backup"
 	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/deldim-kam/Jotnal/internal/database"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/secret"
+	"github.com/deldim-kam/Jotnal/internal/store"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -12,164 +21,464 @@ import (
 type App struct {
 	tviewApp      *tview.Application
 	pages         *tview.Pages
-	db            *sql.DB
+	dbManager     *database.Manager
 	configManager *config.Manager
-
-	// Экраны
+	secrets       *secret.Store
+	store         *store.Store
+
+	// Статус бар и фоновое резервное копирование
+	statusBar   *tview.TextView
+	statusSeq   atomic.Uint64 // порядковый номер последнего поставленного в очередь статуса
+	statusShown uint64        // номер показанного статуса (только в горутине UI)
+	backups     *backup.Scheduler
+
+	// Тема интерфейса и клавиши действий (только в горутине UI)
+	theme theme
+	keys  keyBindings
+
+	// Автоблокировка после простоя
+	lockScreen   *LockScreen
+	lastActivity atomic.Int64 // время последнего ввода, UnixNano
+	locked       atomic.Bool
+	idleTimeout  atomic.Int64 // 0 - автоблокировка отключена
+
+	// Экраны; currentScreen - имя открытого экрана (только в горутине UI)
+	currentScreen   string
 	projectsScreen  *ProjectsScreen
 	employeesScreen *EmployeesScreen
 	snippetsScreen  *SnippetsScreen
 	settingsScreen  *SettingsScreen
+	auditScreen     *AuditScreen
+	trashScreen     *TrashScreen
+	maintenance     *MaintenanceScreen
+	syncScreen      *SyncScreen
+
+	// Палитра команд (только в горутине UI): функции действий экранов,
+	// открытие экрана по имени и недавние выборы, последний первым
+	// (сохраняются между запусками, см. loadRecent)
+	actions    map[string]map[string]func()
+	openScreen func(name string) bool
+	recent     []string
 }
 
 // NewApp создает новый экземпляр приложения
-func NewApp(db *sql.DB, configManager *config.Manager) *App {
+func NewApp(dbManager *database.Manager, configManager *config.Manager, secrets *secret.Store) *App {
+	// Тема устанавливается до создания примитивов: они берут цвета из tview.Styles
+	palette := resolveTheme(configManager.Get().Interface)
+	palette.install()
+
 	app := &App{
 		tviewApp:      tview.NewApplication(),
 		pages:         tview.NewPages(),
-		db:            db,
+		dbManager:     dbManager,
 		configManager: configManager,
+		secrets:       secrets,
+		store:         store.New(dbManager, ""),
+		theme:         palette,
+		actions:       make(map[string]map[string]func()),
 	}
 
+	app.backups = backup.NewScheduler(dbManager, configManager.Get().Backup, app.onBackupEvent)
+	keys, keysErr := loadKeyBindings(configManager.Get().Interface.KeyBindings)
+	app.keys = keys
+	app.loadRecent()
+
 	// Инициализируем экраны
-	app.projectsScreen = NewProjectsScreen(app)
-	app.employeesScreen = NewEmployeesScreen(app)
-	app.snippetsScreen = NewSnippetsScreen(app)
-	app.settingsScreen = NewSettingsScreen(app)
+	app.createScreens()
+	app.lockScreen = NewLockScreen(app)
+	app.SetIdleTimeout(time.Duration(configManager.Get().Security.IdleLockMinutes) * time.Minute)
 
 	// Создаем главное окно
-	mainWindow := app.createMainWindow()
+	mainWindow := app.createMainWindow("")
 	app.pages.AddPage("main", mainWindow, true, true)
+	if keysErr != nil {
+		app.setStatus(keysStatus(keysErr))
+	}
 
 	return app
 }
 
-// createMainWindow создает главное окно с навигацией
-func (a *App) createMainWindow() tview.Primitive {
+// screen - экран, открываемый из бокового меню
+type screen interface {
+	GetView() tview.Primitive
+	Refresh()
+}
+
+// createScreens создает экраны главного окна
+func (a *App) createScreens() {
+	a.projectsScreen = NewProjectsScreen(a)
+	a.employeesScreen = NewEmployeesScreen(a)
+	a.snippetsScreen = NewSnippetsScreen(a)
+	a.settingsScreen = NewSettingsScreen(a)
+	a.auditScreen = NewAuditScreen(a)
+	a.trashScreen = NewTrashScreen(a)
+	a.maintenance = NewMaintenanceScreen(a)
+	a.syncScreen = NewSyncScreen(a)
+}
+
+// rebuild заново создает экраны и главное окно, чтобы тексты интерфейса
+// сменили язык, и снова открывает экран, который был открыт. Диалоги поверх
+// главного окна остаются на прежнем языке до закрытия. Должен вызываться из
+// горутины UI.
+func (a *App) rebuild() {
+	open := a.currentScreen
+	a.createScreens()
+	mainWindow := a.createMainWindow(open)
+	focused := a.tviewApp.GetFocus() // createMainWindow переводит фокус на открытый экран
+	a.pages.RemovePage("main")
+	a.pages.AddPage("main", mainWindow, true, true)
+	a.pages.SendToBack("main")
+
+	// Если поверх есть диалог, фокус возвращается ему
+	if front, _ := a.pages.GetFrontPage(); front != "main" {
+		a.tviewApp.SetFocus(a.pages)
+	} else if open != "" {
+		a.tviewApp.SetFocus(focused)
+	}
+	a.keepLockOnTop()
+}
+
+// createMainWindow создает главное окно с навигацией и открывает экран open
+// (пустое имя - приветствие)
+func (a *App) createMainWindow(open string) tview.Primitive {
 	// Боковое меню
 	menu := tview.NewList().
 		ShowSecondaryText(false).
 		SetHighlightFullLine(true)
 
 	menu.SetBorder(true).
-		SetTitle(" Меню ").
+		SetTitle(i18n.T(" Меню ")).
 		SetTitleAlign(tview.AlignLeft)
 
 	// Область контента
 	content := tview.NewFlex().SetDirection(tview.FlexRow)
 	content.SetBorder(true).
-		SetTitle(" Добро пожаловать в Jotnal ").
+		SetTitle(i18n.T(" Добро пожаловать в Jotnal ")).
 		SetTitleAlign(tview.AlignCenter)
 
 	// Текущий активный экран
-	currentScreen := "welcome"
+	a.currentScreen = "welcome"
 
 	// Функция для переключения экрана
 	switchScreen := func(screenName string, screen tview.Primitive, title string) {
-		if currentScreen != screenName {
+		if a.currentScreen != screenName {
 			content.Clear()
 			content.AddItem(screen, 0, 1, true)
 			content.SetTitle(" " + title + " ")
-			currentScreen = screenName
+			a.currentScreen = screenName
 			a.tviewApp.SetFocus(screen)
 		}
 	}
 
-	// Добавляем пункты меню
-	menu.AddItem("📊 Проекты", "", '1', func() {
-		switchScreen("projects", a.projectsScreen.GetView(), "Управление проектами")
-		a.projectsScreen.Refresh()
-	})
-
-	menu.AddItem("👥 Сотрудники", "", '2', func() {
-		switchScreen("employees", a.employeesScreen.GetView(), "Управление сотрудниками")
-		a.employeesScreen.Refresh()
-	})
-
-	menu.AddItem("📝 Сниппеты", "", '3', func() {
-		switchScreen("snippets", a.snippetsScreen.GetView(), "Библиотека сниппетов кода")
-		a.snippetsScreen.Refresh()
-	})
-
-	menu.AddItem("⚙️  Настройки", "", '4', func() {
-		switchScreen("settings", a.settingsScreen.GetView(), "Настройки приложения")
-		a.settingsScreen.Refresh()
-	})
+	// Добавляем пункты меню; клавиша пункта - клавиша действия экрана main
+	items := []struct {
+		name, label, title string
+		screen             screen
+	}{
+		{"projects", "📊 Проекты", "Управление проектами", a.projectsScreen},
+		{"employees", "👥 Сотрудники", "Управление сотрудниками", a.employeesScreen},
+		{"snippets", "📝 Сниппеты", "Библиотека сниппетов кода", a.snippetsScreen},
+		{"settings", "⚙️  Настройки", "Настройки приложения", a.settingsScreen},
+		{"audit", "🧾 Журнал изменений", "Журнал изменений", a.auditScreen},
+		{"trash", "🗑  Корзина", "Корзина", a.trashScreen},
+		{"maintenance", "🛠  Обслуживание БД", "Обслуживание БД", a.maintenance},
+		{"sync", "🔄 Синхронизация", "Синхронизация", a.syncScreen},
+	}
+	for _, item := range items {
+		menu.AddItem(i18n.T(item.label), "", a.menuShortcut(item.name), func() {
+			switchScreen(item.name, item.screen.GetView(), i18n.T(item.title))
+			item.screen.Refresh()
+		})
+	}
 
 	menu.AddItem("", "", 0, nil) // Разделитель
 
-	menu.AddItem("❌ Выход", "", 'q', func() {
+	menu.AddItem(i18n.T("❌ Выход"), "", a.menuShortcut("quit"), func() {
 		a.tviewApp.Stop()
 	})
 
 	// Приветственное сообщение
 	welcomeText := tview.NewTextView().
 		SetTextAlign(tview.AlignCenter).
-		SetText("\n\n\n" +
-			"╔═══════════════════════════════════════╗\n" +
-			"║                                       ║\n" +
-			"║         Jotnal IDE v1.0               ║\n" +
-			"║                                       ║\n" +
-			"║    Система управления проектами       ║\n" +
-			"║      и сотрудниками                   ║\n" +
-			"║                                       ║\n" +
-			"╚═══════════════════════════════════════╝\n\n\n" +
-			"Используйте цифры 1-4 для навигации\n" +
-			"или выберите пункт из меню слева\n\n" +
-			"Нажмите 'q' для выхода")
+		SetText(i18n.T("\n\n\n"+
+			"╔═══════════════════════════════════════╗\n"+
+			"║                                       ║\n"+
+			"║         Jotnal IDE v1.0               ║\n"+
+			"║                                       ║\n"+
+			"║    Система управления проектами       ║\n"+
+			"║      и сотрудниками                   ║\n"+
+			"║                                       ║\n"+
+			"╚═══════════════════════════════════════╝\n\n\n"+
+			"Используйте клавиши в скобках для навигации\n"+
+			"или выберите пункт из меню слева\n\n") +
+			i18n.Sprintf("%s - поиск команд и записей\n\n", a.key("main", "palette")) +
+			i18n.Sprintf("Нажмите '%s' для выхода", a.key("main", "quit")))
 
 	content.AddItem(welcomeText, 0, 1, false)
 
 	// Статус бар внизу
-	statusBar := tview.NewTextView().
+	a.statusBar = tview.NewTextView().
 		SetDynamicColors(true).
 		SetTextAlign(tview.AlignCenter)
+	a.setStatus("")
+
+	// Экран открывается так же, как выбором пункта меню
+	a.openScreen = func(name string) bool {
+		for i, item := range items {
+			if item.name == name {
+				menu.SetCurrentItem(i)
+				menu.GetItemSelectedFunc(i)()
+				return true
+			}
+		}
+		return false
+	}
 
-	cfg := a.configManager.Get()
-	statusBar.SetText("[yellow]База данных:[white] " + cfg.Database.Path + " [yellow]| Тема:[white] " + cfg.Interface.Theme + " [yellow]| Язык:[white] " + cfg.Interface.Language)
+	// Повторно открываем экран, который был открыт до пересоздания окна
+	a.openScreen(open)
 
 	// Главный layout
 	mainLayout := tview.NewFlex().
 		AddItem(menu, 25, 0, true).
 		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
 			AddItem(content, 0, 1, false).
-			AddItem(statusBar, 1, 0, false), 0, 1, false)
+			AddItem(a.statusBar, 1, 0, false), 0, 1, false)
 
-	// Глобальные горячие клавиши
+	// Глобальные горячие клавиши: клавиша пункта меню выбирает его
+	handlers := map[string]func(){
+		"palette":     a.ShowPalette,
+		"open_shift":  a.OpenShift,
+		"close_shift": a.CloseShift,
+	}
+	for i, item := range items {
+		handlers[item.name] = func() { menu.SetCurrentItem(i) }
+	}
+	menuKeys := a.keyCapture("main", handlers)
 	mainLayout.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Rune() {
-		case 'q':
-			if currentScreen == "welcome" {
-				a.tviewApp.Stop()
-				return nil
-			}
-		case '1':
-			menu.SetCurrentItem(0)
-			return nil
-		case '2':
-			menu.SetCurrentItem(1)
-			return nil
-		case '3':
-			menu.SetCurrentItem(2)
-			return nil
-		case '4':
-			menu.SetCurrentItem(3)
+		if a.currentScreen == "welcome" && a.pressed(event, "main", "quit") {
+			a.tviewApp.Stop()
 			return nil
 		}
-		return event
+		return menuKeys(event)
 	})
 
 	return mainLayout
 }
 
+// menuShortcut возвращает клавишу пункта меню действия action экрана main.
+// Список показывает только клавиши-символы, остальные клавиши пункта
+// работают без подсказки в меню.
+func (a *App) menuShortcut(action string) rune {
+	key := a.key("main", action)
+	if r, size := utf8.DecodeRuneInString(key); size > 0 && size == len(key) {
+		return r
+	}
+	return 0
+}
+
 // Run запускает приложение
 func (a *App) Run() error {
-	return a.tviewApp.SetRoot(a.pages, true).EnableMouse(true).Run()
+	if err := a.backups.Start(); err != nil {
+		a.setStatus(i18n.Sprintf("[error]Резервное копирование отключено:[-] %v", err))
+	}
+
+	// В режиме только для чтения корзина не очищается
+	if !a.dbManager.ReadOnly() {
+		go a.purgeTrash()
+	}
+	go a.checkDBSize()
+
+	// Любой ввод сбрасывает таймер простоя; пока интерфейс заблокирован,
+	// мышь работает только внутри формы разблокировки
+	a.lastActivity.Store(time.Now().UnixNano())
+	a.tviewApp.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		a.lastActivity.Store(time.Now().UnixNano())
+		return event
+	})
+	a.tviewApp.SetMouseCapture(func(event *tcell.EventMouse, action tview.MouseAction) (*tcell.EventMouse, tview.MouseAction) {
+		if a.locked.Load() && !a.lockScreen.contains(event.Position()) {
+			return nil, action
+		}
+		if action != tview.MouseMove {
+			a.lastActivity.Store(time.Now().UnixNano())
+		}
+		return event, action
+	})
+
+	stop := make(chan struct{})
+	go a.watchIdle(stop)
+	go a.watchChanges(stop)
+	go a.watchConfig(stop)
+
+	err := a.tviewApp.SetRoot(a.pages, true).EnableMouse(true).Run()
+	close(stop)
+
+	// Событийный цикл уже остановлен, поэтому копию при выходе делаем синхронно
+	a.backups.Stop()
+	if cfg := a.configManager.Get().Backup; cfg.Enabled && cfg.OnExit {
+		fmt.Println(i18n.T("Создание резервной копии..."))
+		if path, berr := a.backups.RunNow(backup.ReasonExit); berr != nil {
+			i18n.Printf("Ошибка резервного копирования: %v\n", berr)
+		} else {
+			i18n.Printf("✓ Резервная копия сохранена: %s\n", path)
+		}
+	}
+
+	return err
+}
+
+// SetIdleTimeout задает время простоя до автоблокировки (0 - отключить)
+func (a *App) SetIdleTimeout(timeout time.Duration) {
+	a.idleTimeout.Store(int64(timeout))
+}
+
+// Lock закрывает интерфейс экраном блокировки. Должен вызываться из горутины UI.
+func (a *App) Lock() {
+	a.locked.Store(true)
+	a.lockScreen.Lock()
+}
+
+// watchIdle периодически проверяет простой и блокирует интерфейс по таймауту
+func (a *App) watchIdle(stop <-chan struct{}) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			timeout := time.Duration(a.idleTimeout.Load())
+			if timeout <= 0 || a.locked.Load() {
+				continue
+			}
+
+			idle := time.Since(time.Unix(0, a.lastActivity.Load()))
+			if idle >= timeout {
+				// Флаг ставится сразу, чтобы не запросить блокировку повторно
+				a.locked.Store(true)
+				go a.tviewApp.QueueUpdateDraw(a.Lock)
+			}
+		}
+	}
 }
 
 // GetDB возвращает соединение с БД
 func (a *App) GetDB() *sql.DB {
-	return a.db
+	return a.dbManager.GetDB()
+}
+
+// GetDBManager возвращает менеджер БД
+func (a *App) GetDBManager() *database.Manager {
+	return a.dbManager
+}
+
+// GetStore возвращает хранилище записей с журналом изменений
+func (a *App) GetStore() *store.Store {
+	return a.store
+}
+
+// GetSecretStore возвращает хранилище пароля БД
+func (a *App) GetSecretStore() *secret.Store {
+	return a.secrets
+}
+
+// VerifySecret проверяет секрет, которым пользователь открывает БД: парольную
+// фразу ключевого файла в режиме keyfile или пароль БД в остальных режимах
+func (a *App) VerifySecret(input string) bool {
+	if a.secrets.Mode() == config.PasswordModeKeyFile {
+		return a.secrets.VerifyPassphrase(input)
+	}
+	return a.dbManager.VerifyPassword(input)
+}
+
+// secretLabel возвращает название текущего секрета для полей ввода
+func (a *App) secretLabel() string {
+	if a.secrets.Mode() == config.PasswordModeKeyFile {
+		return i18n.T("Парольная фраза")
+	}
+	return i18n.T("Текущий пароль")
+}
+
+// BackupNow запускает внеплановое резервное копирование в фоне
+func (a *App) BackupNow() {
+	a.backups.RunAsync(backup.ReasonManual)
+}
+
+// OpenShift открывает смену от имени текущего пользователя
+func (a *App) OpenShift() {
+	shift, err := a.store.OpenShift()
+	if err != nil {
+		a.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось открыть смену: ")+err.Error(), 50, 10, nil)
+		return
+	}
+	a.setStatus(i18n.Sprintf("[accent]Смена #%d открыта[-] (%s)", shift.ID, i18n.DateTime(shift.OpenedAt.Local())))
+}
+
+// CloseShift закрывает открытую смену и, если это включено в настройках,
+// создает резервную копию в фоне
+func (a *App) CloseShift() {
+	shift, err := a.store.CloseShift()
+	if err != nil {
+		a.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось закрыть смену: ")+err.Error(), 50, 10, nil)
+		return
+	}
+	a.setStatus(i18n.Sprintf("[accent]Смена #%d закрыта[-] (%s)", shift.ID, i18n.DateTime(shift.ClosedAt.Local())))
+	a.backups.Trigger(backup.ReasonShiftClose)
+}
+
+// setStatus выводит сообщение в статус бар после постоянной информации.
+// Должен вызываться из горутины UI.
+func (a *App) setStatus(message string) {
+	cfg := a.configManager.Get()
+	text := i18n.T("[label]База данных:[-] ") + cfg.Database.Path
+	if a.dbManager.ReadOnly() {
+		text += i18n.T(" [error](только чтение)[-]")
+	}
+	text += i18n.T(" [label]| Тема:[-] ") + cfg.Interface.Theme + i18n.T(" [label]| Язык:[-] ") + cfg.Interface.Language
+	if message != "" {
+		text += " [label]|[-] " + message
+	}
+	a.statusBar.SetText(text)
+}
+
+// queueStatus выводит сообщение в статус бар из любой горутины.
+// Обновление передается через QueueUpdateDraw в отдельной горутине, чтобы не
+// блокировать вызывающего, если событийный цикл занят или уже остановлен.
+// Порядковый номер отбрасывает сообщения, доставленные позже более новых.
+func (a *App) queueStatus(message string) {
+	seq := a.statusSeq.Add(1)
+	go a.tviewApp.QueueUpdateDraw(func() {
+		if seq < a.statusShown {
+			return
+		}
+		a.statusShown = seq
+		a.setStatus(message)
+	})
+}
+
+// onBackupEvent отображает ход резервного копирования в статус баре
+func (a *App) onBackupEvent(ev backup.Event) {
+	switch {
+	case !ev.Done:
+		a.queueStatus(i18n.Sprintf("[label]Резервное копирование (%s)...[-]", ev.Reason))
+	case ev.Err != nil:
+		a.queueStatus(i18n.Sprintf("[error]Ошибка резервного копирования:[-] %v", ev.Err))
+	default:
+		a.queueStatus(i18n.Sprintf("[accent]Копия %s создана[-] (%s, %.1f с)",
+			ev.Started.Format("15:04"), ev.Reason, ev.Duration.Seconds()))
+	}
+}
+
+// purgeTrash окончательно удаляет записи, срок хранения которых в корзине истек
+func (a *App) purgeTrash() {
+	purged, err := a.store.PurgeExpired(a.configManager.Get().Trash.Retention())
+	switch {
+	case err != nil:
+		a.queueStatus(i18n.Sprintf("[error]Ошибка очистки корзины:[-] %v", err))
+	case purged > 0:
+		a.queueStatus(i18n.Sprintf("[accent]Из корзины удалено записей: %d[-]", purged))
+	}
 }
 
 // GetConfigManager возвращает менеджер конфигурации
@@ -192,13 +501,14 @@ func (a *App) ShowModal(title, message string, width, height int, doneFunc func(
 	modal.SetTitle(" " + title + " ").SetBorder(true)
 
 	a.pages.AddPage("modal", modal, true, true)
+	a.keepLockOnTop()
 }
 
 // ShowConfirm показывает диалог подтверждения
 func (a *App) ShowConfirm(title, message string, yesFunc, noFunc func()) {
 	modal := tview.NewModal().
 		SetText(message).
-		AddButtons([]string{"Да", "Нет"}).
+		AddButtons([]string{i18n.T("Да"), i18n.T("Нет")}).
 		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
 			a.pages.RemovePage("confirm")
 			if buttonIndex == 0 && yesFunc != nil {
@@ -211,4 +521,13 @@ func (a *App) ShowConfirm(title, message string, yesFunc, noFunc func()) {
 	modal.SetTitle(" " + title + " ").SetBorder(true)
 
 	a.pages.AddPage("confirm", modal, true, true)
+	a.keepLockOnTop()
+}
+
+// keepLockOnTop возвращает экран блокировки поверх окон, открытых во время блокировки
+func (a *App) keepLockOnTop() {
+	if a.locked.Load() && a.pages.HasPage("lock") {
+		a.pages.SendToFront("lock")
+		a.tviewApp.SetFocus(a.lockScreen.form)
+	}
 }
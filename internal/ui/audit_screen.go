@@ -0,0 +1,256 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/store"
+	"github.com/deldim-kam/Jotnal/pkg/models"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// auditLimit - сколько последних записей журнала показывает экран
+const auditLimit = 500
+
+// auditEntities - порядок переключения фильтра по сущностям
+var auditEntities = []string{"", store.EntityProject, store.EntityEmployee, store.EntitySnippet}
+
+// entityNames - названия сущностей для отображения; переводятся при выводе
+var entityNames = map[string]string{
+	"":                   "Все",
+	store.EntityProject:  "Проект",
+	store.EntityEmployee: "Сотрудник",
+	store.EntitySnippet:  "Сниппет",
+}
+
+// actionNames - названия действий для отображения; переводятся при выводе
+var actionNames = map[string]string{
+	store.ActionCreate:  "[accent]создание[-]",
+	store.ActionUpdate:  "[label]изменение[-]",
+	store.ActionDelete:  "[error]в корзину[-]",
+	store.ActionRestore: "[accent]восстановление[-]",
+	store.ActionPurge:   "[error]удаление навсегда[-]",
+}
+
+// fieldNames - названия полей записей для отображения различий; переводятся
+// при выводе
+var fieldNames = map[string]string{
+	"id":          "ID",
+	"name":        "Название",
+	"path":        "Путь",
+	"description": "Описание",
+	"first_name":  "Имя",
+	"last_name":   "Фамилия",
+	"middle_name": "Отчество",
+	"email":       "Email",
+	"position":    "Должность",
+	"department":  "Отдел",
+	"manager_id":  "Руководитель (ID)",
+	"phone":       "Телефон",
+	"hire_date":   "Дата найма",
+	"title":       "Название",
+	"language":    "Язык",
+	"code":        "Код",
+	"tags":        "Теги",
+	"created_at":  "Создан",
+	"deleted_at":  "В корзине с",
+}
+
+// AuditScreen экран журнала изменений
+type AuditScreen struct {
+	app     *App
+	view    *tview.Flex
+	table   *tview.Table
+	info    *tview.TextView
+	entries []models.AuditEntry
+	filter  int // индекс в auditEntities
+}
+
+// NewAuditScreen создает экран журнала изменений
+func NewAuditScreen(app *App) *AuditScreen {
+	s := &AuditScreen{
+		app:   app,
+		table: tview.NewTable().SetBorders(false).SetSelectable(true, false).SetFixed(1, 0),
+		info:  tview.NewTextView().SetDynamicColors(true),
+	}
+
+	s.table.SetBorder(true).
+		SetTitle(i18n.T(" Журнал изменений ")).
+		SetTitleAlign(tview.AlignLeft)
+
+	s.info.SetBorder(true).
+		SetTitle(i18n.T(" Информация ")).
+		SetTitleAlign(tview.AlignLeft)
+
+	s.view = tview.NewFlex().
+		AddItem(s.table, 0, 3, true).
+		AddItem(s.info, 40, 0, false)
+
+	s.table.SetInputCapture(app.keyCapture("audit", map[string]func(){
+		"details": s.showEntry,
+		"filter": func() {
+			s.filter = (s.filter + 1) % len(auditEntities)
+			s.Refresh()
+		},
+		"refresh": s.Refresh,
+	}))
+
+	return s
+}
+
+// setupTable настраивает заголовки таблицы
+func (s *AuditScreen) setupTable() {
+	headers := []string{i18n.T("Время"), i18n.T("Пользователь"), i18n.T("Объект"), "ID", i18n.T("Действие")}
+	for i, header := range headers {
+		cell := tview.NewTableCell(header).
+			SetTextColor(tview.Styles.SecondaryTextColor).
+			SetAlign(tview.AlignCenter).
+			SetSelectable(false).
+			SetAttributes(tcell.AttrBold)
+		s.table.SetCell(0, i, cell)
+	}
+}
+
+// Refresh перечитывает журнал
+func (s *AuditScreen) Refresh() {
+	selection := saveSelection(s.table, s.rowKey)
+
+	s.table.Clear()
+	s.setupTable()
+
+	entity := auditEntities[s.filter]
+	s.info.SetText(i18n.T("\n  [label]Горячие клавиши:[-]\n\n") +
+		s.app.keyHelp("audit", map[string]string{"filter": i18n.T(entityNames[entity])}) + "\n" +
+		i18n.Plural(auditLimit, "  Показаны последние %d записей\n", auditLimit))
+
+	entries, err := s.app.GetStore().ListAudit(store.AuditFilter{Entity: entity, Limit: auditLimit})
+	if err != nil {
+		s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось загрузить журнал: ")+err.Error(), 50, 10, nil)
+		return
+	}
+	s.entries = entries
+
+	for i, e := range entries {
+		row := i + 1
+		s.table.SetCell(row, 0, tview.NewTableCell(i18n.Timestamp(e.CreatedAt.Local())))
+		s.table.SetCell(row, 1, tview.NewTableCell(e.Actor))
+		s.table.SetCell(row, 2, tview.NewTableCell(i18n.T(entityNames[e.Entity])))
+		s.table.SetCell(row, 3, tview.NewTableCell(fmt.Sprintf("%d", e.EntityID)).SetAlign(tview.AlignCenter))
+		s.table.SetCell(row, 4, tview.NewTableCell(i18n.T(actionNames[e.Action])))
+	}
+
+	selection.restore(s.table, len(entries), s.rowKey)
+}
+
+// rowKey возвращает ID записи журнала в строке таблицы
+func (s *AuditScreen) rowKey(row int) string {
+	if row < 1 || row > len(s.entries) {
+		return ""
+	}
+	return strconv.FormatInt(s.entries[row-1].ID, 10)
+}
+
+// showEntry показывает изменения выбранной записи журнала
+func (s *AuditScreen) showEntry() {
+	row, _ := s.table.GetSelection()
+	if row == 0 || row > len(s.entries) {
+		return
+	}
+
+	entry := s.entries[row-1]
+	title := fmt.Sprintf("%s #%d", i18n.T(entityNames[entry.Entity]), entry.EntityID)
+	s.app.showText(title, formatAuditEntry(entry), 80, 24)
+}
+
+// GetView возвращает view экрана
+func (s *AuditScreen) GetView() tview.Primitive {
+	return s.view
+}
+
+// ShowHistory показывает историю изменений одной записи с различиями по полям
+func (a *App) ShowHistory(entity string, id int64, title string) {
+	entries, err := a.store.History(entity, id)
+	if err != nil {
+		a.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось загрузить историю: ")+err.Error(), 50, 10, nil)
+		return
+	}
+
+	if len(entries) == 0 {
+		a.ShowModal(i18n.T("История"), i18n.T("Изменений записи не найдено"), 40, 8, nil)
+		return
+	}
+
+	var text strings.Builder
+	for _, entry := range entries {
+		text.WriteString(formatAuditEntry(entry))
+		text.WriteString("\n")
+	}
+
+	a.showText(title, text.String(), 80, 24)
+}
+
+// showText показывает прокручиваемый текст; любая клавиша, кроме прокрутки, закрывает окно
+func (a *App) showText(title, text string, width, height int) {
+	textView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetText(text)
+	textView.SetBorder(true).SetTitle(" " + title + " ")
+
+	textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn, tcell.KeyHome, tcell.KeyEnd:
+			return event
+		}
+		a.pages.RemovePage("text")
+		return nil
+	})
+
+	a.pages.AddPage("text", center(textView, width, height), true, true)
+	a.keepLockOnTop()
+}
+
+// formatAuditEntry форматирует запись журнала с различиями по полям
+func formatAuditEntry(entry models.AuditEntry) string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "\n[label]%s[-]  %s  %s\n",
+		i18n.Timestamp(entry.CreatedAt.Local()), entry.Actor, i18n.T(actionNames[entry.Action]))
+
+	changes, err := store.Diff(entry)
+	if err != nil {
+		fmt.Fprintf(&text, "  [error]%s[-]\n", tview.Escape(err.Error()))
+		return text.String()
+	}
+
+	for _, change := range changes {
+		name := i18n.T(fieldNames[change.Field])
+		if name == "" {
+			name = change.Field
+		}
+
+		switch entry.Action {
+		case store.ActionCreate:
+			fmt.Fprintf(&text, "  %s: [accent]%s[-]\n", name, displayValue(change.New))
+		case store.ActionPurge:
+			fmt.Fprintf(&text, "  %s: [error]%s[-]\n", name, displayValue(change.Old))
+		default:
+			fmt.Fprintf(&text, "  %s: [error]%s[-] → [accent]%s[-]\n",
+				name, displayValue(change.Old), displayValue(change.New))
+		}
+	}
+
+	return text.String()
+}
+
+// displayValue подготавливает значение поля к выводу: даты показываются
+// в местном времени, разметка tview экранируется
+func displayValue(value string) string {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return i18n.Timestamp(t.Local())
+	}
+	return tview.Escape(value)
+}
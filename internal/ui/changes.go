@@ -0,0 +1,137 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/store"
+	"github.com/rivo/tview"
+)
+
+// changesInterval - как часто проверяются изменения, сделанные другими экземплярами
+const changesInterval = 2 * time.Second
+
+// screenEntities - какие сущности показывает экран; nil - все
+var screenEntities = map[string][]string{
+	"projects":  {store.EntityProject},
+	"employees": {store.EntityEmployee},
+	"snippets":  {store.EntitySnippet},
+	"audit":     nil,
+	"trash":     nil,
+	"sync":      {store.EntitySync},
+}
+
+// watchChanges следит за счетчиками изменений в БД и обновляет открытый экран,
+// если его данные изменились, в том числе в другом запущенном экземпляре
+func (a *App) watchChanges(stop <-chan struct{}) {
+	seen, _ := a.store.Changes()
+
+	ticker := time.NewTicker(changesInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			// Пока интерфейс заблокирован, изменения копятся и применяются после разблокировки
+			if a.locked.Load() {
+				continue
+			}
+
+			// Ошибка чтения (например, БД занята сменой ключа) - проверим в следующий раз
+			current, err := a.store.Changes()
+			if err != nil {
+				continue
+			}
+
+			changed := current.Changed(seen)
+			if len(changed) == 0 {
+				continue
+			}
+			seen = current
+
+			go a.tviewApp.QueueUpdateDraw(func() {
+				a.refreshChanged(changed)
+			})
+		}
+	}
+}
+
+// refreshChanged перечитывает открытый экран, если он показывает измененные сущности.
+// Должен вызываться из горутины UI.
+func (a *App) refreshChanged(entities []string) {
+	shown, ok := screenEntities[a.currentScreen]
+	if !ok || !containsAny(shown, entities) {
+		return
+	}
+
+	switch a.currentScreen {
+	case "projects":
+		a.projectsScreen.Refresh()
+	case "employees":
+		a.employeesScreen.Refresh()
+	case "snippets":
+		a.snippetsScreen.Refresh()
+	case "audit":
+		a.auditScreen.Refresh()
+	case "trash":
+		a.trashScreen.Refresh()
+	case "sync":
+		a.syncScreen.Refresh()
+	}
+}
+
+// containsAny проверяет, есть ли в shown хотя бы одна из entities; пустой shown - все
+func containsAny(shown, entities []string) bool {
+	if shown == nil {
+		return true
+	}
+	for _, s := range shown {
+		for _, e := range entities {
+			if s == e {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tableSelection - выбранная строка таблицы, восстанавливаемая после обновления
+type tableSelection struct {
+	key string
+	row int
+}
+
+// saveSelection запоминает выбранную строку таблицы и ее ключ
+func saveSelection(table *tview.Table, keyOf func(row int) string) tableSelection {
+	row, _ := table.GetSelection()
+	if row < 1 {
+		return tableSelection{}
+	}
+	return tableSelection{key: keyOf(row), row: row}
+}
+
+// restore выбирает строку с тем же ключом; если ее больше нет - строку на той же
+// позиции, а без сохраненного выбора - первую строку. rows - число строк данных.
+func (sel tableSelection) restore(table *tview.Table, rows int, keyOf func(row int) string) {
+	if rows == 0 {
+		return
+	}
+	if sel.key != "" {
+		for row := 1; row <= rows; row++ {
+			if keyOf(row) == sel.key {
+				table.Select(row, 0)
+				return
+			}
+		}
+	}
+
+	row := sel.row
+	if row < 1 {
+		row = 1
+	}
+	if row > rows {
+		row = rows
+	}
+	table.Select(row, 0)
+}
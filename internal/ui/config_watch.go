@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+)
+
+// configInterval - как часто проверяется, не изменился ли config.json
+const configInterval = 2 * time.Second
+
+// watchConfig следит за config.json и применяет его изменения без перезапуска.
+// Файл сравнивается по времени изменения и размеру; собственные сохранения
+// тоже перечитываются, это безвредно.
+func (a *App) watchConfig(stop <-chan struct{}) {
+	path := a.configManager.Path()
+	seen, _ := os.Stat(path)
+
+	ticker := time.NewTicker(configInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			// Файла может не быть, пока редактор его заменяет - проверим в следующий раз
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			if seen != nil && info.ModTime().Equal(seen.ModTime()) && info.Size() == seen.Size() {
+				continue
+			}
+			seen = info
+
+			go a.tviewApp.QueueUpdateDraw(a.reloadConfig)
+		}
+	}
+}
+
+// reloadConfig перечитывает config.json и применяет его. Неверный файл
+// (например, недописанный в редакторе) не применяется: действующие настройки
+// остаются, ошибка выводится в статус бар. Должен вызываться из горутины UI.
+func (a *App) reloadConfig() {
+	if err := a.configManager.Reload(); err != nil {
+		a.setStatus(i18n.Sprintf("[error]config.json не применен:[-] %s", strings.Join(strings.Fields(err.Error()), " ")))
+		return
+	}
+	a.applyConfig()
+}
+
+// applyConfig применяет параметры, которые действуют без перезапуска: тему,
+// язык, клавиши, автоблокировку и резервное копирование. Путь к БД и параметры
+// доступа к ней вступают в силу после перезапуска. Должен вызываться из
+// горутины UI.
+func (a *App) applyConfig() {
+	cfg := a.configManager.Get()
+	a.applyTheme(cfg.Interface)
+	// Неверные клавиши не применяются: остаются действующие
+	keys, keysErr := loadKeyBindings(cfg.Interface.KeyBindings)
+	if keysErr != nil {
+		keys = a.keys
+	}
+	// Тему applyTheme применяет к уже созданным экранам, а подписи меню и
+	// справка по клавишам строятся при создании экранов, поэтому после смены
+	// языка или клавиш экраны пересоздаются
+	if cfg.Interface.Language != i18n.Language() || !reflect.DeepEqual(keys, a.keys) {
+		i18n.SetLanguage(cfg.Interface.Language)
+		a.keys = keys
+		a.rebuild()
+	}
+	a.SetIdleTimeout(time.Duration(cfg.Security.IdleLockMinutes) * time.Minute)
+
+	if err := a.backups.Reconfigure(cfg.Backup); err != nil {
+		a.setStatus(i18n.Sprintf("[error]Резервное копирование отключено:[-] %v", err))
+		return
+	}
+	if keysErr != nil {
+		a.setStatus(keysStatus(keysErr))
+		return
+	}
+	a.setStatus("")
+}
+
+// keysStatus возвращает сообщение статус бара о неверных клавишах в config.json
+func keysStatus(err error) string {
+	return i18n.Sprintf("[error]Клавиши из config.json не применены:[-] %s", strings.Join(strings.Fields(err.Error()), " "))
+}
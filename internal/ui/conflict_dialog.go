@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/store"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// editField связывает поле формы редактирования с полем модели
+type editField[T any] struct {
+	label string // переводится при выводе
+	value func(*T) *string
+}
+
+// conflictField - значения одного поля при конфликте редактирования
+type conflictField struct {
+	label  string
+	base   string // значение, с которого начиналось редактирование
+	mine   string // значение, сохраняемое пользователем
+	theirs string // значение, сохраненное другим пользователем
+}
+
+// collectConflict собирает поля, которые изменил хотя бы один из пользователей
+func collectConflict[T any](fields []editField[T], base, mine, theirs T) []conflictField {
+	var result []conflictField
+	for _, f := range fields {
+		c := conflictField{
+			label:  f.label,
+			base:   *f.value(&base),
+			mine:   *f.value(&mine),
+			theirs: *f.value(&theirs),
+		}
+		if c.mine != c.base || c.theirs != c.base {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// mergeEdits объединяет изменения: поля, измененные пользователем, берутся из
+// его версии, остальные - из текущей версии в БД
+func mergeEdits[T any](fields []editField[T], base, mine, theirs T) T {
+	merged := theirs
+	for _, f := range fields {
+		if *f.value(&mine) != *f.value(&base) {
+			*f.value(&merged) = *f.value(&mine)
+		}
+	}
+	return merged
+}
+
+// showConflict показывает конфликт одновременного редактирования и предлагает
+// перезаписать чужие изменения, объединить их со своими или отменить сохранение
+func (a *App) showConflict(cerr *store.ConflictError, fields []conflictField, overwrite, merge func()) {
+	if cerr.Deleted {
+		a.ShowModal(i18n.T("Конфликт"), i18n.T("Изменения не сохранены: ")+cerr.Error(), 60, 10, nil)
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(i18n.T("\n [label]Запись изменена после того, как вы открыли ее для редактирования[-]\n"))
+	if cerr.Actor != "" {
+		i18n.Fprintf(&text, " Изменил: %s, %s\n", tview.Escape(cerr.Actor), i18n.Timestamp(cerr.ChangedAt.Local()))
+	}
+	text.WriteString("\n")
+
+	for _, f := range fields {
+		mineChanged, theirsChanged := f.mine != f.base, f.theirs != f.base
+		marker := "  "
+		if mineChanged && theirsChanged && f.mine != f.theirs {
+			// Оба изменили поле по-разному - при объединении останется ваше значение
+			marker = "[error]![-] "
+		}
+
+		fmt.Fprintf(&text, " %s[label]%s[-]\n", marker, i18n.T(f.label))
+		i18n.Fprintf(&text, "     ваше:    %s\n", conflictValue(f.mine, mineChanged))
+		i18n.Fprintf(&text, "     текущее: %s\n", conflictValue(f.theirs, theirsChanged))
+	}
+
+	text.WriteString(i18n.T("\n [error]![-] - поле изменено обоими; при объединении сохранится ваше значение\n"))
+
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetText(text.String())
+
+	closeDialog := func() {
+		a.pages.RemovePage("conflict")
+	}
+
+	buttons := tview.NewForm().
+		AddButton(i18n.T("Перезаписать"), func() {
+			closeDialog()
+			overwrite()
+		}).
+		AddButton(i18n.T("Объединить"), func() {
+			closeDialog()
+			merge()
+		}).
+		AddButton(i18n.T("Отмена"), closeDialog).
+		SetButtonsAlign(tview.AlignCenter)
+	buttons.SetCancelFunc(closeDialog)
+
+	// Стрелки прокручивают список полей, Tab переходит к кнопкам
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyTab {
+			a.tviewApp.SetFocus(buttons)
+			return nil
+		}
+		if event.Key() == tcell.KeyEscape {
+			closeDialog()
+			return nil
+		}
+		return event
+	})
+
+	dialog := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(view, 0, 1, false).
+		AddItem(buttons, 3, 0, true)
+	dialog.SetBorder(true).SetTitle(i18n.T(" Конфликт редактирования "))
+
+	a.pages.AddPage("conflict", center(dialog, 80, 24), true, true)
+	a.keepLockOnTop()
+}
+
+// conflictValue форматирует значение поля в диалоге конфликта
+func conflictValue(value string, changed bool) string {
+	shown := tview.Escape(strings.ReplaceAll(value, "\n", "⏎"))
+	if shown == "" {
+		shown = i18n.T("[muted](пусто)[-]")
+	}
+	if changed {
+		return "[accent]" + shown + "[-]"
+	}
+	return shown
+}
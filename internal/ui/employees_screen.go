@@ -1,9 +1,11 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
-	"time"
 
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/store"
 	"github.com/deldim-kam/Jotnal/pkg/models"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -26,17 +28,12 @@ func NewEmployeesScreen(app *App) *EmployeesScreen {
 	}
 
 	s.table.SetBorder(true).
-		SetTitle(" Список сотрудников ").
+		SetTitle(i18n.T(" Список сотрудников ")).
 		SetTitleAlign(tview.AlignLeft)
 
-	s.info.SetText("\n  [yellow]Горячие клавиши:[white]\n\n" +
-		"  [green]a[white] - Добавить сотрудника\n" +
-		"  [green]e[white] - Редактировать\n" +
-		"  [green]d[white] - Удалить\n" +
-		"  [green]Enter[white] - Просмотр деталей\n" +
-		"  [green]r[white] - Обновить список\n")
+	s.info.SetText(i18n.T("\n  [label]Горячие клавиши:[-]\n\n") + app.keyHelp("employees", nil))
 	s.info.SetBorder(true).
-		SetTitle(" Информация ").
+		SetTitle(i18n.T(" Информация ")).
 		SetTitleAlign(tview.AlignLeft)
 
 	s.setupTable()
@@ -45,38 +42,23 @@ func NewEmployeesScreen(app *App) *EmployeesScreen {
 		AddItem(s.table, 0, 3, true).
 		AddItem(s.info, 40, 0, false)
 
-	s.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Rune() {
-		case 'a':
-			s.addEmployee()
-			return nil
-		case 'e':
-			s.editEmployee()
-			return nil
-		case 'd':
-			s.deleteEmployee()
-			return nil
-		case 'r':
-			s.Refresh()
-			return nil
-		}
-
-		if event.Key() == tcell.KeyEnter {
-			s.showDetails()
-			return nil
-		}
-
-		return event
-	})
+	s.table.SetInputCapture(app.keyCapture("employees", map[string]func(){
+		"add":     s.addEmployee,
+		"edit":    s.editEmployee,
+		"delete":  s.deleteEmployee,
+		"details": s.showDetails,
+		"history": s.showHistory,
+		"refresh": s.Refresh,
+	}))
 
 	return s
 }
 
 func (s *EmployeesScreen) setupTable() {
-	headers := []string{"ID", "Фамилия", "Имя", "Email", "Должность", "Отдел", "Дата найма"}
+	headers := []string{"ID", i18n.T("Фамилия"), i18n.T("Имя"), "Email", i18n.T("Должность"), i18n.T("Отдел"), i18n.T("Дата найма")}
 	for i, header := range headers {
 		cell := tview.NewTableCell(header).
-			SetTextColor(tcell.ColorYellow).
+			SetTextColor(tview.Styles.SecondaryTextColor).
 			SetAlign(tview.AlignCenter).
 			SetSelectable(false).
 			SetAttributes(tcell.AttrBold)
@@ -85,12 +67,14 @@ func (s *EmployeesScreen) setupTable() {
 }
 
 func (s *EmployeesScreen) Refresh() {
+	selection := saveSelection(s.table, s.rowKey)
+
 	s.table.Clear()
 	s.setupTable()
 
 	employees, err := s.loadEmployees()
 	if err != nil {
-		s.app.ShowModal("Ошибка", "Не удалось загрузить сотрудников: "+err.Error(), 50, 10, nil)
+		s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось загрузить сотрудников: ")+err.Error(), 50, 10, nil)
 		return
 	}
 
@@ -102,92 +86,80 @@ func (s *EmployeesScreen) Refresh() {
 		s.table.SetCell(row, 3, tview.NewTableCell(emp.Email))
 		s.table.SetCell(row, 4, tview.NewTableCell(emp.Position))
 		s.table.SetCell(row, 5, tview.NewTableCell(emp.Department))
-		s.table.SetCell(row, 6, tview.NewTableCell(emp.HireDate.Format("2006-01-02")))
+		s.table.SetCell(row, 6, tview.NewTableCell(i18n.Date(emp.HireDate)))
 	}
 
-	if len(employees) > 0 {
-		s.table.Select(1, 0)
-	}
+	selection.restore(s.table, len(employees), s.rowKey)
 }
 
-func (s *EmployeesScreen) loadEmployees() ([]models.Employee, error) {
-	query := `SELECT id, first_name, last_name, middle_name, email, position,
-			  department, manager_id, phone, hire_date, created_at, updated_at
-			  FROM employees ORDER BY last_name, first_name`
+// rowKey возвращает ID сотрудника в строке таблицы
+func (s *EmployeesScreen) rowKey(row int) string {
+	return s.table.GetCell(row, 0).Text
+}
 
-	rows, err := s.app.GetDB().Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var employees []models.Employee
-	for rows.Next() {
-		var e models.Employee
-		err := rows.Scan(&e.ID, &e.FirstName, &e.LastName, &e.MiddleName, &e.Email,
-			&e.Position, &e.Department, &e.ManagerID, &e.Phone, &e.HireDate,
-			&e.CreatedAt, &e.UpdatedAt)
-		if err != nil {
-			return nil, err
-		}
-		employees = append(employees, e)
-	}
+// selectEmployee выбирает строку сотрудника с ID id
+func (s *EmployeesScreen) selectEmployee(id int64) {
+	tableSelection{key: fmt.Sprintf("%d", id)}.restore(s.table, s.table.GetRowCount()-1, s.rowKey)
+}
 
-	return employees, nil
+func (s *EmployeesScreen) loadEmployees() ([]models.Employee, error) {
+	return s.app.GetStore().ListEmployees()
 }
 
 func (s *EmployeesScreen) addEmployee() {
 	form := tview.NewForm()
-	form.SetBorder(true).SetTitle(" Новый сотрудник ").SetTitleAlign(tview.AlignLeft)
+	form.SetBorder(true).SetTitle(i18n.T(" Новый сотрудник ")).SetTitleAlign(tview.AlignLeft)
 
 	var lastName, firstName, middleName, email, position, department, phone string
 
-	form.AddInputField("Фамилия:*", "", 30, nil, func(text string) {
+	form.AddInputField(i18n.T("Фамилия:*"), "", 30, nil, func(text string) {
 		lastName = text
 	})
-	form.AddInputField("Имя:*", "", 30, nil, func(text string) {
+	form.AddInputField(i18n.T("Имя:*"), "", 30, nil, func(text string) {
 		firstName = text
 	})
-	form.AddInputField("Отчество:", "", 30, nil, func(text string) {
+	form.AddInputField(i18n.T("Отчество:"), "", 30, nil, func(text string) {
 		middleName = text
 	})
 	form.AddInputField("Email:", "", 40, nil, func(text string) {
 		email = text
 	})
-	form.AddInputField("Должность:*", "", 40, nil, func(text string) {
+	form.AddInputField(i18n.T("Должность:*"), "", 40, nil, func(text string) {
 		position = text
 	})
-	form.AddInputField("Отдел:", "", 40, nil, func(text string) {
+	form.AddInputField(i18n.T("Отдел:"), "", 40, nil, func(text string) {
 		department = text
 	})
-	form.AddInputField("Телефон:", "", 20, nil, func(text string) {
+	form.AddInputField(i18n.T("Телефон:"), "", 20, nil, func(text string) {
 		phone = text
 	})
 
-	form.AddButton("Сохранить", func() {
+	form.AddButton(i18n.T("Сохранить"), func() {
 		if lastName == "" || firstName == "" || position == "" {
-			s.app.ShowModal("Ошибка", "Фамилия, имя и должность обязательны", 50, 10, nil)
+			s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Фамилия, имя и должность обязательны"), 50, 10, nil)
 			return
 		}
 
-		_, err := s.app.GetDB().Exec(
-			`INSERT INTO employees (first_name, last_name, middle_name, email, position,
-			 department, phone, hire_date, created_at, updated_at)
-			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-			firstName, lastName, middleName, email, position, department, phone,
-			time.Now(), time.Now(), time.Now(),
-		)
+		_, err := s.app.GetStore().CreateEmployee(models.Employee{
+			FirstName:  firstName,
+			LastName:   lastName,
+			MiddleName: middleName,
+			Email:      email,
+			Position:   position,
+			Department: department,
+			Phone:      phone,
+		})
 		if err != nil {
-			s.app.ShowModal("Ошибка", "Не удалось создать сотрудника: "+err.Error(), 50, 10, nil)
+			s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось создать сотрудника: ")+err.Error(), 50, 10, nil)
 			return
 		}
 
 		s.app.pages.RemovePage("form")
 		s.Refresh()
-		s.app.ShowModal("Успех", "Сотрудник успешно добавлен!", 40, 8, nil)
+		s.app.ShowModal(i18n.T("Успех"), i18n.T("Сотрудник успешно добавлен!"), 40, 8, nil)
 	})
 
-	form.AddButton("Отмена", func() {
+	form.AddButton(i18n.T("Отмена"), func() {
 		s.app.pages.RemovePage("form")
 	})
 
@@ -204,73 +176,104 @@ func (s *EmployeesScreen) editEmployee() {
 	var empID int64
 	fmt.Sscanf(idCell.Text, "%d", &empID)
 
-	var e models.Employee
-	err := s.app.GetDB().QueryRow(
-		`SELECT id, first_name, last_name, middle_name, email, position,
-		 department, phone FROM employees WHERE id = ?`,
-		empID,
-	).Scan(&e.ID, &e.FirstName, &e.LastName, &e.MiddleName, &e.Email,
-		&e.Position, &e.Department, &e.Phone)
-
+	e, err := s.app.GetStore().GetEmployee(empID)
 	if err != nil {
-		s.app.ShowModal("Ошибка", "Не удалось загрузить сотрудника: "+err.Error(), 50, 10, nil)
+		s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось загрузить сотрудника: ")+err.Error(), 50, 10, nil)
 		return
 	}
 
+	s.showEditForm(*e, *e)
+}
+
+// employeeFields - редактируемые поля сотрудника для разрешения конфликтов
+var employeeFields = []editField[models.Employee]{
+	{"Фамилия", func(e *models.Employee) *string { return &e.LastName }},
+	{"Имя", func(e *models.Employee) *string { return &e.FirstName }},
+	{"Отчество", func(e *models.Employee) *string { return &e.MiddleName }},
+	{"Email", func(e *models.Employee) *string { return &e.Email }},
+	{"Должность", func(e *models.Employee) *string { return &e.Position }},
+	{"Отдел", func(e *models.Employee) *string { return &e.Department }},
+	{"Телефон", func(e *models.Employee) *string { return &e.Phone }},
+}
+
+// showEditForm открывает форму редактирования: orig - загруженная версия записи,
+// values - значения полей формы (после объединения они отличаются от orig)
+func (s *EmployeesScreen) showEditForm(orig, values models.Employee) {
 	form := tview.NewForm()
-	form.SetBorder(true).SetTitle(" Редактирование сотрудника ").SetTitleAlign(tview.AlignLeft)
+	form.SetBorder(true).SetTitle(i18n.T(" Редактирование сотрудника ")).SetTitleAlign(tview.AlignLeft)
 
 	var lastName, firstName, middleName, email, position, department, phone string
-	lastName, firstName, middleName = e.LastName, e.FirstName, e.MiddleName
-	email, position, department, phone = e.Email, e.Position, e.Department, e.Phone
+	lastName, firstName, middleName = values.LastName, values.FirstName, values.MiddleName
+	email, position, department, phone = values.Email, values.Position, values.Department, values.Phone
 
-	form.AddInputField("Фамилия:*", lastName, 30, nil, func(text string) {
+	form.AddInputField(i18n.T("Фамилия:*"), lastName, 30, nil, func(text string) {
 		lastName = text
 	})
-	form.AddInputField("Имя:*", firstName, 30, nil, func(text string) {
+	form.AddInputField(i18n.T("Имя:*"), firstName, 30, nil, func(text string) {
 		firstName = text
 	})
-	form.AddInputField("Отчество:", middleName, 30, nil, func(text string) {
+	form.AddInputField(i18n.T("Отчество:"), middleName, 30, nil, func(text string) {
 		middleName = text
 	})
 	form.AddInputField("Email:", email, 40, nil, func(text string) {
 		email = text
 	})
-	form.AddInputField("Должность:*", position, 40, nil, func(text string) {
+	form.AddInputField(i18n.T("Должность:*"), position, 40, nil, func(text string) {
 		position = text
 	})
-	form.AddInputField("Отдел:", department, 40, nil, func(text string) {
+	form.AddInputField(i18n.T("Отдел:"), department, 40, nil, func(text string) {
 		department = text
 	})
-	form.AddInputField("Телефон:", phone, 20, nil, func(text string) {
+	form.AddInputField(i18n.T("Телефон:"), phone, 20, nil, func(text string) {
 		phone = text
 	})
 
-	form.AddButton("Сохранить", func() {
-		_, err := s.app.GetDB().Exec(
-			`UPDATE employees SET first_name = ?, last_name = ?, middle_name = ?,
-			 email = ?, position = ?, department = ?, phone = ?, updated_at = ?
-			 WHERE id = ?`,
-			firstName, lastName, middleName, email, position, department, phone,
-			time.Now(), empID,
-		)
-		if err != nil {
-			s.app.ShowModal("Ошибка", "Не удалось обновить сотрудника: "+err.Error(), 50, 10, nil)
-			return
-		}
+	form.AddButton(i18n.T("Сохранить"), func() {
+		updated := orig
+		updated.FirstName, updated.LastName, updated.MiddleName = firstName, lastName, middleName
+		updated.Email, updated.Position, updated.Department, updated.Phone = email, position, department, phone
 
-		s.app.pages.RemovePage("form")
-		s.Refresh()
-		s.app.ShowModal("Успех", "Сотрудник успешно обновлен!", 40, 8, nil)
+		s.saveEmployee(orig, updated)
 	})
 
-	form.AddButton("Отмена", func() {
+	form.AddButton(i18n.T("Отмена"), func() {
 		s.app.pages.RemovePage("form")
 	})
 
 	s.app.pages.AddPage("form", center(form, 70, 22), true, true)
 }
 
+// saveEmployee сохраняет изменения; если сотрудника успели изменить, предлагает
+// перезаписать, объединить или отменить
+func (s *EmployeesScreen) saveEmployee(orig, updated models.Employee) {
+	err := s.app.GetStore().UpdateEmployee(updated)
+
+	var conflict *store.ConflictError
+	if errors.As(err, &conflict) {
+		current := *conflict.Current.(*models.Employee)
+		s.app.showConflict(conflict, collectConflict(employeeFields, orig, updated, current),
+			func() {
+				// Руководитель и дата найма в форме не редактируются
+				updated.Version = current.Version
+				updated.ManagerID, updated.HireDate = current.ManagerID, current.HireDate
+				s.saveEmployee(current, updated)
+			},
+			func() {
+				s.showEditForm(current, mergeEdits(employeeFields, orig, updated, current))
+			},
+		)
+		return
+	}
+	if err != nil {
+		s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось обновить сотрудника: ")+err.Error(), 50, 10, nil)
+		return
+	}
+
+	s.app.pages.RemovePage("form")
+	s.Refresh()
+	s.app.ShowModal(i18n.T("Успех"), i18n.T("Сотрудник успешно обновлен!"), 40, 8, nil)
+}
+
 func (s *EmployeesScreen) deleteEmployee() {
 	row, _ := s.table.GetSelection()
 	if row == 0 {
@@ -283,16 +286,16 @@ func (s *EmployeesScreen) deleteEmployee() {
 	fmt.Sscanf(idCell.Text, "%d", &empID)
 
 	s.app.ShowConfirm(
-		"Подтверждение удаления",
-		fmt.Sprintf("Вы уверены, что хотите удалить сотрудника '%s'?", nameCell.Text),
+		i18n.T("Подтверждение удаления"),
+		i18n.Sprintf("Переместить сотрудника '%s' в корзину?", nameCell.Text),
 		func() {
-			_, err := s.app.GetDB().Exec("DELETE FROM employees WHERE id = ?", empID)
+			err := s.app.GetStore().DeleteEmployee(empID)
 			if err != nil {
-				s.app.ShowModal("Ошибка", "Не удалось удалить сотрудника: "+err.Error(), 50, 10, nil)
+				s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось удалить сотрудника: ")+err.Error(), 50, 10, nil)
 				return
 			}
 			s.Refresh()
-			s.app.ShowModal("Успех", "Сотрудник удален!", 40, 8, nil)
+			s.app.ShowModal(i18n.T("Успех"), i18n.T("Сотрудник перемещен в корзину"), 40, 8, nil)
 		},
 		nil,
 	)
@@ -308,22 +311,13 @@ func (s *EmployeesScreen) showDetails() {
 	var empID int64
 	fmt.Sscanf(idCell.Text, "%d", &empID)
 
-	var e models.Employee
-	err := s.app.GetDB().QueryRow(
-		`SELECT id, first_name, last_name, middle_name, email, position,
-		 department, manager_id, phone, hire_date, created_at, updated_at
-		 FROM employees WHERE id = ?`,
-		empID,
-	).Scan(&e.ID, &e.FirstName, &e.LastName, &e.MiddleName, &e.Email,
-		&e.Position, &e.Department, &e.ManagerID, &e.Phone, &e.HireDate,
-		&e.CreatedAt, &e.UpdatedAt)
-
+	e, err := s.app.GetStore().GetEmployee(empID)
 	if err != nil {
-		s.app.ShowModal("Ошибка", "Не удалось загрузить сотрудника: "+err.Error(), 50, 10, nil)
+		s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось загрузить сотрудника: ")+err.Error(), 50, 10, nil)
 		return
 	}
 
-	managerName := "Нет"
+	managerName := i18n.T("Нет")
 	if e.ManagerID != nil {
 		var mFirstName, mLastName string
 		s.app.GetDB().QueryRow(
@@ -333,27 +327,27 @@ func (s *EmployeesScreen) showDetails() {
 		managerName = fmt.Sprintf("%s %s", mLastName, mFirstName)
 	}
 
-	details := fmt.Sprintf(
-		"\n[yellow]ID:[white] %d\n\n"+
-			"[yellow]ФИО:[white] %s %s %s\n\n"+
-			"[yellow]Email:[white] %s\n\n"+
-			"[yellow]Должность:[white] %s\n\n"+
-			"[yellow]Отдел:[white] %s\n\n"+
-			"[yellow]Телефон:[white] %s\n\n"+
-			"[yellow]Руководитель:[white] %s\n\n"+
-			"[yellow]Дата найма:[white] %s\n\n"+
-			"[yellow]Создан:[white] %s\n",
+	details := i18n.Sprintf(
+		"\n[label]ID:[-] %d\n\n"+
+			"[label]ФИО:[-] %s %s %s\n\n"+
+			"[label]Email:[-] %s\n\n"+
+			"[label]Должность:[-] %s\n\n"+
+			"[label]Отдел:[-] %s\n\n"+
+			"[label]Телефон:[-] %s\n\n"+
+			"[label]Руководитель:[-] %s\n\n"+
+			"[label]Дата найма:[-] %s\n\n"+
+			"[label]Создан:[-] %s\n",
 		e.ID, e.LastName, e.FirstName, e.MiddleName, e.Email,
 		e.Position, e.Department, e.Phone, managerName,
-		e.HireDate.Format("2006-01-02"),
-		e.CreatedAt.Format("2006-01-02 15:04:05"),
+		i18n.Date(e.HireDate),
+		i18n.Timestamp(e.CreatedAt),
 	)
 
 	textView := tview.NewTextView().
 		SetDynamicColors(true).
 		SetText(details).
 		SetBorder(true).
-		SetTitle(" Детали сотрудника ")
+		SetTitle(i18n.T(" Детали сотрудника "))
 
 	textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		s.app.pages.RemovePage("details")
@@ -363,6 +357,20 @@ func (s *EmployeesScreen) showDetails() {
 	s.app.pages.AddPage("details", center(textView, 70, 22), true, true)
 }
 
+// showHistory показывает историю изменений выбранного сотрудника
+func (s *EmployeesScreen) showHistory() {
+	row, _ := s.table.GetSelection()
+	if row == 0 {
+		return
+	}
+
+	var empID int64
+	fmt.Sscanf(s.table.GetCell(row, 0).Text, "%d", &empID)
+	name := s.table.GetCell(row, 1).Text + " " + s.table.GetCell(row, 2).Text
+
+	s.app.ShowHistory(store.EntityEmployee, empID, i18n.T("История: ")+name)
+}
+
 func (s *EmployeesScreen) GetView() tview.Primitive {
 	return s.view
 }
@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"github.com/deldim-kam/Jotnal/internal/backup"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/store"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// CheckIntegrity проверяет целостность БД в фоне и показывает отчет
+func (a *App) CheckIntegrity() {
+	a.setStatus(i18n.T("[label]Проверка целостности БД...[-]"))
+	go func() {
+		report, err := a.store.CheckIntegrity()
+		a.tviewApp.QueueUpdateDraw(func() {
+			a.setStatus("")
+			if err != nil {
+				a.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось проверить БД: ")+err.Error(), 60, 10, nil)
+				return
+			}
+			a.showIntegrityReport(report, "")
+		})
+	}()
+}
+
+// repairIntegrity создает резервную копию, исправляет проблемы и проверяет БД повторно
+func (a *App) repairIntegrity() {
+	a.setStatus(i18n.T("[label]Резервное копирование перед исправлением...[-]"))
+	go func() {
+		path, err := a.backups.RunNow(backup.ReasonRepair)
+		if err != nil {
+			a.tviewApp.QueueUpdateDraw(func() {
+				a.setStatus("")
+				a.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось создать резервную копию, исправление отменено: ")+err.Error(), 60, 10, nil)
+			})
+			return
+		}
+
+		fixed, err := a.store.Repair()
+		var report *store.IntegrityReport
+		if err == nil {
+			report, err = a.store.CheckIntegrity()
+		}
+
+		a.tviewApp.QueueUpdateDraw(func() {
+			a.setStatus("")
+			if err != nil {
+				a.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось исправить БД: ")+err.Error()+i18n.T("\nРезервная копия: ")+path, 60, 12, nil)
+				return
+			}
+			a.showIntegrityReport(report, i18n.Sprintf("Резервная копия: %s\nИсправлено записей: %d\n\n", path, fixed))
+		})
+	}()
+}
+
+// showIntegrityReport показывает отчет проверки; если есть проблемы, исправимые
+// автоматически, предлагает исправить их
+func (a *App) showIntegrityReport(report *store.IntegrityReport, header string) {
+	view := tview.NewTextView().
+		SetScrollable(true).
+		SetText("\n" + header + report.String())
+
+	closeDialog := func() {
+		a.pages.RemovePage("integrity")
+	}
+
+	buttons := tview.NewForm().SetButtonsAlign(tview.AlignCenter)
+	if report.Fixable() > 0 {
+		buttons.AddButton(i18n.T("Исправить"), func() {
+			closeDialog()
+			a.ShowConfirm(i18n.T("Исправление БД"),
+				i18n.Sprintf("Исправить автоматически проблем: %d? Перед исправлением будет создана резервная копия.", report.Fixable()),
+				a.repairIntegrity, nil)
+		})
+	}
+	buttons.AddButton(i18n.T("Закрыть"), closeDialog)
+	buttons.SetCancelFunc(closeDialog)
+
+	// Стрелки прокручивают отчет, Tab переходит к кнопкам
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyTab:
+			a.tviewApp.SetFocus(buttons)
+			return nil
+		case tcell.KeyEscape:
+			closeDialog()
+			return nil
+		}
+		return event
+	})
+
+	dialog := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(view, 0, 1, false).
+		AddItem(buttons, 3, 0, true)
+	dialog.SetBorder(true).SetTitle(i18n.T(" Проверка целостности БД "))
+
+	a.pages.AddPage("integrity", center(dialog, 90, 26), true, true)
+	a.keepLockOnTop()
+}
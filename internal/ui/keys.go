@@ -0,0 +1,313 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// keyAction - действие экрана, которому назначается клавиша
+type keyAction struct {
+	name  string // имя в interface.key_bindings
+	key   string // клавиша по умолчанию, как ее записывает config.NormalizeKey
+	label string // описание в справке; переводится при выводе
+}
+
+// keyScreen - экран и его действия в порядке справки
+type keyScreen struct {
+	name    string
+	label   string // название в палитре команд; переводится при выводе
+	actions []keyAction
+}
+
+// keyScreens - реестр действий интерфейса. Клавиши экрана main действуют на
+// всех экранах, кроме quit: выход по клавише работает на приветствии.
+// Действие без клавиши по умолчанию вызывается из палитры команд.
+var keyScreens = []keyScreen{
+	{"main", "Меню", []keyAction{
+		{"projects", "1", "Раздел «Проекты»"},
+		{"employees", "2", "Раздел «Сотрудники»"},
+		{"snippets", "3", "Раздел «Сниппеты»"},
+		{"settings", "4", "Раздел «Настройки»"},
+		{"audit", "5", "Раздел «Журнал изменений»"},
+		{"trash", "6", "Раздел «Корзина»"},
+		{"maintenance", "7", "Раздел «Обслуживание БД»"},
+		{"sync", "8", "Раздел «Синхронизация»"},
+		{"palette", "Ctrl+K", "Палитра команд"},
+		{"open_shift", "", "Открыть смену"},
+		{"close_shift", "", "Закрыть смену"},
+		{"quit", "q", "Выход"},
+	}},
+	{"projects", "Проекты", []keyAction{
+		{"add", "a", "Добавить проект"},
+		{"edit", "e", "Редактировать"},
+		{"delete", "d", "Удалить в корзину"},
+		{"details", "Enter", "Просмотр деталей"},
+		{"history", "h", "История изменений"},
+		{"refresh", "r", "Обновить список"},
+	}},
+	{"employees", "Сотрудники", []keyAction{
+		{"add", "a", "Добавить сотрудника"},
+		{"edit", "e", "Редактировать"},
+		{"delete", "d", "Удалить в корзину"},
+		{"details", "Enter", "Просмотр деталей"},
+		{"history", "h", "История изменений"},
+		{"refresh", "r", "Обновить список"},
+	}},
+	{"snippets", "Сниппеты", []keyAction{
+		{"add", "a", "Добавить сниппет"},
+		{"edit", "e", "Редактировать"},
+		{"delete", "d", "Удалить в корзину"},
+		{"refresh", "r", "Обновить список"},
+	}},
+	{"settings", "Настройки", []keyAction{
+		{"password", "Ctrl+D", "Изменить пароль БД"},
+		{"db_path", "Ctrl+P", "Изменить путь к БД"},
+		{"backup", "Ctrl+B", "Создать резервную копию"},
+		{"lock", "Ctrl+L", "Заблокировать интерфейс"},
+		{"integrity", "Ctrl+T", "Проверить целостность БД"},
+	}},
+	{"audit", "Журнал изменений", []keyAction{
+		{"details", "Enter", "Показать изменения"},
+		{"filter", "f", "Фильтр"},
+		{"refresh", "r", "Обновить список"},
+	}},
+	{"trash", "Корзина", []keyAction{
+		{"restore", "u", "Восстановить"},
+		{"purge", "x", "Удалить навсегда"},
+		{"history", "h", "История изменений"},
+		{"refresh", "r", "Обновить список"},
+	}},
+	{"maintenance", "Обслуживание БД", []keyAction{
+		{"vacuum", "v", "VACUUM (сжать файл)"},
+		{"analyze", "a", "ANALYZE (собрать статистику)"},
+		{"optimize", "o", "PRAGMA optimize"},
+		{"refresh", "r", "Обновить"},
+	}},
+	{"sync", "Синхронизация", []keyAction{
+		{"details", "Enter", "Подробности"},
+		{"keep_local", "l", "Оставить значение этой БД"},
+		{"take_remote", "i", "Принять входящее значение"},
+		{"filter", "f", "Показывать"},
+		{"refresh", "r", "Обновить"},
+	}},
+}
+
+// keyBindings - действующие клавиши: экран -> действие -> клавиша
+type keyBindings map[string]map[string]string
+
+// defaultKeyBindings возвращает клавиши по умолчанию из реестра
+func defaultKeyBindings() keyBindings {
+	keys := make(keyBindings, len(keyScreens))
+	for _, screen := range keyScreens {
+		keys[screen.name] = make(map[string]string, len(screen.actions))
+		for _, action := range screen.actions {
+			keys[screen.name][action.name] = action.key
+		}
+	}
+	return keys
+}
+
+// loadKeyBindings возвращает клавиши по умолчанию с заменами из
+// interface.key_bindings. Если замены неверны, возвращаются клавиши по
+// умолчанию и ошибка.
+func loadKeyBindings(overrides map[string]map[string]string) (keyBindings, error) {
+	keys := defaultKeyBindings()
+	if err := CheckKeyBindings(overrides); err != nil {
+		return keys, err
+	}
+	for screen, actions := range overrides {
+		for action, key := range actions {
+			if key != "" {
+				keys[screen][action], _ = config.NormalizeKey(key)
+			}
+		}
+	}
+	return keys, nil
+}
+
+// CheckKeyBindings проверяет interface.key_bindings по реестру клавиш:
+// экраны и действия должны существовать, а одна клавиша не может вызывать
+// два действия на одном экране (с учетом клавиш бокового меню). Запись самих
+// клавиш проверяет config.Validate. Возвращает *config.ValidationError.
+func CheckKeyBindings(overrides map[string]map[string]string) error {
+	var problems []config.Problem
+	add := func(key, format string, args ...interface{}) {
+		problems = append(problems, config.Problem{Key: key, Message: i18n.Sprintf(format, args...)})
+	}
+
+	keys := defaultKeyBindings()
+	for _, screen := range sortedKeys(overrides) {
+		prefix := "interface.key_bindings." + screen
+		if keys[screen] == nil {
+			add(prefix, "неизвестный экран (допустимо: %s)", strings.Join(keyScreenNames(), ", "))
+			continue
+		}
+		for _, action := range sortedKeys(overrides[screen]) {
+			if _, ok := keys[screen][action]; !ok {
+				add(prefix+"."+action, "неизвестное действие (допустимо: %s)", strings.Join(keyActionNames(screen), ", "))
+				continue
+			}
+			if key, err := config.NormalizeKey(overrides[screen][action]); err == nil {
+				keys[screen][action] = key
+			}
+		}
+	}
+
+	for _, screen := range keyScreens {
+		taken := make(map[string]string) // клавиша -> действие
+		if screen.name != "main" {
+			for _, action := range keyScreens[0].actions {
+				if key := keys["main"][action.name]; key != "" && action.name != "quit" {
+					taken[key] = "main." + action.name
+				}
+			}
+		}
+		for _, action := range screen.actions {
+			key := keys[screen.name][action.name]
+			if key == "" {
+				continue
+			}
+			if other, ok := taken[key]; ok {
+				add("interface.key_bindings."+screen.name+"."+action.name, "клавиша %s уже назначена действию %s", key, other)
+				continue
+			}
+			taken[key] = screen.name + "." + action.name
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return &config.ValidationError{Problems: problems}
+}
+
+// keyScreenNames возвращает имена экранов реестра
+func keyScreenNames() []string {
+	names := make([]string, len(keyScreens))
+	for i, screen := range keyScreens {
+		names[i] = screen.name
+	}
+	return names
+}
+
+// keyActionNames возвращает имена действий экрана screen
+func keyActionNames(screen string) []string {
+	var names []string
+	for _, s := range keyScreens {
+		if s.name == screen {
+			for _, action := range s.actions {
+				names = append(names, action.name)
+			}
+		}
+	}
+	return names
+}
+
+// sortedKeys возвращает ключи словаря по алфавиту, чтобы ошибки выводились
+// в одном и том же порядке
+func sortedKeys[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// keyName возвращает имя нажатой клавиши в том виде, в котором его
+// записывает config.NormalizeKey; пустая строка - клавишу нельзя назначить
+func keyName(event *tcell.EventKey) string {
+	mods := event.Modifiers()
+	name := tcell.KeyNames[event.Key()]
+	switch key := event.Key(); {
+	case key == tcell.KeyRune:
+		name = string(event.Rune())
+		mods &^= tcell.ModShift // регистр уже учтен в самом символе
+	case key >= tcell.KeyCtrlA && key <= tcell.KeyCtrlZ:
+		name = string(rune('A' + key - tcell.KeyCtrlA))
+		mods |= tcell.ModCtrl
+	}
+
+	parts := []string{}
+	if mods&tcell.ModCtrl != 0 {
+		parts = append(parts, "Ctrl")
+	}
+	if mods&tcell.ModAlt != 0 {
+		parts = append(parts, "Alt")
+	}
+	if mods&tcell.ModShift != 0 {
+		parts = append(parts, "Shift")
+	}
+	key, err := config.NormalizeKey(strings.Join(append(parts, name), "+"))
+	if err != nil {
+		return ""
+	}
+	return key
+}
+
+// key возвращает клавишу действия action экрана screen
+func (a *App) key(screen, action string) string {
+	return a.keys[screen][action]
+}
+
+// pressed сообщает, что event - клавиша действия action экрана screen.
+// Символ без модификаторов в поле ввода - это текст, а не клавиша действия.
+func (a *App) pressed(event *tcell.EventKey, screen, action string) bool {
+	name := keyName(event)
+	if name == "" || name != a.key(screen, action) {
+		return false
+	}
+	if utf8.RuneCountInString(name) == 1 {
+		switch a.tviewApp.GetFocus().(type) {
+		case *tview.InputField, *tview.TextArea:
+			return false
+		}
+	}
+	return true
+}
+
+// keyCapture возвращает обработчик клавиш экрана screen: клавиша действия
+// вызывает его функцию из handlers, остальные клавиши передаются дальше.
+// Функции действий запоминаются для палитры команд.
+func (a *App) keyCapture(screen string, handlers map[string]func()) func(event *tcell.EventKey) *tcell.EventKey {
+	a.actions[screen] = handlers
+	return func(event *tcell.EventKey) *tcell.EventKey {
+		for action, handler := range handlers {
+			if a.pressed(event, screen, action) {
+				handler()
+				return nil
+			}
+		}
+		return event
+	}
+}
+
+// keyHelp возвращает справку по клавишам экрана screen для панели
+// «Информация». Значение из status дописывается к описанию действия
+// (например, текущий фильтр).
+func (a *App) keyHelp(screen string, status map[string]string) string {
+	var help strings.Builder
+	for _, s := range keyScreens {
+		if s.name != screen {
+			continue
+		}
+		for _, action := range s.actions {
+			if a.key(screen, action.name) == "" {
+				continue
+			}
+			label := i18n.T(action.label)
+			if value, ok := status[action.name]; ok {
+				label += ": " + value
+			}
+			fmt.Fprintf(&help, "  [accent]%s[-] - %s\n", tview.Escape(a.key(screen, action.name)), label)
+		}
+	}
+	return help.String()
+}
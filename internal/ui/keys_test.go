@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/gdamore/tcell/v2"
+)
+
+// problemKeys возвращает параметры, о которых сообщила CheckKeyBindings
+func problemKeys(t *testing.T, err error) []string {
+	t.Helper()
+
+	if err == nil {
+		return nil
+	}
+	var verr *config.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("ошибка %T, ожидалась *config.ValidationError: %v", err, err)
+	}
+	keys := make([]string, len(verr.Problems))
+	for i, p := range verr.Problems {
+		keys[i] = p.Key
+	}
+	return keys
+}
+
+func TestCheckKeyBindings(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string]map[string]string
+		want      []string // параметры с ошибками в порядке вывода
+	}{
+		{"без замен", nil, nil},
+		{"клавиши по умолчанию в другой записи", map[string]map[string]string{
+			"main":     {"palette": "ctrl+k"},
+			"projects": {"details": "enter"},
+		}, nil},
+		{"неизвестный экран", map[string]map[string]string{
+			"editor": {"save": "Ctrl+S"},
+		}, []string{"interface.key_bindings.editor"}},
+		{"неизвестное действие", map[string]map[string]string{
+			"projects": {"launch": "l", "add": "n"},
+		}, []string{"interface.key_bindings.projects.launch"}},
+		{"две клавиши на одном экране", map[string]map[string]string{
+			"projects": {"edit": "a"},
+		}, []string{"interface.key_bindings.projects.edit"}},
+		{"обмен клавишами", map[string]map[string]string{
+			"projects": {"add": "e", "edit": "a"},
+		}, nil},
+		{"клавиша бокового меню", map[string]map[string]string{
+			"snippets": {"add": "1"},
+		}, []string{"interface.key_bindings.snippets.add"}},
+		{"клавиша меню занимает клавишу экранов", map[string]map[string]string{
+			"main": {"palette": "r"},
+		}, []string{
+			"interface.key_bindings.projects.refresh",
+			"interface.key_bindings.employees.refresh",
+			"interface.key_bindings.snippets.refresh",
+			"interface.key_bindings.audit.refresh",
+			"interface.key_bindings.trash.refresh",
+			"interface.key_bindings.maintenance.refresh",
+			"interface.key_bindings.sync.refresh",
+		}},
+		{"выход не занимает клавишу экранов", map[string]map[string]string{
+			"projects": {"add": "q"},
+		}, nil},
+		{"выход на экране меню", map[string]map[string]string{
+			"main": {"quit": "1"},
+		}, []string{"interface.key_bindings.main.quit"}},
+		{"действия без клавиши не конфликтуют", map[string]map[string]string{
+			"main": {"open_shift": "", "close_shift": ""},
+		}, nil},
+		{"пустая клавиша оставляет клавишу по умолчанию", map[string]map[string]string{
+			"projects": {"add": ""},
+			"trash":    {"restore": "a"},
+		}, nil},
+		{"одна клавиша открытию и закрытию смены", map[string]map[string]string{
+			"main": {"open_shift": "F2", "close_shift": "f2"},
+		}, []string{"interface.key_bindings.main.close_shift"}},
+		{"неверная запись клавиши проверяется в config", map[string]map[string]string{
+			"projects": {"add": "Ctrl+1"},
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := problemKeys(t, CheckKeyBindings(tt.overrides))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckKeyBindings() = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadKeyBindings(t *testing.T) {
+	keys, err := loadKeyBindings(map[string]map[string]string{
+		"projects": {"add": "ctrl+n", "edit": ""},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys["projects"]["add"] != "Ctrl+N" || keys["projects"]["edit"] != "e" {
+		t.Errorf("клавиши проектов: %v", keys["projects"])
+	}
+
+	// При ошибке действуют клавиши по умолчанию
+	keys, err = loadKeyBindings(map[string]map[string]string{
+		"projects": {"add": "e"},
+	})
+	if err == nil {
+		t.Fatal("ожидалась ошибка")
+	}
+	if keys["projects"]["add"] != "a" {
+		t.Errorf("клавиша projects.add = %q, ожидалась клавиша по умолчанию", keys["projects"]["add"])
+	}
+}
+
+func TestDefaultKeysAreNormalized(t *testing.T) {
+	for _, screen := range keyScreens {
+		for _, action := range screen.actions {
+			if action.key == "" {
+				continue
+			}
+			if key, err := config.NormalizeKey(action.key); err != nil || key != action.key {
+				t.Errorf("%s.%s: клавиша %q записана как %q (%v)", screen.name, action.name, action.key, key, err)
+			}
+		}
+	}
+}
+
+func TestKeyName(t *testing.T) {
+	tests := []struct {
+		name  string
+		event *tcell.EventKey
+		want  string
+	}{
+		{"символ", tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone), "a"},
+		{"заглавная буква", tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModShift), "A"},
+		{"кириллица", tcell.NewEventKey(tcell.KeyRune, 'ф', tcell.ModNone), "ф"},
+		{"Ctrl с буквой", tcell.NewEventKey(tcell.KeyCtrlK, 0, tcell.ModCtrl), "Ctrl+K"},
+		{"Ctrl с буквой без модификатора", tcell.NewEventKey(tcell.KeyCtrlK, 0, tcell.ModNone), "Ctrl+K"},
+		{"Alt с символом", tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModAlt), "Alt+x"},
+		{"Enter", tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), "Enter"},
+		{"Shift с функциональной клавишей", tcell.NewEventKey(tcell.KeyF5, 0, tcell.ModShift), "Shift+F5"},
+		{"Shift+Tab", tcell.NewEventKey(tcell.KeyBacktab, 0, tcell.ModNone), "Backtab"},
+		{"PgDn", tcell.NewEventKey(tcell.KeyPgDn, 0, tcell.ModNone), "PgDn"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keyName(tt.event); got != tt.want {
+				t.Errorf("keyName() = %q, ожидалось %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/security"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// LockScreen экран блокировки, закрывающий все страницы после простоя
+type LockScreen struct {
+	app     *App
+	view    *tview.Flex
+	form    *tview.Form
+	message *tview.TextView
+
+	// Элемент, на котором был фокус до блокировки
+	prevFocus tview.Primitive
+}
+
+// NewLockScreen создает экран блокировки
+func NewLockScreen(app *App) *LockScreen {
+	s := &LockScreen{
+		app:     app,
+		form:    tview.NewForm(),
+		message: tview.NewTextView().SetDynamicColors(true).SetTextAlign(tview.AlignCenter),
+	}
+
+	s.form.SetBorder(true).
+		SetTitleAlign(tview.AlignCenter)
+
+	panel := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(s.form, 7, 0, true).
+		AddItem(s.message, 2, 0, false)
+
+	// Пустые Box закрашивают экран, чтобы содержимое под блокировкой не было видно
+	s.view = tview.NewFlex().
+		AddItem(tview.NewBox(), 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(tview.NewBox(), 0, 1, false).
+			AddItem(panel, 9, 0, true).
+			AddItem(tview.NewBox(), 0, 1, false), 60, 0, true).
+		AddItem(tview.NewBox(), 0, 1, false)
+
+	return s
+}
+
+// Lock закрывает интерфейс экраном блокировки
+func (s *LockScreen) Lock() {
+	if s.app.pages.HasPage("lock") {
+		return
+	}
+
+	s.prevFocus = s.app.tviewApp.GetFocus()
+	s.buildForm()
+	s.message.SetText("")
+
+	s.app.pages.AddPage("lock", s.view, true, true)
+	s.app.tviewApp.SetFocus(s.form)
+}
+
+// buildForm заново создает поле ввода, чтобы в нем не оставалось введенного текста
+func (s *LockScreen) buildForm() {
+	s.form.Clear(true)
+	// Заголовок задается здесь, а не при создании: язык мог смениться
+	s.form.SetTitle(i18n.T(" Jotnal заблокирован "))
+
+	label := s.app.secretLabel() + ":"
+	if s.app.GetConfigManager().Get().Security.PINHash != "" {
+		label = i18n.Sprintf("PIN или %s", label)
+	}
+
+	s.form.AddPasswordField(label, "", 30, '*', nil)
+	s.form.AddButton(i18n.T("Разблокировать"), s.tryUnlock)
+
+	// Enter в поле ввода сразу пытается разблокировать
+	field := s.form.GetFormItem(0).(*tview.InputField)
+	field.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			s.tryUnlock()
+		}
+	})
+}
+
+// tryUnlock проверяет введенный секрет с учетом блокировки после неудачных попыток
+func (s *LockScreen) tryUnlock() {
+	field := s.form.GetFormItem(0).(*tview.InputField)
+	input := field.GetText()
+	// Введенный секрет не должен оставаться в интерфейсе
+	field.SetText("")
+
+	cfg := s.app.GetConfigManager().Get()
+	lockout := security.NewLockout(s.app.GetDBManager().GetPath(), cfg.Security)
+
+	if wait := lockout.Remaining(); wait > 0 {
+		s.message.SetText(i18n.Sprintf("[error]Слишком много попыток. Повторите через %s[-]", security.FormatWait(wait)))
+		return
+	}
+
+	valid := false
+	if cfg.Security.PINHash != "" && security.VerifyPIN(input, cfg.Security.PINHash) {
+		valid = true
+	} else if s.app.VerifySecret(input) {
+		valid = true
+	}
+
+	if !valid {
+		delay, err := lockout.RecordFailure()
+		switch {
+		case err != nil:
+			s.message.SetText("[error]" + err.Error() + "[-]")
+		case delay > 0:
+			s.message.SetText(i18n.Sprintf("[error]Неверный ввод. Разблокировка недоступна %s[-]", security.FormatWait(delay)))
+		default:
+			s.message.SetText(i18n.T("[error]Неверный ввод[-]"))
+		}
+		return
+	}
+
+	lockout.RecordSuccess()
+	s.unlock()
+}
+
+// unlock убирает экран блокировки и возвращает фокус
+func (s *LockScreen) unlock() {
+	s.form.Clear(true)
+	s.app.pages.RemovePage("lock")
+	s.app.lastActivity.Store(time.Now().UnixNano())
+	s.app.locked.Store(false)
+
+	if s.prevFocus != nil {
+		s.app.tviewApp.SetFocus(s.prevFocus)
+		s.prevFocus = nil
+	}
+}
+
+// contains сообщает, находится ли точка внутри формы разблокировки
+func (s *LockScreen) contains(x, y int) bool {
+	return s.form.InRect(x, y)
+}
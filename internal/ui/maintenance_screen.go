@@ -0,0 +1,228 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deldim-kam/Jotnal/internal/database"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// MaintenanceScreen экран обслуживания БД: размер файла, таблиц и индексов,
+// VACUUM, ANALYZE и PRAGMA optimize
+type MaintenanceScreen struct {
+	app     *App
+	view    *tview.Flex
+	table   *tview.Table
+	info    *tview.TextView
+	running bool // выполняется операция обслуживания
+}
+
+// NewMaintenanceScreen создает экран обслуживания БД
+func NewMaintenanceScreen(app *App) *MaintenanceScreen {
+	s := &MaintenanceScreen{
+		app:   app,
+		table: tview.NewTable().SetBorders(false).SetSelectable(true, false).SetFixed(1, 0),
+		info:  tview.NewTextView().SetDynamicColors(true),
+	}
+
+	s.table.SetBorder(true).
+		SetTitle(i18n.T(" Таблицы и индексы ")).
+		SetTitleAlign(tview.AlignLeft)
+
+	s.info.SetBorder(true).
+		SetTitle(i18n.T(" Файл БД ")).
+		SetTitleAlign(tview.AlignLeft)
+
+	s.view = tview.NewFlex().
+		AddItem(s.table, 0, 3, true).
+		AddItem(s.info, 44, 0, false)
+
+	s.table.SetInputCapture(app.keyCapture("maintenance", map[string]func(){
+		"vacuum": func() {
+			s.app.ShowConfirm("VACUUM",
+				i18n.T("Перестроить файл БД? На время операции запись в БД будет недоступна, на диске нужно место под копию файла."),
+				func() { s.run("VACUUM", s.app.GetDBManager().Vacuum) }, nil)
+		},
+		"analyze":  func() { s.run("ANALYZE", s.app.GetDBManager().Analyze) },
+		"optimize": func() { s.run("PRAGMA optimize", s.app.GetDBManager().Optimize) },
+		"refresh":  s.Refresh,
+	}))
+
+	return s
+}
+
+// Refresh собирает сведения о БД в фоне: для больших БД подсчет размеров таблиц занимает время
+func (s *MaintenanceScreen) Refresh() {
+	s.info.SetText(i18n.T("\n  Сбор сведений о БД..."))
+	go func() {
+		stats, err := s.app.GetDBManager().Stats()
+		s.app.tviewApp.QueueUpdateDraw(func() {
+			if err != nil {
+				s.info.SetText(i18n.T("\n  [error]Ошибка:[-] ") + tview.Escape(err.Error()))
+				return
+			}
+			s.show(stats)
+		})
+	}()
+}
+
+// show выводит сведения о БД
+func (s *MaintenanceScreen) show(stats *database.Stats) {
+	selection := saveSelection(s.table, s.rowKey)
+	s.table.Clear()
+
+	headers := []string{i18n.T("Таблица / индекс"), i18n.T("Строк"), i18n.T("Данные"), i18n.T("Статистика")}
+	for i, header := range headers {
+		s.table.SetCell(0, i, tview.NewTableCell(header).
+			SetTextColor(tview.Styles.SecondaryTextColor).
+			SetAlign(tview.AlignCenter).
+			SetSelectable(false).
+			SetAttributes(tcell.AttrBold))
+	}
+
+	// Индексы выводятся под своей таблицей
+	row := 1
+	for _, t := range stats.Tables {
+		s.table.SetCell(row, 0, tview.NewTableCell(t.Name))
+		s.table.SetCell(row, 1, tview.NewTableCell(fmt.Sprintf("%d", t.Rows)).SetAlign(tview.AlignRight))
+		s.table.SetCell(row, 2, tview.NewTableCell(formatSize(t.DataSize)).SetAlign(tview.AlignRight))
+		s.table.SetCell(row, 3, tview.NewTableCell(""))
+		row++
+
+		for _, idx := range stats.Indexes {
+			if idx.Table != t.Name {
+				continue
+			}
+			rows, stat := "", i18n.T("[muted]нет[-]")
+			if idx.Analyzed() {
+				rows, stat = fmt.Sprintf("%d", idx.Rows), i18n.Sprintf("~%d на ключ", idx.PerKey)
+			}
+			name := fmt.Sprintf("  %s (%s)", idx.Name, strings.Join(idx.Columns, ", "))
+			s.table.SetCell(row, 0, tview.NewTableCell(name).SetTextColor(tview.Styles.TertiaryTextColor))
+			s.table.SetCell(row, 1, tview.NewTableCell(rows).SetAlign(tview.AlignRight))
+			s.table.SetCell(row, 2, tview.NewTableCell(""))
+			s.table.SetCell(row, 3, tview.NewTableCell(stat))
+			row++
+		}
+	}
+	selection.restore(s.table, row-1, s.rowKey)
+
+	var info strings.Builder
+	i18n.Fprintf(&info, "\n  [label]Размер файла:[-] %s\n", formatSize(stats.FileSize))
+	if stats.WALSize > 0 {
+		i18n.Fprintf(&info, "  [label]Журнал WAL:[-] %s\n", formatSize(stats.WALSize))
+	}
+	i18n.Fprintf(&info, "  [label]Страниц:[-] %d по %d Б\n", stats.PageCount, stats.PageSize)
+	i18n.Fprintf(&info, "  [label]Свободно:[-] %d стр. (%s)\n", stats.FreePages, formatSize(stats.FreeSize()))
+	if warning := s.app.sizeWarning(stats.FileSize + stats.WALSize); warning != "" {
+		fmt.Fprintf(&info, "\n  [error]%s[-]\n", warning)
+	}
+
+	info.WriteString(i18n.T("\n  [label]Горячие клавиши:[-]\n\n"))
+	info.WriteString(s.app.keyHelp("maintenance", nil))
+	info.WriteString(i18n.T("\n  [muted]Данные - объем значений в столбцах\n" +
+		"  без служебных структур SQLite.\n" +
+		"  Статистика индекса - строк на значение\n" +
+		"  ключа (меньше - избирательнее); нет -\n" +
+		"  выполните ANALYZE[-]\n"))
+	s.info.SetText(info.String())
+}
+
+// rowKey возвращает имя таблицы или индекса в строке
+func (s *MaintenanceScreen) rowKey(row int) string {
+	return strings.TrimSpace(s.table.GetCell(row, 0).Text)
+}
+
+// run выполняет операцию обслуживания в фоне, показывая ход выполнения,
+// и сообщает, как изменился размер файла
+func (s *MaintenanceScreen) run(name string, op func() error) {
+	if s.running {
+		return
+	}
+	s.running = true
+
+	progress := tview.NewModal()
+	progress.SetTitle(i18n.T(" Обслуживание БД ")).SetBorder(true)
+	s.app.pages.AddPage("progress", progress, true, true)
+	s.app.keepLockOnTop()
+
+	started := time.Now()
+	sizeBefore := s.app.GetDBManager().FileSize()
+	spinner := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+	update := func(step int) {
+		progress.SetText(i18n.Sprintf("%s %s выполняется... %.0f с", spinner[step%len(spinner)], name, time.Since(started).Seconds()))
+	}
+	update(0)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- op()
+	}()
+
+	go func() {
+		ticker := time.NewTicker(200 * time.Millisecond)
+		defer ticker.Stop()
+
+		for step := 1; ; step++ {
+			select {
+			case <-ticker.C:
+				s.app.tviewApp.QueueUpdateDraw(func() { update(step) })
+			case err := <-done:
+				sizeAfter := s.app.GetDBManager().FileSize()
+				s.app.tviewApp.QueueUpdateDraw(func() {
+					s.running = false
+					s.app.pages.RemovePage("progress")
+					if err != nil {
+						s.app.ShowModal(i18n.T("Ошибка"), name+": "+err.Error(), 60, 10, nil)
+						return
+					}
+					result := i18n.Sprintf("%s завершен за %.1f с", name, time.Since(started).Seconds())
+					sizes := i18n.Sprintf("Размер файла: %s → %s", formatSize(sizeBefore), formatSize(sizeAfter))
+					// Результат заменяет в статус баре предупреждение о размере, если оно было
+					s.app.setStatus("[accent]" + result + "[-], " + sizes)
+					s.app.ShowModal(i18n.T("Готово"), result+"\n"+sizes, 50, 10, nil)
+					s.Refresh()
+				})
+				return
+			}
+		}
+	}()
+}
+
+// GetView возвращает view экрана
+func (s *MaintenanceScreen) GetView() tview.Primitive {
+	return s.view
+}
+
+// sizeWarning возвращает предупреждение, если размер БД превысил порог из настроек
+func (a *App) sizeWarning(size int64) string {
+	limit := a.configManager.Get().Database.SizeWarning()
+	if limit <= 0 || size <= limit {
+		return ""
+	}
+	return i18n.Sprintf("Размер БД %s превышает порог %s", formatSize(size), formatSize(limit))
+}
+
+// checkDBSize предупреждает в статус баре, если БД слишком выросла
+func (a *App) checkDBSize() {
+	if warning := a.sizeWarning(a.dbManager.FileSize()); warning != "" {
+		a.queueStatus(i18n.Sprintf("[error]%s[-] - см. «Обслуживание БД»", warning))
+	}
+}
+
+// formatSize форматирует размер в байтах
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return i18n.Sprintf("%.1f ГБ", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return i18n.Sprintf("%.1f МБ", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return i18n.Sprintf("%.1f КБ", float64(size)/(1<<10))
+	}
+	return i18n.Sprintf("%d Б", size)
+}
@@ -0,0 +1,316 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// recentLimit - сколько последних выборов палитры команд запоминается
+const recentLimit = 20
+
+// recentFile - файл недавних выборов палитры рядом с config.json: у каждого
+// пользователя они свои, даже если БД общая
+const recentFile = "palette-recent.json"
+
+// recentState - содержимое файла недавних выборов
+type recentState struct {
+	Database string   `json:"database"` // БД, к записям которой относятся ID
+	Recent   []string `json:"recent"`
+}
+
+// paletteItem - строка палитры команд: действие из реестра клавиш или запись
+type paletteItem struct {
+	id    string // ключ для недавних выборов: action:экран.действие, project:ID и т.д.
+	title string
+	group string // экран действия или тип записи
+	key   string // клавиша действия
+	run   func()
+}
+
+// ShowPalette показывает палитру команд: нечеткий поиск по действиям всех
+// экранов и по проектам, сотрудникам и сниппетам. Недавние выборы идут первыми.
+func (a *App) ShowPalette() {
+	if a.pages.HasPage("palette") {
+		return
+	}
+	previous := a.tviewApp.GetFocus()
+	items := a.paletteItems()
+	var shown []paletteItem
+
+	input := tview.NewInputField().
+		SetLabel("> ").
+		SetFieldWidth(0)
+	table := tview.NewTable().SetSelectable(true, false)
+
+	fill := func(query string) {
+		shown = a.rankPalette(items, query)
+		table.Clear()
+		for row, item := range shown {
+			table.SetCell(row, 0, tview.NewTableCell(tview.Escape(item.title)).SetExpansion(1))
+			table.SetCell(row, 1, tview.NewTableCell("[muted]"+tview.Escape(item.group)+"[-]"))
+			table.SetCell(row, 2, tview.NewTableCell("[accent]"+tview.Escape(item.key)+"[-]").SetAlign(tview.AlignRight))
+		}
+		table.Select(0, 0).ScrollToBeginning()
+	}
+
+	closePalette := func() {
+		a.pages.RemovePage("palette")
+		a.tviewApp.SetFocus(previous)
+	}
+	choose := func(row int) {
+		if row < 0 || row >= len(shown) {
+			return
+		}
+		item := shown[row]
+		closePalette()
+		a.remember(item.id)
+		item.run()
+	}
+
+	input.SetChangedFunc(fill)
+	input.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEnter:
+			row, _ := table.GetSelection()
+			choose(row)
+		case tcell.KeyEscape:
+			closePalette()
+		}
+	})
+	// Стрелки двигают выбор в списке, остальные клавиши остаются полю поиска
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn:
+			table.InputHandler()(event, func(p tview.Primitive) {})
+			return nil
+		}
+		return event
+	})
+	table.SetSelectedFunc(func(row, column int) {
+		choose(row)
+	})
+	fill("")
+
+	view := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(input, 1, 0, true).
+		AddItem(table, 0, 1, false)
+	view.SetBorder(true).
+		SetTitle(i18n.T(" Команды и записи ")).
+		SetTitleAlign(tview.AlignLeft)
+
+	a.pages.AddPage("palette", center(view, 80, 20), true, true)
+	a.keepLockOnTop()
+}
+
+// paletteItems собирает строки палитры: действия из реестра клавиш (кроме
+// самой палитры) и записи. Записи, которые не удалось загрузить (например,
+// БД занята), в палитру не попадают.
+func (a *App) paletteItems() []paletteItem {
+	var items []paletteItem
+	for _, screen := range keyScreens {
+		for _, action := range screen.actions {
+			if screen.name == "main" && action.name == "palette" {
+				continue
+			}
+			items = append(items, paletteItem{
+				id:    "action:" + screen.name + "." + action.name,
+				title: i18n.T(action.label),
+				group: i18n.T(screen.label),
+				key:   a.key(screen.name, action.name),
+				run:   func() { a.runAction(screen.name, action.name) },
+			})
+		}
+	}
+
+	st := a.GetStore()
+	if projects, err := st.ListProjects(); err == nil {
+		for _, p := range projects {
+			items = append(items, paletteItem{
+				id:    fmt.Sprintf("project:%d", p.ID),
+				title: p.Name,
+				group: i18n.T("Проект"),
+				run: func() {
+					a.openScreen("projects")
+					a.projectsScreen.selectProject(p.ID)
+				},
+			})
+		}
+	}
+	if employees, err := st.ListEmployees(); err == nil {
+		for _, e := range employees {
+			items = append(items, paletteItem{
+				id:    fmt.Sprintf("employee:%d", e.ID),
+				title: strings.Join(strings.Fields(e.LastName+" "+e.FirstName+" "+e.MiddleName), " "),
+				group: i18n.T("Сотрудник"),
+				run: func() {
+					a.openScreen("employees")
+					a.employeesScreen.selectEmployee(e.ID)
+				},
+			})
+		}
+	}
+	if snippets, err := st.ListSnippets(); err == nil {
+		for _, s := range snippets {
+			items = append(items, paletteItem{
+				id:    fmt.Sprintf("snippet:%d", s.ID),
+				title: s.Title,
+				group: i18n.T("Сниппет"),
+				run: func() {
+					a.openScreen("snippets")
+					a.snippetsScreen.selectSnippet(s.ID)
+				},
+			})
+		}
+	}
+	return items
+}
+
+// runAction выполняет действие action экрана screen так же, как его клавиша,
+// предварительно открыв экран. Пункты меню открывают свой экран.
+func (a *App) runAction(screen, action string) {
+	if screen == "main" {
+		if action == "quit" {
+			a.tviewApp.Stop()
+			return
+		}
+		if a.openScreen(action) {
+			return
+		}
+	} else {
+		a.openScreen(screen)
+	}
+	if run := a.actions[screen][action]; run != nil {
+		run()
+	}
+}
+
+// rankPalette отбирает строки, подходящие под query, и упорядочивает их:
+// сначала недавние выборы (последний первым), затем по оценке совпадения,
+// при равенстве - в исходном порядке
+func (a *App) rankPalette(items []paletteItem, query string) []paletteItem {
+	recent := make(map[string]int, len(a.recent))
+	for i, id := range a.recent {
+		recent[id] = i
+	}
+
+	type match struct {
+		item          paletteItem
+		recent, score int
+	}
+	var matches []match
+	for _, item := range items {
+		score, ok := fuzzyScore(query, item.title+" "+item.group)
+		if !ok {
+			continue
+		}
+		rank, ok := recent[item.id]
+		if !ok {
+			rank = len(a.recent)
+		}
+		matches = append(matches, match{item: item, recent: rank, score: score})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].recent != matches[j].recent {
+			return matches[i].recent < matches[j].recent
+		}
+		return matches[i].score > matches[j].score
+	})
+
+	ranked := make([]paletteItem, len(matches))
+	for i, m := range matches {
+		ranked[i] = m.item
+	}
+	return ranked
+}
+
+// remember запоминает выбор в палитре как самый недавний и сохраняет список
+func (a *App) remember(id string) {
+	recent := []string{id}
+	for _, other := range a.recent {
+		if other != id && len(recent) < recentLimit {
+			recent = append(recent, other)
+		}
+	}
+	a.recent = recent
+
+	if err := a.saveRecent(); err != nil {
+		a.setStatus("[error]" + tview.Escape(err.Error()) + "[-]")
+	}
+}
+
+// recentPath возвращает путь к файлу недавних выборов
+func (a *App) recentPath() string {
+	return filepath.Join(filepath.Dir(a.configManager.Path()), recentFile)
+}
+
+// loadRecent читает недавние выборы; отсутствующий или поврежденный файл
+// означает пустой список. ID записей относятся к одной БД, поэтому после
+// смены БД остаются только действия.
+func (a *App) loadRecent() {
+	data, err := os.ReadFile(a.recentPath())
+	if err != nil {
+		return
+	}
+	var state recentState
+	if json.Unmarshal(data, &state) != nil {
+		return
+	}
+
+	a.recent = nil
+	for _, id := range state.Recent {
+		if len(a.recent) == recentLimit {
+			break
+		}
+		if state.Database == a.dbManager.GetPath() || strings.HasPrefix(id, "action:") {
+			a.recent = append(a.recent, id)
+		}
+	}
+}
+
+// saveRecent записывает недавние выборы в файл
+func (a *App) saveRecent() error {
+	data, err := json.Marshal(recentState{Database: a.dbManager.GetPath(), Recent: a.recent})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(a.recentPath(), data, 0600); err != nil {
+		return i18n.Errorf("не удалось сохранить недавние выборы палитры: %w", err)
+	}
+	return nil
+}
+
+// fuzzyScore сопоставляет query с text без учета регистра: все символы запроса
+// должны встретиться в text в том же порядке, но не обязательно подряд.
+// Символы подряд и в начале слов повышают оценку; ok = false - не совпадает.
+func fuzzyScore(query, text string) (score int, ok bool) {
+	q := []rune(strings.ToLower(strings.TrimSpace(query)))
+	t := []rune(strings.ToLower(text))
+
+	matched, last := 0, -2
+	for i, r := range t {
+		if matched == len(q) {
+			break
+		}
+		if r != q[matched] {
+			continue
+		}
+		score++
+		if i == last+1 {
+			score += 3
+		}
+		if i == 0 || !unicode.IsLetter(t[i-1]) && !unicode.IsDigit(t[i-1]) {
+			score += 2
+		}
+		matched, last = matched+1, i
+	}
+	return score, matched == len(q)
+}
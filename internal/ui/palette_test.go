@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/deldim-kam/Jotnal/internal/database"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		query, text string
+		want        int
+		wantOK      bool
+	}{
+		{"", "Проекты", 0, true},
+		{"  ", "Проекты", 0, true},
+		{"ab", "ab", 7, true},   // начало слова и подряд
+		{"ab", "a b", 6, true},  // оба символа в начале слов
+		{"ab", "xaxb", 2, true}, // вразброс внутри слова
+		{"AB", "xaxb", 2, true}, // без учета регистра
+		{"про", "Проекты", 11, true},
+		{"доб сотр", "Добавить сотрудника Сотрудники", 30, true},
+		{"ба", "аб", 0, false},  // порядок символов важен
+		{"abc", "ab", 0, false}, // запрос длиннее текста
+		{"проект", "Сниппет", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query+"/"+tt.text, func(t *testing.T) {
+			score, ok := fuzzyScore(tt.query, tt.text)
+			if ok != tt.wantOK || ok && score != tt.want {
+				t.Errorf("fuzzyScore(%q, %q) = %d, %v, ожидалось %d, %v", tt.query, tt.text, score, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRankPalette(t *testing.T) {
+	items := []paletteItem{
+		{id: "action:projects.add", title: "Добавить проект", group: "Проекты"},
+		{id: "action:employees.add", title: "Добавить сотрудника", group: "Сотрудники"},
+		{id: "project:1", title: "Альфа", group: "Проект"},
+		{id: "project:2", title: "Бета", group: "Проект"},
+		{id: "snippet:1", title: "hello", group: "Сниппет"},
+	}
+
+	tests := []struct {
+		name   string
+		recent []string
+		query  string
+		want   []string
+	}{
+		{"без запроса в исходном порядке", nil, "",
+			[]string{"action:projects.add", "action:employees.add", "project:1", "project:2", "snippet:1"}},
+		{"недавние первыми, последний первым", []string{"project:2", "snippet:1"}, "",
+			[]string{"project:2", "snippet:1", "action:projects.add", "action:employees.add", "project:1"}},
+		{"отбор по запросу", nil, "доб",
+			[]string{"action:projects.add", "action:employees.add"}},
+		{"лучшее совпадение выше, при равенстве исходный порядок", nil, "ак",
+			[]string{"project:1", "action:projects.add", "action:employees.add", "project:2"}},
+		{"недавний выше лучшего совпадения", []string{"project:2"}, "ак",
+			[]string{"project:2", "project:1", "action:projects.add", "action:employees.add"}},
+		{"недавний не подходит под запрос", []string{"snippet:1"}, "бета",
+			[]string{"project:2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &App{recent: tt.recent}
+			var got []string
+			for _, item := range a.rankPalette(items, tt.query) {
+				got = append(got, item.id)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rankPalette(%q) = %v, ожидалось %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+// newRecentApp создает приложение только с тем, что нужно для недавних
+// выборов: конфигурацией в dir и БД dbName (без подключения)
+func newRecentApp(t *testing.T, dir, dbName string) *App {
+	t.Helper()
+
+	cfg, err := config.NewManager(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := database.NewManager(filepath.Join(dir, dbName), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &App{configManager: cfg, dbManager: db}
+}
+
+func TestRecentPersisted(t *testing.T) {
+	dir := t.TempDir()
+	a := newRecentApp(t, dir, "jotnal.db")
+	for i := range recentLimit + 5 {
+		a.remember(fmt.Sprintf("project:%d", i))
+	}
+	a.remember("action:main.open_shift")
+	a.remember("project:3")
+
+	if len(a.recent) != recentLimit || a.recent[0] != "project:3" || a.recent[1] != "action:main.open_shift" {
+		t.Fatalf("недавние выборы: %v", a.recent)
+	}
+
+	// Следующий запуск с той же БД видит тот же список
+	next := newRecentApp(t, dir, "jotnal.db")
+	next.loadRecent()
+	if !reflect.DeepEqual(next.recent, a.recent) {
+		t.Errorf("после перезапуска: %v, ожидалось %v", next.recent, a.recent)
+	}
+
+	// ID записей другой БД не переносятся, действия остаются
+	other := newRecentApp(t, dir, "other.db")
+	other.loadRecent()
+	if !reflect.DeepEqual(other.recent, []string{"action:main.open_shift"}) {
+		t.Errorf("с другой БД: %v", other.recent)
+	}
+
+	// Поврежденный файл означает пустой список
+	if err := os.WriteFile(filepath.Join(dir, recentFile), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	broken := newRecentApp(t, dir, "jotnal.db")
+	broken.loadRecent()
+	if len(broken.recent) != 0 {
+		t.Errorf("с поврежденным файлом: %v", broken.recent)
+	}
+}
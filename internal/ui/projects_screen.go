@@ -1,9 +1,11 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
-	"time"
 
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/store"
 	"github.com/deldim-kam/Jotnal/pkg/models"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -26,17 +28,12 @@ func NewProjectsScreen(app *App) *ProjectsScreen {
 	}
 
 	s.table.SetBorder(true).
-		SetTitle(" Список проектов ").
+		SetTitle(i18n.T(" Список проектов ")).
 		SetTitleAlign(tview.AlignLeft)
 
-	s.info.SetText("\n  [yellow]Горячие клавиши:[white]\n\n" +
-		"  [green]a[white] - Добавить проект\n" +
-		"  [green]e[white] - Редактировать\n" +
-		"  [green]d[white] - Удалить\n" +
-		"  [green]Enter[white] - Просмотр деталей\n" +
-		"  [green]r[white] - Обновить список\n")
+	s.info.SetText(i18n.T("\n  [label]Горячие клавиши:[-]\n\n") + app.keyHelp("projects", nil))
 	s.info.SetBorder(true).
-		SetTitle(" Информация ").
+		SetTitle(i18n.T(" Информация ")).
 		SetTitleAlign(tview.AlignLeft)
 
 	// Настраиваем таблицу
@@ -48,39 +45,24 @@ func NewProjectsScreen(app *App) *ProjectsScreen {
 		AddItem(s.info, 40, 0, false)
 
 	// Обработка клавиш
-	s.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Rune() {
-		case 'a':
-			s.addProject()
-			return nil
-		case 'e':
-			s.editProject()
-			return nil
-		case 'd':
-			s.deleteProject()
-			return nil
-		case 'r':
-			s.Refresh()
-			return nil
-		}
-
-		if event.Key() == tcell.KeyEnter {
-			s.showDetails()
-			return nil
-		}
-
-		return event
-	})
+	s.table.SetInputCapture(app.keyCapture("projects", map[string]func(){
+		"add":     s.addProject,
+		"edit":    s.editProject,
+		"delete":  s.deleteProject,
+		"details": s.showDetails,
+		"history": s.showHistory,
+		"refresh": s.Refresh,
+	}))
 
 	return s
 }
 
 // setupTable настраивает заголовки таблицы
 func (s *ProjectsScreen) setupTable() {
-	headers := []string{"ID", "Название", "Путь", "Описание", "Создан"}
+	headers := []string{"ID", i18n.T("Название"), i18n.T("Путь"), i18n.T("Описание"), i18n.T("Создан")}
 	for i, header := range headers {
 		cell := tview.NewTableCell(header).
-			SetTextColor(tcell.ColorYellow).
+			SetTextColor(tview.Styles.SecondaryTextColor).
 			SetAlign(tview.AlignCenter).
 			SetSelectable(false).
 			SetAttributes(tcell.AttrBold)
@@ -90,6 +72,8 @@ func (s *ProjectsScreen) setupTable() {
 
 // Refresh обновляет список проектов
 func (s *ProjectsScreen) Refresh() {
+	selection := saveSelection(s.table, s.rowKey)
+
 	// Очищаем таблицу (кроме заголовка)
 	s.table.Clear()
 	s.setupTable()
@@ -97,7 +81,7 @@ func (s *ProjectsScreen) Refresh() {
 	// Загружаем проекты из БД
 	projects, err := s.loadProjects()
 	if err != nil {
-		s.app.ShowModal("Ошибка", "Не удалось загрузить проекты: "+err.Error(), 50, 10, nil)
+		s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось загрузить проекты: ")+err.Error(), 50, 10, nil)
 		return
 	}
 
@@ -109,72 +93,66 @@ func (s *ProjectsScreen) Refresh() {
 		s.table.SetCell(row, 1, tview.NewTableCell(project.Name))
 		s.table.SetCell(row, 2, tview.NewTableCell(project.Path))
 		s.table.SetCell(row, 3, tview.NewTableCell(truncate(project.Description, 30)))
-		s.table.SetCell(row, 4, tview.NewTableCell(project.CreatedAt.Format("2006-01-02")))
+		s.table.SetCell(row, 4, tview.NewTableCell(i18n.Date(project.CreatedAt)))
 	}
 
-	if len(projects) > 0 {
-		s.table.Select(1, 0)
-	}
+	selection.restore(s.table, len(projects), s.rowKey)
 }
 
-// loadProjects загружает проекты из БД
-func (s *ProjectsScreen) loadProjects() ([]models.Project, error) {
-	rows, err := s.app.GetDB().Query("SELECT id, name, path, description, created_at, updated_at FROM projects ORDER BY created_at DESC")
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+// rowKey возвращает ID проекта в строке таблицы
+func (s *ProjectsScreen) rowKey(row int) string {
+	return s.table.GetCell(row, 0).Text
+}
 
-	var projects []models.Project
-	for rows.Next() {
-		var p models.Project
-		if err := rows.Scan(&p.ID, &p.Name, &p.Path, &p.Description, &p.CreatedAt, &p.UpdatedAt); err != nil {
-			return nil, err
-		}
-		projects = append(projects, p)
-	}
+// selectProject выбирает строку проекта с ID id
+func (s *ProjectsScreen) selectProject(id int64) {
+	tableSelection{key: fmt.Sprintf("%d", id)}.restore(s.table, s.table.GetRowCount()-1, s.rowKey)
+}
 
-	return projects, nil
+// loadProjects загружает проекты из БД
+func (s *ProjectsScreen) loadProjects() ([]models.Project, error) {
+	return s.app.GetStore().ListProjects()
 }
 
 // addProject добавляет новый проект
 func (s *ProjectsScreen) addProject() {
 	form := tview.NewForm()
-	form.SetBorder(true).SetTitle(" Новый проект ").SetTitleAlign(tview.AlignLeft)
+	form.SetBorder(true).SetTitle(i18n.T(" Новый проект ")).SetTitleAlign(tview.AlignLeft)
 
 	var name, path, description string
 
-	form.AddInputField("Название:", "", 40, nil, func(text string) {
+	form.AddInputField(i18n.T("Название:"), "", 40, nil, func(text string) {
 		name = text
 	})
-	form.AddInputField("Путь:", "", 60, nil, func(text string) {
+	form.AddInputField(i18n.T("Путь:"), "", 60, nil, func(text string) {
 		path = text
 	})
-	form.AddTextArea("Описание:", "", 60, 3, 0, func(text string) {
+	form.AddTextArea(i18n.T("Описание:"), "", 60, 3, 0, func(text string) {
 		description = text
 	})
 
-	form.AddButton("Сохранить", func() {
+	form.AddButton(i18n.T("Сохранить"), func() {
 		if name == "" || path == "" {
-			s.app.ShowModal("Ошибка", "Название и путь обязательны для заполнения", 50, 10, nil)
+			s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Название и путь обязательны для заполнения"), 50, 10, nil)
 			return
 		}
 
-		_, err := s.app.GetDB().Exec(
-			"INSERT INTO projects (name, path, description, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
-			name, path, description, time.Now(), time.Now(),
-		)
+		_, err := s.app.GetStore().CreateProject(models.Project{
+			Name:        name,
+			Path:        path,
+			Description: description,
+		})
 		if err != nil {
-			s.app.ShowModal("Ошибка", "Не удалось создать проект: "+err.Error(), 50, 10, nil)
+			s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось создать проект: ")+err.Error(), 50, 10, nil)
 			return
 		}
 
 		s.app.pages.RemovePage("form")
 		s.Refresh()
-		s.app.ShowModal("Успех", "Проект успешно создан!", 40, 8, nil)
+		s.app.ShowModal(i18n.T("Успех"), i18n.T("Проект успешно создан!"), 40, 8, nil)
 	})
 
-	form.AddButton("Отмена", func() {
+	form.AddButton(i18n.T("Отмена"), func() {
 		s.app.pages.RemovePage("form")
 	})
 
@@ -193,55 +171,84 @@ func (s *ProjectsScreen) editProject() {
 	fmt.Sscanf(idCell.Text, "%d", &projectID)
 
 	// Загружаем данные проекта
-	var p models.Project
-	err := s.app.GetDB().QueryRow(
-		"SELECT id, name, path, description FROM projects WHERE id = ?",
-		projectID,
-	).Scan(&p.ID, &p.Name, &p.Path, &p.Description)
-
+	p, err := s.app.GetStore().GetProject(projectID)
 	if err != nil {
-		s.app.ShowModal("Ошибка", "Не удалось загрузить проект: "+err.Error(), 50, 10, nil)
+		s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось загрузить проект: ")+err.Error(), 50, 10, nil)
 		return
 	}
 
+	s.showEditForm(*p, *p)
+}
+
+// projectFields - редактируемые поля проекта для разрешения конфликтов
+var projectFields = []editField[models.Project]{
+	{"Название", func(p *models.Project) *string { return &p.Name }},
+	{"Путь", func(p *models.Project) *string { return &p.Path }},
+	{"Описание", func(p *models.Project) *string { return &p.Description }},
+}
+
+// showEditForm открывает форму редактирования: orig - загруженная версия проекта,
+// values - значения полей формы (после объединения они отличаются от orig)
+func (s *ProjectsScreen) showEditForm(orig, values models.Project) {
 	form := tview.NewForm()
-	form.SetBorder(true).SetTitle(" Редактирование проекта ").SetTitleAlign(tview.AlignLeft)
+	form.SetBorder(true).SetTitle(i18n.T(" Редактирование проекта ")).SetTitleAlign(tview.AlignLeft)
 
 	var name, path, description string
-	name, path, description = p.Name, p.Path, p.Description
+	name, path, description = values.Name, values.Path, values.Description
 
-	form.AddInputField("Название:", name, 40, nil, func(text string) {
+	form.AddInputField(i18n.T("Название:"), name, 40, nil, func(text string) {
 		name = text
 	})
-	form.AddInputField("Путь:", path, 60, nil, func(text string) {
+	form.AddInputField(i18n.T("Путь:"), path, 60, nil, func(text string) {
 		path = text
 	})
-	form.AddTextArea("Описание:", description, 60, 3, 0, func(text string) {
+	form.AddTextArea(i18n.T("Описание:"), description, 60, 3, 0, func(text string) {
 		description = text
 	})
 
-	form.AddButton("Сохранить", func() {
-		_, err := s.app.GetDB().Exec(
-			"UPDATE projects SET name = ?, path = ?, description = ?, updated_at = ? WHERE id = ?",
-			name, path, description, time.Now(), projectID,
-		)
-		if err != nil {
-			s.app.ShowModal("Ошибка", "Не удалось обновить проект: "+err.Error(), 50, 10, nil)
-			return
-		}
+	form.AddButton(i18n.T("Сохранить"), func() {
+		updated := orig
+		updated.Name, updated.Path, updated.Description = name, path, description
 
-		s.app.pages.RemovePage("form")
-		s.Refresh()
-		s.app.ShowModal("Успех", "Проект успешно обновлен!", 40, 8, nil)
+		s.saveProject(orig, updated)
 	})
 
-	form.AddButton("Отмена", func() {
+	form.AddButton(i18n.T("Отмена"), func() {
 		s.app.pages.RemovePage("form")
 	})
 
 	s.app.pages.AddPage("form", center(form, 80, 15), true, true)
 }
 
+// saveProject сохраняет изменения; если проект успели изменить, предлагает
+// перезаписать, объединить или отменить
+func (s *ProjectsScreen) saveProject(orig, updated models.Project) {
+	err := s.app.GetStore().UpdateProject(updated)
+
+	var conflict *store.ConflictError
+	if errors.As(err, &conflict) {
+		current := *conflict.Current.(*models.Project)
+		s.app.showConflict(conflict, collectConflict(projectFields, orig, updated, current),
+			func() {
+				updated.Version = current.Version
+				s.saveProject(current, updated)
+			},
+			func() {
+				s.showEditForm(current, mergeEdits(projectFields, orig, updated, current))
+			},
+		)
+		return
+	}
+	if err != nil {
+		s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось обновить проект: ")+err.Error(), 50, 10, nil)
+		return
+	}
+
+	s.app.pages.RemovePage("form")
+	s.Refresh()
+	s.app.ShowModal(i18n.T("Успех"), i18n.T("Проект успешно обновлен!"), 40, 8, nil)
+}
+
 // deleteProject удаляет выбранный проект
 func (s *ProjectsScreen) deleteProject() {
 	row, _ := s.table.GetSelection()
@@ -255,16 +262,16 @@ func (s *ProjectsScreen) deleteProject() {
 	fmt.Sscanf(idCell.Text, "%d", &projectID)
 
 	s.app.ShowConfirm(
-		"Подтверждение удаления",
-		fmt.Sprintf("Вы уверены, что хотите удалить проект '%s'?", nameCell.Text),
+		i18n.T("Подтверждение удаления"),
+		i18n.Sprintf("Переместить проект '%s' в корзину?", nameCell.Text),
 		func() {
-			_, err := s.app.GetDB().Exec("DELETE FROM projects WHERE id = ?", projectID)
+			err := s.app.GetStore().DeleteProject(projectID)
 			if err != nil {
-				s.app.ShowModal("Ошибка", "Не удалось удалить проект: "+err.Error(), 50, 10, nil)
+				s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось удалить проект: ")+err.Error(), 50, 10, nil)
 				return
 			}
 			s.Refresh()
-			s.app.ShowModal("Успех", "Проект удален!", 40, 8, nil)
+			s.app.ShowModal(i18n.T("Успех"), i18n.T("Проект перемещен в корзину"), 40, 8, nil)
 		},
 		nil,
 	)
@@ -281,34 +288,29 @@ func (s *ProjectsScreen) showDetails() {
 	var projectID int64
 	fmt.Sscanf(idCell.Text, "%d", &projectID)
 
-	var p models.Project
-	err := s.app.GetDB().QueryRow(
-		"SELECT id, name, path, description, created_at, updated_at FROM projects WHERE id = ?",
-		projectID,
-	).Scan(&p.ID, &p.Name, &p.Path, &p.Description, &p.CreatedAt, &p.UpdatedAt)
-
+	p, err := s.app.GetStore().GetProject(projectID)
 	if err != nil {
-		s.app.ShowModal("Ошибка", "Не удалось загрузить проект: "+err.Error(), 50, 10, nil)
+		s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось загрузить проект: ")+err.Error(), 50, 10, nil)
 		return
 	}
 
-	details := fmt.Sprintf(
-		"\n[yellow]ID:[white] %d\n\n"+
-			"[yellow]Название:[white] %s\n\n"+
-			"[yellow]Путь:[white] %s\n\n"+
-			"[yellow]Описание:[white] %s\n\n"+
-			"[yellow]Создан:[white] %s\n\n"+
-			"[yellow]Обновлен:[white] %s\n",
+	details := i18n.Sprintf(
+		"\n[label]ID:[-] %d\n\n"+
+			"[label]Название:[-] %s\n\n"+
+			"[label]Путь:[-] %s\n\n"+
+			"[label]Описание:[-] %s\n\n"+
+			"[label]Создан:[-] %s\n\n"+
+			"[label]Обновлен:[-] %s\n",
 		p.ID, p.Name, p.Path, p.Description,
-		p.CreatedAt.Format("2006-01-02 15:04:05"),
-		p.UpdatedAt.Format("2006-01-02 15:04:05"),
+		i18n.Timestamp(p.CreatedAt),
+		i18n.Timestamp(p.UpdatedAt),
 	)
 
 	textView := tview.NewTextView().
 		SetDynamicColors(true).
 		SetText(details).
 		SetBorder(true).
-		SetTitle(" Детали проекта ")
+		SetTitle(i18n.T(" Детали проекта "))
 
 	textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		s.app.pages.RemovePage("details")
@@ -318,6 +320,19 @@ func (s *ProjectsScreen) showDetails() {
 	s.app.pages.AddPage("details", center(textView, 70, 20), true, true)
 }
 
+// showHistory показывает историю изменений выбранного проекта
+func (s *ProjectsScreen) showHistory() {
+	row, _ := s.table.GetSelection()
+	if row == 0 {
+		return
+	}
+
+	var projectID int64
+	fmt.Sscanf(s.table.GetCell(row, 0).Text, "%d", &projectID)
+
+	s.app.ShowHistory(store.EntityProject, projectID, i18n.T("История: ")+s.table.GetCell(row, 1).Text)
+}
+
 // GetView возвращает view экрана
 func (s *ProjectsScreen) GetView() tview.Primitive {
 	return s.view
@@ -3,7 +3,8 @@ package ui
 import (
 	"fmt"
 
-	"github.com/gdamore/tcell/v2"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/security"
 	"github.com/rivo/tview"
 )
 
@@ -24,17 +25,25 @@ func NewSettingsScreen(app *App) *SettingsScreen {
 	}
 
 	s.form.SetBorder(true).
-		SetTitle(" Настройки приложения ").
+		SetTitle(i18n.T(" Настройки приложения ")).
 		SetTitleAlign(tview.AlignLeft)
 
 	s.info.SetBorder(true).
-		SetTitle(" Информация о БД ").
+		SetTitle(i18n.T(" Информация о БД ")).
 		SetTitleAlign(tview.AlignLeft)
 
 	s.view = tview.NewFlex().
 		AddItem(s.form, 0, 2, true).
 		AddItem(s.info, 0, 1, false)
 
+	s.view.SetInputCapture(app.keyCapture("settings", map[string]func(){
+		"password":  s.changePassword,
+		"db_path":   s.changePath,
+		"backup":    s.app.BackupNow,
+		"lock":      s.app.Lock,
+		"integrity": s.app.CheckIntegrity,
+	}))
+
 	return s
 }
 
@@ -50,39 +59,127 @@ func (s *SettingsScreen) Refresh() {
 	width = cfg.Interface.WindowSize.Width
 	height = cfg.Interface.WindowSize.Height
 	language = cfg.Interface.Language
+	backupCfg := cfg.Backup
+	securityCfg := cfg.Security
+	trashCfg := cfg.Trash
+	dbCfg := cfg.Database
 
-	s.form.AddInputField("Тема (dark/light):", theme, 20, nil, func(text string) {
+	s.form.AddInputField(i18n.T("Тема (dark/light/high-contrast):"), theme, 20, nil, func(text string) {
 		theme = text
 	})
-	s.form.AddInputField("Размер шрифта:", fmt.Sprintf("%d", fontSize), 10, nil, func(text string) {
+	s.form.AddInputField(i18n.T("Размер шрифта:"), fmt.Sprintf("%d", fontSize), 10, nil, func(text string) {
 		fmt.Sscanf(text, "%d", &fontSize)
 	})
-	s.form.AddInputField("Ширина окна:", fmt.Sprintf("%d", width), 10, nil, func(text string) {
+	s.form.AddInputField(i18n.T("Ширина окна:"), fmt.Sprintf("%d", width), 10, nil, func(text string) {
 		fmt.Sscanf(text, "%d", &width)
 	})
-	s.form.AddInputField("Высота окна:", fmt.Sprintf("%d", height), 10, nil, func(text string) {
+	s.form.AddInputField(i18n.T("Высота окна:"), fmt.Sprintf("%d", height), 10, nil, func(text string) {
 		fmt.Sscanf(text, "%d", &height)
 	})
-	s.form.AddInputField("Язык (ru/en):", language, 10, nil, func(text string) {
+	s.form.AddInputField(i18n.T("Язык (ru/en):"), language, 10, nil, func(text string) {
 		language = text
 	})
 
-	s.form.AddButton("Сохранить", func() {
+	s.form.AddCheckbox(i18n.T("Автокопирование:"), backupCfg.Enabled, func(checked bool) {
+		backupCfg.Enabled = checked
+	})
+	s.form.AddInputField(i18n.T("Интервал (hourly/daily/off/30m):"), backupCfg.Interval, 10, nil, func(text string) {
+		backupCfg.Interval = text
+	})
+	s.form.AddCheckbox(i18n.T("Копия при закрытии смены:"), backupCfg.OnShiftClose, func(checked bool) {
+		backupCfg.OnShiftClose = checked
+	})
+	s.form.AddCheckbox(i18n.T("Копия при выходе:"), backupCfg.OnExit, func(checked bool) {
+		backupCfg.OnExit = checked
+	})
+	s.form.AddInputField(i18n.T("Хранить копий (0 - все):"), fmt.Sprintf("%d", backupCfg.KeepLast), 10, nil, func(text string) {
+		fmt.Sscanf(text, "%d", &backupCfg.KeepLast)
+	})
+
+	s.form.AddInputField(i18n.T("Автоблокировка, мин (0 - выкл):"), fmt.Sprintf("%d", securityCfg.IdleLockMinutes), 10, nil, func(text string) {
+		fmt.Sscanf(text, "%d", &securityCfg.IdleLockMinutes)
+	})
+	s.form.AddInputField(i18n.T("Режим журнала БД (wal/delete):"), dbCfg.JournalMode, 10, nil, func(text string) {
+		dbCfg.JournalMode = text
+	})
+	s.form.AddInputField(i18n.T("Ожидание занятой БД, мс:"), fmt.Sprintf("%d", dbCfg.BusyTimeout), 10, nil, func(text string) {
+		fmt.Sscanf(text, "%d", &dbCfg.BusyTimeout)
+	})
+	s.form.AddInputField(i18n.T("Повторов записи:"), fmt.Sprintf("%d", dbCfg.WriteRetries), 10, nil, func(text string) {
+		fmt.Sscanf(text, "%d", &dbCfg.WriteRetries)
+	})
+	s.form.AddCheckbox(i18n.T("Только один экземпляр:"), dbCfg.SingleInstance, func(checked bool) {
+		dbCfg.SingleInstance = checked
+	})
+	s.form.AddInputField(i18n.T("Предупреждать при размере БД, МБ (0 - нет):"), fmt.Sprintf("%d", dbCfg.SizeWarningMB), 10, nil, func(text string) {
+		fmt.Sscanf(text, "%d", &dbCfg.SizeWarningMB)
+	})
+	s.form.AddInputField(i18n.T("Хранить в корзине, дней (0 - всегда):"), fmt.Sprintf("%d", trashCfg.RetentionDays), 10, nil, func(text string) {
+		fmt.Sscanf(text, "%d", &trashCfg.RetentionDays)
+	})
+
+	s.form.AddButton(i18n.T("Сохранить"), func() {
 		err := s.app.GetConfigManager().UpdateInterfaceSettings(
 			theme, fontSize, width, height, language,
 		)
 		if err != nil {
-			s.app.ShowModal("Ошибка", "Не удалось сохранить настройки: "+err.Error(), 50, 10, nil)
+			s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось сохранить настройки: ")+err.Error(), 50, 10, nil)
 			return
 		}
 
-		s.app.ShowModal("Успех", "Настройки сохранены!\nПерезапустите приложение для применения изменений.", 50, 10, nil)
+		if err := s.app.GetConfigManager().UpdateBackupSettings(backupCfg); err != nil {
+			s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось сохранить настройки копирования: ")+err.Error(), 50, 10, nil)
+			return
+		}
+
+		// PIN мог быть изменен отдельной формой, поэтому берем его из текущей конфигурации
+		securityCfg.PINHash = s.app.GetConfigManager().Get().Security.PINHash
+		if err := s.app.GetConfigManager().UpdateSecuritySettings(securityCfg); err != nil {
+			s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось сохранить настройки безопасности: ")+err.Error(), 50, 10, nil)
+			return
+		}
+
+		err = s.app.GetConfigManager().UpdateDatabaseAccess(
+			dbCfg.JournalMode, dbCfg.BusyTimeout, dbCfg.WriteRetries, dbCfg.SingleInstance,
+		)
+		if err != nil {
+			s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось сохранить параметры доступа к БД: ")+err.Error(), 50, 10, nil)
+			return
+		}
+
+		if err := s.app.GetConfigManager().UpdateSizeWarning(dbCfg.SizeWarningMB); err != nil {
+			s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось сохранить порог размера БД: ")+err.Error(), 50, 10, nil)
+			return
+		}
+
+		if err := s.app.GetConfigManager().UpdateTrashSettings(trashCfg); err != nil {
+			s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось сохранить настройки корзины: ")+err.Error(), 50, 10, nil)
+			return
+		}
+
+		// Тема, автоблокировка и копирование применяются сразу, доступ к
+		// БД настраивается при ее открытии
+		s.app.applyConfig()
+		message := i18n.T("Настройки сохранены и применены.")
+		if dbCfg.JournalMode != cfg.Database.JournalMode || dbCfg.BusyTimeout != cfg.Database.BusyTimeout ||
+			dbCfg.WriteRetries != cfg.Database.WriteRetries || dbCfg.SingleInstance != cfg.Database.SingleInstance {
+			message += i18n.T("\nПараметры доступа к БД вступят в силу после перезапуска.")
+		}
+		s.app.ShowModal(i18n.T("Успех"), message, 50, 10, nil)
 	})
 
-	s.form.AddButton("Сбросить", func() {
+	s.form.AddButton(i18n.T("Сбросить"), func() {
 		s.Refresh()
 	})
 
+	s.form.AddButton("PIN", func() {
+		s.changePIN()
+	})
+
+	s.form.AddButton(i18n.T("Проверка БД"), func() {
+		s.app.CheckIntegrity()
+	})
+
 	// Обновляем информацию о БД
 	s.updateDBInfo()
 }
@@ -98,104 +195,201 @@ func (s *SettingsScreen) updateDBInfo() {
 	db.QueryRow("SELECT COUNT(*) FROM employees").Scan(&employeesCount)
 	db.QueryRow("SELECT COUNT(*) FROM snippets").Scan(&snippetsCount)
 
-	info := fmt.Sprintf(
-		"\n[yellow]Путь к БД:[white]\n%s\n\n"+
-			"[yellow]Статистика:[white]\n\n"+
+	size := s.app.GetDBManager().FileSize()
+	sizeInfo := formatSize(size)
+	if warning := s.app.sizeWarning(size); warning != "" {
+		sizeInfo += i18n.Sprintf("\n[error]%s[-]\nСм. «Обслуживание БД» (%s)", warning, tview.Escape(s.app.key("main", "maintenance")))
+	}
+
+	info := i18n.Sprintf(
+		"\n[label]Путь к БД:[-]\n%s\n\n"+
+			"[label]Размер:[-] %s\n\n"+
+			"[label]Статистика:[-]\n\n"+
 			"  Проектов: %d\n"+
 			"  Сотрудников: %d\n"+
 			"  Сниппетов: %d\n\n"+
-			"[yellow]Горячие клавиши:[white]\n\n"+
-			"  [green]Ctrl+D[white] - Изменить пароль БД\n"+
-			"  [green]Ctrl+P[white] - Изменить путь к БД\n",
-		cfg.Database.Path,
+			"[label]Горячие клавиши:[-]\n\n",
+		cfg.Database.Path, sizeInfo,
 		projectsCount, employeesCount, snippetsCount,
-	)
+	) + s.app.keyHelp("settings", nil)
 
 	s.info.SetText(info)
 }
 
 func (s *SettingsScreen) GetView() tview.Primitive {
-	s.view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Key() == tcell.KeyCtrlD {
-			s.changePassword()
-			return nil
-		}
-		if event.Key() == tcell.KeyCtrlP {
-			s.changePath()
-			return nil
-		}
-		return event
-	})
-
 	return s.view
 }
 
 func (s *SettingsScreen) changePassword() {
 	form := tview.NewForm()
-	form.SetBorder(true).SetTitle(" Смена пароля БД ").SetTitleAlign(tview.AlignLeft)
+	policy := security.NewPolicy(s.app.GetConfigManager().Get().Security)
+	strength := tview.NewTextView().SetDynamicColors(true)
 
-	var newPassword, confirmPassword string
+	var currentPassword, newPassword, confirmPassword string
 
-	form.AddPasswordField("Новый пароль:", "", 30, '*', func(text string) {
+	form.AddPasswordField(s.app.secretLabel()+":", "", 30, '*', func(text string) {
+		currentPassword = text
+	})
+
+	form.AddPasswordField(i18n.T("Новый пароль:"), "", 30, '*', func(text string) {
 		newPassword = text
+		if text == "" {
+			strength.SetText("")
+			return
+		}
+		level := security.EstimateStrength(text)
+		strength.SetText(i18n.Sprintf("Надежность: [%s]%s[-]", level.Color(), level))
 	})
-	form.AddPasswordField("Подтвердите пароль:", "", 30, '*', func(text string) {
+	form.AddPasswordField(i18n.T("Подтвердите пароль:"), "", 30, '*', func(text string) {
 		confirmPassword = text
 	})
 
-	form.AddButton("Сменить", func() {
-		if newPassword == "" {
-			s.app.ShowModal("Ошибка", "Пароль не может быть пустым", 40, 8, nil)
+	form.AddButton(i18n.T("Сменить"), func() {
+		dbManager := s.app.GetDBManager()
+
+		if !s.app.VerifySecret(currentPassword) {
+			s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Неверный текущий пароль"), 40, 8, nil)
+			return
+		}
+
+		if err := policy.Validate(newPassword); err != nil {
+			s.app.ShowModal(i18n.T("Ошибка"), err.Error()+i18n.T("\n\nТребования: ")+policy.Describe(), 60, 12, nil)
 			return
 		}
 
 		if newPassword != confirmPassword {
-			s.app.ShowModal("Ошибка", "Пароли не совпадают", 40, 8, nil)
+			s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Пароли не совпадают"), 40, 8, nil)
 			return
 		}
 
-		s.app.ShowModal("Внимание", "Для смены пароля БД перезапустите приложение\nи используйте старый интерфейс (опция 3 в меню)", 60, 12, func() {
-			s.app.pages.RemovePage("password-form")
+		// Новый пароль сохраняется в конфигурации только после успешной
+		// проверки нового ключа; при любой ошибке БД остается со старым ключом
+		secrets := s.app.GetSecretStore()
+		err := dbManager.ChangePassword(newPassword, func() error {
+			return secrets.Save(newPassword)
 		})
+		if err != nil {
+			s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось сменить пароль: ")+err.Error(), 60, 12, nil)
+			return
+		}
+
+		message := i18n.T("Пароль БД успешно изменен!")
+		if !secrets.Persistent() {
+			message += i18n.T("\nЗапомните его: он потребуется при следующем запуске.")
+		}
+
+		s.app.pages.RemovePage("password-form")
+		s.app.ShowModal(i18n.T("Успех"), message, 50, 10, nil)
 	})
 
-	form.AddButton("Отмена", func() {
+	form.AddButton(i18n.T("Отмена"), func() {
 		s.app.pages.RemovePage("password-form")
 	})
 
-	s.app.pages.AddPage("password-form", center(form, 60, 12), true, true)
+	hint := tview.NewTextView().SetWordWrap(true).SetText(i18n.T("Требования: ") + policy.Describe())
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(form, 0, 1, true).
+		AddItem(strength, 1, 0, false).
+		AddItem(hint, 2, 0, false)
+	layout.SetBorder(true).SetTitle(i18n.T(" Смена пароля БД ")).SetTitleAlign(tview.AlignLeft)
+
+	s.app.pages.AddPage("password-form", center(layout, 70, 16), true, true)
+}
+
+// changePIN задает или удаляет PIN для снятия автоблокировки
+func (s *SettingsScreen) changePIN() {
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle(i18n.T(" PIN для разблокировки ")).SetTitleAlign(tview.AlignLeft)
+
+	var current, pin, confirmPIN string
+
+	form.AddPasswordField(s.app.secretLabel()+":", "", 30, '*', func(text string) {
+		current = text
+	})
+	form.AddPasswordField(i18n.T("Новый PIN (пусто - удалить):"), "", 12, '*', func(text string) {
+		pin = text
+	})
+	form.AddPasswordField(i18n.T("Повторите PIN:"), "", 12, '*', func(text string) {
+		confirmPIN = text
+	})
+
+	form.AddButton(i18n.T("Сохранить"), func() {
+		if !s.app.VerifySecret(current) {
+			s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Неверный текущий пароль"), 40, 8, nil)
+			return
+		}
+
+		if pin != confirmPIN {
+			s.app.ShowModal(i18n.T("Ошибка"), i18n.T("PIN не совпадают"), 40, 8, nil)
+			return
+		}
+
+		securityCfg := s.app.GetConfigManager().Get().Security
+		securityCfg.PINHash = ""
+		if pin != "" {
+			if err := security.ValidatePIN(pin); err != nil {
+				s.app.ShowModal(i18n.T("Ошибка"), err.Error(), 50, 8, nil)
+				return
+			}
+			hash, err := security.HashPIN(pin)
+			if err != nil {
+				s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось сохранить PIN: ")+err.Error(), 50, 10, nil)
+				return
+			}
+			securityCfg.PINHash = hash
+		}
+
+		if err := s.app.GetConfigManager().UpdateSecuritySettings(securityCfg); err != nil {
+			s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось сохранить PIN: ")+err.Error(), 50, 10, nil)
+			return
+		}
+
+		s.app.pages.RemovePage("pin-form")
+		if pin == "" {
+			s.app.ShowModal(i18n.T("Успех"), i18n.T("PIN удален"), 40, 8, nil)
+		} else {
+			s.app.ShowModal(i18n.T("Успех"), i18n.T("PIN установлен"), 40, 8, nil)
+		}
+	})
+
+	form.AddButton(i18n.T("Отмена"), func() {
+		s.app.pages.RemovePage("pin-form")
+	})
+
+	s.app.pages.AddPage("pin-form", center(form, 60, 13), true, true)
 }
 
 func (s *SettingsScreen) changePath() {
 	form := tview.NewForm()
-	form.SetBorder(true).SetTitle(" Изменение пути к БД ").SetTitleAlign(tview.AlignLeft)
+	form.SetBorder(true).SetTitle(i18n.T(" Изменение пути к БД ")).SetTitleAlign(tview.AlignLeft)
 
 	cfg := s.app.GetConfigManager().Get()
 	var newPath string
 	newPath = cfg.Database.Path
 
-	form.AddInputField("Путь к БД:", newPath, 60, nil, func(text string) {
+	form.AddInputField(i18n.T("Путь к БД:"), newPath, 60, nil, func(text string) {
 		newPath = text
 	})
 
-	form.AddButton("Сохранить", func() {
+	form.AddButton(i18n.T("Сохранить"), func() {
 		if newPath == "" {
-			s.app.ShowModal("Ошибка", "Путь не может быть пустым", 40, 8, nil)
+			s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Путь не может быть пустым"), 40, 8, nil)
 			return
 		}
 
 		err := s.app.GetConfigManager().UpdateDatabasePath(newPath)
 		if err != nil {
-			s.app.ShowModal("Ошибка", "Не удалось сохранить путь: "+err.Error(), 50, 10, nil)
+			s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось сохранить путь: ")+err.Error(), 50, 10, nil)
 			return
 		}
 
-		s.app.ShowModal("Успех", "Путь к БД обновлен!\nПерезапустите приложение.", 50, 10, func() {
+		s.app.ShowModal(i18n.T("Успех"), i18n.T("Путь к БД обновлен!\nПерезапустите приложение."), 50, 10, func() {
 			s.app.pages.RemovePage("path-form")
 		})
 	})
 
-	form.AddButton("Отмена", func() {
+	form.AddButton(i18n.T("Отмена"), func() {
 		s.app.pages.RemovePage("path-form")
 	})
 
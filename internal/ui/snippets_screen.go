@@ -1,11 +1,12 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
-	"time"
 
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/store"
 	"github.com/deldim-kam/Jotnal/pkg/models"
-	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
@@ -15,6 +16,7 @@ type SnippetsScreen struct {
 	view    *tview.Flex
 	list    *tview.List
 	preview *tview.TextView
+	ids     []int64 // ID сниппетов в порядке списка
 }
 
 // NewSnippetsScreen создает новый экран сниппетов
@@ -26,35 +28,24 @@ func NewSnippetsScreen(app *App) *SnippetsScreen {
 	}
 
 	s.list.SetBorder(true).
-		SetTitle(" Список сниппетов ").
+		SetTitle(i18n.T(" Список сниппетов ")).
 		SetTitleAlign(tview.AlignLeft)
 
-	s.preview.SetText("\n\n  Выберите сниппет для просмотра")
+	s.preview.SetText(i18n.T("\n\n  Выберите сниппет для просмотра"))
 	s.preview.SetBorder(true).
-		SetTitle(" Предпросмотр ").
+		SetTitle(i18n.T(" Предпросмотр ")).
 		SetTitleAlign(tview.AlignLeft)
 
 	s.view = tview.NewFlex().
 		AddItem(s.list, 0, 1, true).
 		AddItem(s.preview, 0, 2, false)
 
-	s.list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Rune() {
-		case 'a':
-			s.addSnippet()
-			return nil
-		case 'e':
-			s.editSnippet()
-			return nil
-		case 'd':
-			s.deleteSnippet()
-			return nil
-		case 'r':
-			s.Refresh()
-			return nil
-		}
-		return event
-	})
+	s.list.SetInputCapture(app.keyCapture("snippets", map[string]func(){
+		"add":     s.addSnippet,
+		"edit":    s.editSnippet,
+		"delete":  s.deleteSnippet,
+		"refresh": s.Refresh,
+	}))
 
 	s.list.SetChangedFunc(func(index int, mainText, secondaryText string, shortcut rune) {
 		s.showPreview(index)
@@ -64,46 +55,50 @@ func NewSnippetsScreen(app *App) *SnippetsScreen {
 }
 
 func (s *SnippetsScreen) Refresh() {
+	// Запоминаем выбранный сниппет, чтобы выбрать его снова после обновления
+	index := s.list.GetCurrentItem()
+	var selectedID int64
+	if index >= 0 && index < len(s.ids) {
+		selectedID = s.ids[index]
+	}
+
 	s.list.Clear()
+	s.ids = nil
 
 	snippets, err := s.loadSnippets()
 	if err != nil {
-		s.app.ShowModal("Ошибка", "Не удалось загрузить сниппеты: "+err.Error(), 50, 10, nil)
+		s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось загрузить сниппеты: ")+err.Error(), 50, 10, nil)
 		return
 	}
 
-	for _, snippet := range snippets {
+	for i, snippet := range snippets {
 		title := fmt.Sprintf("[%s] %s", snippet.Language, snippet.Title)
 		s.list.AddItem(title, truncate(snippet.Description, 50), 0, nil)
+		s.ids = append(s.ids, snippet.ID)
+		if snippet.ID == selectedID {
+			index = i
+		}
 	}
 
 	if len(snippets) > 0 {
-		s.showPreview(0)
+		index = min(max(index, 0), len(snippets)-1)
+		s.list.SetCurrentItem(index)
+		s.showPreview(index)
 	}
 }
 
-func (s *SnippetsScreen) loadSnippets() ([]models.Snippet, error) {
-	rows, err := s.app.GetDB().Query(
-		"SELECT id, title, description, language, code, tags, created_at, updated_at FROM snippets ORDER BY created_at DESC",
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var snippets []models.Snippet
-	for rows.Next() {
-		var snippet models.Snippet
-		err := rows.Scan(&snippet.ID, &snippet.Title, &snippet.Description,
-			&snippet.Language, &snippet.Code, &snippet.Tags,
-			&snippet.CreatedAt, &snippet.UpdatedAt)
-		if err != nil {
-			return nil, err
+// selectSnippet выбирает сниппет с ID id
+func (s *SnippetsScreen) selectSnippet(id int64) {
+	for i, snippetID := range s.ids {
+		if snippetID == id {
+			s.list.SetCurrentItem(i)
+			return
 		}
-		snippets = append(snippets, snippet)
 	}
+}
 
-	return snippets, nil
+func (s *SnippetsScreen) loadSnippets() ([]models.Snippet, error) {
+	return s.app.GetStore().ListSnippets()
 }
 
 func (s *SnippetsScreen) showPreview(index int) {
@@ -113,15 +108,15 @@ func (s *SnippetsScreen) showPreview(index int) {
 	}
 
 	snippet := snippets[index]
-	preview := fmt.Sprintf(
-		"\n[yellow]Название:[white] %s\n\n"+
-			"[yellow]Язык:[white] %s\n\n"+
-			"[yellow]Описание:[white] %s\n\n"+
-			"[yellow]Теги:[white] %s\n\n"+
-			"[yellow]Создан:[white] %s\n\n"+
-			"[yellow]Код:[white]\n\n%s",
+	preview := i18n.Sprintf(
+		"\n[label]Название:[-] %s\n\n"+
+			"[label]Язык:[-] %s\n\n"+
+			"[label]Описание:[-] %s\n\n"+
+			"[label]Теги:[-] %s\n\n"+
+			"[label]Создан:[-] %s\n\n"+
+			"[label]Код:[-]\n\n%s",
 		snippet.Title, snippet.Language, snippet.Description,
-		snippet.Tags, snippet.CreatedAt.Format("2006-01-02 15:04:05"),
+		snippet.Tags, i18n.Timestamp(snippet.CreatedAt),
 		snippet.Code,
 	)
 
@@ -130,48 +125,50 @@ func (s *SnippetsScreen) showPreview(index int) {
 
 func (s *SnippetsScreen) addSnippet() {
 	form := tview.NewForm()
-	form.SetBorder(true).SetTitle(" Новый сниппет ").SetTitleAlign(tview.AlignLeft)
+	form.SetBorder(true).SetTitle(i18n.T(" Новый сниппет ")).SetTitleAlign(tview.AlignLeft)
 
 	var title, description, language, code, tags string
 
-	form.AddInputField("Название:*", "", 50, nil, func(text string) {
+	form.AddInputField(i18n.T("Название:*"), "", 50, nil, func(text string) {
 		title = text
 	})
-	form.AddInputField("Язык:*", "go", 20, nil, func(text string) {
+	form.AddInputField(i18n.T("Язык:*"), "go", 20, nil, func(text string) {
 		language = text
 	})
-	form.AddInputField("Теги:", "", 50, nil, func(text string) {
+	form.AddInputField(i18n.T("Теги:"), "", 50, nil, func(text string) {
 		tags = text
 	})
-	form.AddTextArea("Описание:", "", 60, 2, 0, func(text string) {
+	form.AddTextArea(i18n.T("Описание:"), "", 60, 2, 0, func(text string) {
 		description = text
 	})
-	form.AddTextArea("Код:*", "", 60, 8, 0, func(text string) {
+	form.AddTextArea(i18n.T("Код:*"), "", 60, 8, 0, func(text string) {
 		code = text
 	})
 
-	form.AddButton("Сохранить", func() {
+	form.AddButton(i18n.T("Сохранить"), func() {
 		if title == "" || language == "" || code == "" {
-			s.app.ShowModal("Ошибка", "Название, язык и код обязательны", 50, 10, nil)
+			s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Название, язык и код обязательны"), 50, 10, nil)
 			return
 		}
 
-		_, err := s.app.GetDB().Exec(
-			`INSERT INTO snippets (title, description, language, code, tags, created_at, updated_at)
-			 VALUES (?, ?, ?, ?, ?, ?, ?)`,
-			title, description, language, code, tags, time.Now(), time.Now(),
-		)
+		_, err := s.app.GetStore().CreateSnippet(models.Snippet{
+			Title:       title,
+			Description: description,
+			Language:    language,
+			Code:        code,
+			Tags:        tags,
+		})
 		if err != nil {
-			s.app.ShowModal("Ошибка", "Не удалось создать сниппет: "+err.Error(), 50, 10, nil)
+			s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось создать сниппет: ")+err.Error(), 50, 10, nil)
 			return
 		}
 
 		s.app.pages.RemovePage("form")
 		s.Refresh()
-		s.app.ShowModal("Успех", "Сниппет успешно создан!", 40, 8, nil)
+		s.app.ShowModal(i18n.T("Успех"), i18n.T("Сниппет успешно создан!"), 40, 8, nil)
 	})
 
-	form.AddButton("Отмена", func() {
+	form.AddButton(i18n.T("Отмена"), func() {
 		s.app.pages.RemovePage("form")
 	})
 
@@ -186,53 +183,88 @@ func (s *SnippetsScreen) editSnippet() {
 	}
 
 	snippet := snippets[index]
+	s.showEditForm(snippet, snippet)
+}
 
+// snippetFields - редактируемые поля сниппета для разрешения конфликтов
+var snippetFields = []editField[models.Snippet]{
+	{"Название", func(sn *models.Snippet) *string { return &sn.Title }},
+	{"Язык", func(sn *models.Snippet) *string { return &sn.Language }},
+	{"Теги", func(sn *models.Snippet) *string { return &sn.Tags }},
+	{"Описание", func(sn *models.Snippet) *string { return &sn.Description }},
+	{"Код", func(sn *models.Snippet) *string { return &sn.Code }},
+}
+
+// showEditForm открывает форму редактирования: orig - загруженная версия сниппета,
+// values - значения полей формы (после объединения они отличаются от orig)
+func (s *SnippetsScreen) showEditForm(orig, values models.Snippet) {
 	form := tview.NewForm()
-	form.SetBorder(true).SetTitle(" Редактирование сниппета ").SetTitleAlign(tview.AlignLeft)
+	form.SetBorder(true).SetTitle(i18n.T(" Редактирование сниппета ")).SetTitleAlign(tview.AlignLeft)
 
 	var title, description, language, code, tags string
-	title, description, language = snippet.Title, snippet.Description, snippet.Language
-	code, tags = snippet.Code, snippet.Tags
+	title, description, language = values.Title, values.Description, values.Language
+	code, tags = values.Code, values.Tags
 
-	form.AddInputField("Название:*", title, 50, nil, func(text string) {
+	form.AddInputField(i18n.T("Название:*"), title, 50, nil, func(text string) {
 		title = text
 	})
-	form.AddInputField("Язык:*", language, 20, nil, func(text string) {
+	form.AddInputField(i18n.T("Язык:*"), language, 20, nil, func(text string) {
 		language = text
 	})
-	form.AddInputField("Теги:", tags, 50, nil, func(text string) {
+	form.AddInputField(i18n.T("Теги:"), tags, 50, nil, func(text string) {
 		tags = text
 	})
-	form.AddTextArea("Описание:", description, 60, 2, 0, func(text string) {
+	form.AddTextArea(i18n.T("Описание:"), description, 60, 2, 0, func(text string) {
 		description = text
 	})
-	form.AddTextArea("Код:*", code, 60, 8, 0, func(text string) {
+	form.AddTextArea(i18n.T("Код:*"), code, 60, 8, 0, func(text string) {
 		code = text
 	})
 
-	form.AddButton("Сохранить", func() {
-		_, err := s.app.GetDB().Exec(
-			`UPDATE snippets SET title = ?, description = ?, language = ?, code = ?, tags = ?, updated_at = ?
-			 WHERE id = ?`,
-			title, description, language, code, tags, time.Now(), snippet.ID,
-		)
-		if err != nil {
-			s.app.ShowModal("Ошибка", "Не удалось обновить сниппет: "+err.Error(), 50, 10, nil)
-			return
-		}
+	form.AddButton(i18n.T("Сохранить"), func() {
+		updated := orig
+		updated.Title, updated.Description, updated.Language = title, description, language
+		updated.Code, updated.Tags = code, tags
 
-		s.app.pages.RemovePage("form")
-		s.Refresh()
-		s.app.ShowModal("Успех", "Сниппет успешно обновлен!", 40, 8, nil)
+		s.saveSnippet(orig, updated)
 	})
 
-	form.AddButton("Отмена", func() {
+	form.AddButton(i18n.T("Отмена"), func() {
 		s.app.pages.RemovePage("form")
 	})
 
 	s.app.pages.AddPage("form", center(form, 80, 25), true, true)
 }
 
+// saveSnippet сохраняет изменения; если сниппет успели изменить, предлагает
+// перезаписать, объединить или отменить
+func (s *SnippetsScreen) saveSnippet(orig, updated models.Snippet) {
+	err := s.app.GetStore().UpdateSnippet(updated)
+
+	var conflict *store.ConflictError
+	if errors.As(err, &conflict) {
+		current := *conflict.Current.(*models.Snippet)
+		s.app.showConflict(conflict, collectConflict(snippetFields, orig, updated, current),
+			func() {
+				updated.Version = current.Version
+				s.saveSnippet(current, updated)
+			},
+			func() {
+				s.showEditForm(current, mergeEdits(snippetFields, orig, updated, current))
+			},
+		)
+		return
+	}
+	if err != nil {
+		s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось обновить сниппет: ")+err.Error(), 50, 10, nil)
+		return
+	}
+
+	s.app.pages.RemovePage("form")
+	s.Refresh()
+	s.app.ShowModal(i18n.T("Успех"), i18n.T("Сниппет успешно обновлен!"), 40, 8, nil)
+}
+
 func (s *SnippetsScreen) deleteSnippet() {
 	index := s.list.GetCurrentItem()
 	snippets, _ := s.loadSnippets()
@@ -243,16 +275,16 @@ func (s *SnippetsScreen) deleteSnippet() {
 	snippet := snippets[index]
 
 	s.app.ShowConfirm(
-		"Подтверждение удаления",
-		fmt.Sprintf("Вы уверены, что хотите удалить сниппет '%s'?", snippet.Title),
+		i18n.T("Подтверждение удаления"),
+		i18n.Sprintf("Переместить сниппет '%s' в корзину?", snippet.Title),
 		func() {
-			_, err := s.app.GetDB().Exec("DELETE FROM snippets WHERE id = ?", snippet.ID)
+			err := s.app.GetStore().DeleteSnippet(snippet.ID)
 			if err != nil {
-				s.app.ShowModal("Ошибка", "Не удалось удалить сниппет: "+err.Error(), 50, 10, nil)
+				s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось удалить сниппет: ")+err.Error(), 50, 10, nil)
 				return
 			}
 			s.Refresh()
-			s.app.ShowModal("Успех", "Сниппет удален!", 40, 8, nil)
+			s.app.ShowModal(i18n.T("Успех"), i18n.T("Сниппет перемещен в корзину"), 40, 8, nil)
 		},
 		nil,
 	)
@@ -0,0 +1,206 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/deldim-kam/Jotnal/internal/changeset"
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/store"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// resolutionNames - названия решений по конфликтам для отображения;
+// переводятся при выводе
+var resolutionNames = map[string]string{
+	"":                     "[error]не решен[-]",
+	store.ResolutionLocal:  "оставлено здесь",
+	store.ResolutionRemote: "принято входящее",
+}
+
+// SyncScreen экран синхронизации: узел этой БД, доверенные узлы и конфликты
+type SyncScreen struct {
+	app          *App
+	view         *tview.Flex
+	table        *tview.Table
+	info         *tview.TextView
+	conflicts    []store.SyncConflict
+	showResolved bool
+}
+
+// NewSyncScreen создает экран синхронизации
+func NewSyncScreen(app *App) *SyncScreen {
+	s := &SyncScreen{
+		app:   app,
+		table: tview.NewTable().SetBorders(false).SetSelectable(true, false).SetFixed(1, 0),
+		info:  tview.NewTextView().SetDynamicColors(true).SetWrap(true),
+	}
+
+	s.table.SetBorder(true).
+		SetTitle(i18n.T(" Конфликты ")).
+		SetTitleAlign(tview.AlignLeft)
+
+	s.info.SetBorder(true).
+		SetTitle(i18n.T(" Информация ")).
+		SetTitleAlign(tview.AlignLeft)
+
+	s.view = tview.NewFlex().
+		AddItem(s.table, 0, 3, true).
+		AddItem(s.info, 44, 0, false)
+
+	s.table.SetInputCapture(app.keyCapture("sync", map[string]func(){
+		"details":     s.showDetails,
+		"keep_local":  func() { s.resolve(false) },
+		"take_remote": func() { s.resolve(true) },
+		"filter": func() {
+			s.showResolved = !s.showResolved
+			s.Refresh()
+		},
+		"refresh": s.Refresh,
+	}))
+
+	return s
+}
+
+// setupTable настраивает заголовки таблицы
+func (s *SyncScreen) setupTable() {
+	headers := []string{i18n.T("Тип"), i18n.T("Запись"), i18n.T("Поле"), i18n.T("Здесь"), i18n.T("Входящее"), i18n.T("Узел"), i18n.T("Статус")}
+	for i, header := range headers {
+		cell := tview.NewTableCell(header).
+			SetTextColor(tview.Styles.SecondaryTextColor).
+			SetAlign(tview.AlignCenter).
+			SetSelectable(false).
+			SetAttributes(tcell.AttrBold)
+		s.table.SetCell(0, i, cell)
+	}
+}
+
+// Refresh обновляет состояние синхронизации и список конфликтов
+func (s *SyncScreen) Refresh() {
+	selection := saveSelection(s.table, s.rowKey)
+
+	s.table.Clear()
+	s.setupTable()
+
+	status, err := s.app.GetStore().SyncStatus()
+	if err != nil {
+		s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось загрузить состояние синхронизации: ")+err.Error(), 50, 10, nil)
+		return
+	}
+	s.info.SetText(s.statusText(status))
+
+	conflicts, err := s.app.GetStore().ListConflicts(s.showResolved)
+	if err != nil {
+		s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось загрузить конфликты: ")+err.Error(), 50, 10, nil)
+		return
+	}
+	s.conflicts = conflicts
+
+	for i, c := range conflicts {
+		row := i + 1
+		s.table.SetCell(row, 0, tview.NewTableCell(i18n.T(entityNames[c.Entity])))
+		s.table.SetCell(row, 1, tview.NewTableCell(tview.Escape(c.Title)).SetMaxWidth(24))
+		s.table.SetCell(row, 2, tview.NewTableCell(fieldName(c.Field)))
+		s.table.SetCell(row, 3, tview.NewTableCell(tview.Escape(c.Local)).SetMaxWidth(20))
+		s.table.SetCell(row, 4, tview.NewTableCell(tview.Escape(c.Remote)).SetMaxWidth(20))
+		s.table.SetCell(row, 5, tview.NewTableCell(tview.Escape(c.Peer)))
+		s.table.SetCell(row, 6, tview.NewTableCell(i18n.T(resolutionNames[c.Resolution])))
+	}
+
+	selection.restore(s.table, len(conflicts), s.rowKey)
+}
+
+// statusText форматирует сведения об узле и горячие клавиши
+func (s *SyncScreen) statusText(status *store.SyncStatus) string {
+	var text strings.Builder
+	i18n.Fprintf(&text, "\n  [label]Этот узел:[-] %s (%s)\n", tview.Escape(status.Node.Name), status.Node.ID)
+	i18n.Fprintf(&text, "  [label]Отпечаток:[-] %s\n", changeset.Fingerprint(status.Node.PublicKey()))
+	i18n.Fprintf(&text, "  [label]Стратегия:[-] %s\n", s.app.GetConfigManager().Get().Sync.Strategy)
+	i18n.Fprintf(&text, "  [label]Не отправлено:[-] %d\n", status.Pending)
+	i18n.Fprintf(&text, "  [label]Открытых конфликтов:[-] %d\n", status.OpenConflicts)
+
+	text.WriteString(i18n.T("\n  [label]Доверенные узлы:[-]\n"))
+	if len(status.Peers) == 0 {
+		text.WriteString(i18n.T("  нет\n"))
+	}
+	for _, p := range status.Peers {
+		last := i18n.T("не загружался")
+		if p.LastImport != nil {
+			last = i18n.DateTime(p.LastImport.Local())
+		}
+		i18n.Fprintf(&text, "  %s (%s)\n    %s\n    пакет: %s\n",
+			tview.Escape(p.Name), p.Node, changeset.Fingerprint(p.PublicKey), last)
+	}
+
+	filter := i18n.T("открытые")
+	if s.showResolved {
+		filter = i18n.T("все")
+	}
+	text.WriteString(i18n.T("\n  [label]Горячие клавиши:[-]\n\n"))
+	text.WriteString(s.app.keyHelp("sync", map[string]string{"filter": filter}))
+	text.WriteString(i18n.T("\n  Обмен пакетами: jotnal sync export\n  и jotnal sync import\n"))
+	return text.String()
+}
+
+// rowKey возвращает ID конфликта в строке таблицы
+func (s *SyncScreen) rowKey(row int) string {
+	if row < 1 || row > len(s.conflicts) {
+		return ""
+	}
+	return fmt.Sprintf("%d", s.conflicts[row-1].ID)
+}
+
+// selected возвращает выбранный конфликт
+func (s *SyncScreen) selected() (store.SyncConflict, bool) {
+	row, _ := s.table.GetSelection()
+	if row == 0 || row > len(s.conflicts) {
+		return store.SyncConflict{}, false
+	}
+	return s.conflicts[row-1], true
+}
+
+// showDetails показывает оба значения поля целиком
+func (s *SyncScreen) showDetails() {
+	c, ok := s.selected()
+	if !ok {
+		return
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "\n [label]%s:[-] %s\n", i18n.T(entityNames[c.Entity]), tview.Escape(c.Title))
+	i18n.Fprintf(&text, " [label]Поле:[-] %s\n", fieldName(c.Field))
+	i18n.Fprintf(&text, " [label]Статус:[-] %s\n", i18n.T(resolutionNames[c.Resolution]))
+	i18n.Fprintf(&text, "\n [label]Значение в этой БД:[-]\n%s\n", tview.Escape(c.Local))
+	i18n.Fprintf(&text, "\n [label]Входящее значение:[-] (%s, %s, %s)\n%s\n",
+		tview.Escape(c.Peer), tview.Escape(c.RemoteActor), i18n.Timestamp(c.RemoteTime.Local()),
+		tview.Escape(c.Remote))
+	s.app.showText(i18n.T("Конфликт синхронизации"), text.String(), 80, 22)
+}
+
+// resolve решает выбранный конфликт
+func (s *SyncScreen) resolve(takeRemote bool) {
+	c, ok := s.selected()
+	if !ok || c.Resolution != "" {
+		return
+	}
+
+	if err := s.app.GetStore().ResolveConflict(c.ID, takeRemote); err != nil {
+		s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось решить конфликт: ")+err.Error(), 50, 10, nil)
+		return
+	}
+	s.Refresh()
+}
+
+// fieldName возвращает название поля для отображения
+func fieldName(field string) string {
+	if name, ok := fieldNames[field]; ok {
+		return i18n.T(name)
+	}
+	return field
+}
+
+// GetView возвращает view экрана
+func (s *SyncScreen) GetView() tview.Primitive {
+	return s.view
+}
@@ -0,0 +1,260 @@
+package ui
+
+import (
+	"github.com/deldim-kam/Jotnal/internal/config"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// theme - палитра интерфейса: цвета tview и цвета, которых в tview.Theme нет
+type theme struct {
+	tview.Theme
+	Error tcell.Color
+	Muted tcell.Color
+}
+
+// builtinThemes - встроенные палитры; dark повторяет цвета tview по умолчанию
+var builtinThemes = map[string]theme{
+	config.ThemeDark: {
+		Theme: tview.Theme{
+			PrimitiveBackgroundColor:    tcell.ColorBlack,
+			ContrastBackgroundColor:     tcell.ColorBlue,
+			MoreContrastBackgroundColor: tcell.ColorGreen,
+			BorderColor:                 tcell.ColorWhite,
+			TitleColor:                  tcell.ColorWhite,
+			GraphicsColor:               tcell.ColorWhite,
+			PrimaryTextColor:            tcell.ColorWhite,
+			SecondaryTextColor:          tcell.ColorYellow,
+			TertiaryTextColor:           tcell.ColorGreen,
+			InverseTextColor:            tcell.ColorBlue,
+			ContrastSecondaryTextColor:  tcell.ColorNavy,
+		},
+		Error: tcell.ColorRed,
+		Muted: tcell.ColorGray,
+	},
+	config.ThemeLight: {
+		Theme: tview.Theme{
+			PrimitiveBackgroundColor:    tcell.ColorWhite,
+			ContrastBackgroundColor:     tcell.ColorLightGray,
+			MoreContrastBackgroundColor: tcell.ColorLightBlue,
+			BorderColor:                 tcell.ColorDimGray,
+			TitleColor:                  tcell.ColorBlack,
+			GraphicsColor:               tcell.ColorDimGray,
+			PrimaryTextColor:            tcell.ColorBlack,
+			SecondaryTextColor:          tcell.ColorDarkBlue,
+			TertiaryTextColor:           tcell.ColorDarkGreen,
+			InverseTextColor:            tcell.ColorWhite,
+			ContrastSecondaryTextColor:  tcell.ColorDarkSlateGray,
+		},
+		Error: tcell.ColorDarkRed,
+		Muted: tcell.ColorGray,
+	},
+	config.ThemeHighContrast: {
+		Theme: tview.Theme{
+			PrimitiveBackgroundColor:    tcell.ColorBlack,
+			ContrastBackgroundColor:     tcell.ColorNavy,
+			MoreContrastBackgroundColor: tcell.ColorPurple,
+			BorderColor:                 tcell.ColorWhite,
+			TitleColor:                  tcell.ColorYellow,
+			GraphicsColor:               tcell.ColorWhite,
+			PrimaryTextColor:            tcell.ColorWhite,
+			SecondaryTextColor:          tcell.ColorYellow,
+			TertiaryTextColor:           tcell.ColorAqua,
+			InverseTextColor:            tcell.ColorBlack,
+			ContrastSecondaryTextColor:  tcell.ColorYellow,
+		},
+		Error: tcell.ColorFuchsia,
+		Muted: tcell.ColorSilver,
+	},
+}
+
+// Имена цветовых тегов, которые используют тексты интерфейса: [label]Путь:[-].
+// Теги регистрируются в tcell.ColorNames и меняют цвет вместе с темой.
+const (
+	tagLabel  = "label"  // подписи и заголовки
+	tagAccent = "accent" // горячие клавиши и сообщения об успехе
+	tagError  = "error"
+	tagMuted  = "muted"
+)
+
+// resolveTheme возвращает палитру темы из настроек интерфейса: встроенную или
+// пользовательскую из interface.themes поверх ее базовой. Неизвестная тема
+// (конфигурация уже проверена, так что это не должно случаться) - dark.
+func resolveTheme(cfg config.InterfaceConfig) theme {
+	if t, ok := builtinThemes[cfg.Theme]; ok {
+		return t
+	}
+	custom, ok := cfg.Themes[cfg.Theme]
+	if !ok {
+		return builtinThemes[config.ThemeDark]
+	}
+
+	t := builtinThemes[config.ThemeDark]
+	if base, ok := builtinThemes[custom.Base]; ok {
+		t = base
+	}
+	for _, c := range []struct {
+		name  string
+		color *tcell.Color
+	}{
+		{custom.Background, &t.PrimitiveBackgroundColor},
+		{custom.ContrastBackground, &t.ContrastBackgroundColor},
+		{custom.MoreContrastBackground, &t.MoreContrastBackgroundColor},
+		{custom.Border, &t.BorderColor},
+		{custom.Title, &t.TitleColor},
+		{custom.Graphics, &t.GraphicsColor},
+		{custom.Text, &t.PrimaryTextColor},
+		{custom.Label, &t.SecondaryTextColor},
+		{custom.Accent, &t.TertiaryTextColor},
+		{custom.Error, &t.Error},
+		{custom.Muted, &t.Muted},
+		{custom.InverseText, &t.InverseTextColor},
+		{custom.ContrastLabel, &t.ContrastSecondaryTextColor},
+	} {
+		if c.name != "" {
+			*c.color = tcell.GetColor(c.name)
+		}
+	}
+	return t
+}
+
+// install делает палитру текущей: новые примитивы tview берут цвета из
+// tview.Styles, а цветовые теги - из tcell.ColorNames
+func (t theme) install() {
+	tview.Styles = t.Theme
+	tcell.ColorNames[tagLabel] = t.SecondaryTextColor
+	tcell.ColorNames[tagAccent] = t.TertiaryTextColor
+	tcell.ColorNames[tagError] = t.Error
+	tcell.ColorNames[tagMuted] = t.Muted
+}
+
+// applyTheme устанавливает тему из настроек и перекрашивает уже созданные
+// примитивы: tview копирует цвета из tview.Styles только при создании.
+// Должен вызываться из горутины UI.
+func (a *App) applyTheme(cfg config.InterfaceConfig) {
+	old := a.theme
+	a.theme = resolveTheme(cfg)
+	a.theme.install()
+	if old == a.theme {
+		return
+	}
+
+	r := restyler{old: old, new: a.theme, seen: make(map[tview.Primitive]bool)}
+	r.restyle(a.pages)
+	// Экраны, которые сейчас не открыты, и экран блокировки в дерево страниц не входят
+	for _, view := range []tview.Primitive{
+		a.projectsScreen.view, a.employeesScreen.view, a.snippetsScreen.view,
+		a.settingsScreen.view, a.auditScreen.view, a.trashScreen.view,
+		a.maintenance.view, a.syncScreen.view, a.lockScreen.view,
+	} {
+		r.restyle(view)
+	}
+}
+
+// restyler перекрашивает дерево примитивов из палитры old в new
+type restyler struct {
+	old, new theme
+	seen     map[tview.Primitive]bool
+}
+
+// restyle перекрашивает примитив и все вложенные в него
+func (r restyler) restyle(p tview.Primitive) {
+	if p == nil || r.seen[p] {
+		return
+	}
+	r.seen[p] = true
+	s := r.new.Theme
+
+	switch p := p.(type) {
+	case *tview.Pages:
+		r.box(p.Box)
+		for _, name := range p.GetPageNames(false) {
+			r.restyle(p.GetPage(name))
+		}
+	case *tview.Flex:
+		r.box(p.Box)
+		for i := 0; i < p.GetItemCount(); i++ {
+			r.restyle(p.GetItem(i))
+		}
+	case *tview.Form:
+		r.box(p.Box)
+		p.SetLabelColor(s.SecondaryTextColor).
+			SetFieldStyle(tcell.StyleDefault.Background(s.ContrastBackgroundColor).Foreground(s.PrimaryTextColor)).
+			SetButtonStyle(tcell.StyleDefault.Background(s.ContrastBackgroundColor).Foreground(s.PrimaryTextColor)).
+			SetButtonActivatedStyle(tcell.StyleDefault.Background(s.PrimaryTextColor).Foreground(s.ContrastBackgroundColor)).
+			SetButtonDisabledStyle(tcell.StyleDefault.Background(s.ContrastBackgroundColor).Foreground(s.ContrastSecondaryTextColor))
+		// Цвета полей форма задает при каждой отрисовке, но фон самих полей - нет
+		for i := 0; i < p.GetFormItemCount(); i++ {
+			r.restyle(p.GetFormItem(i))
+		}
+		for i := 0; i < p.GetButtonCount(); i++ {
+			r.restyle(p.GetButton(i))
+		}
+	case *tview.InputField:
+		r.box(p.Box)
+	case *tview.TextArea:
+		r.box(p.Box)
+	case *tview.Checkbox:
+		r.box(p.Box)
+	case *tview.Button:
+		r.box(p.Box)
+	case *tview.List:
+		r.box(p.Box)
+		p.SetMainTextStyle(tcell.StyleDefault.Foreground(s.PrimaryTextColor).Background(s.PrimitiveBackgroundColor)).
+			SetSecondaryTextStyle(tcell.StyleDefault.Foreground(s.TertiaryTextColor).Background(s.PrimitiveBackgroundColor)).
+			SetShortcutStyle(tcell.StyleDefault.Foreground(s.SecondaryTextColor).Background(s.PrimitiveBackgroundColor)).
+			SetSelectedStyle(tcell.StyleDefault.Foreground(s.PrimitiveBackgroundColor).Background(s.PrimaryTextColor))
+	case *tview.Modal:
+		r.box(p.Box)
+		p.Box.SetBackgroundColor(s.ContrastBackgroundColor)
+		p.SetBackgroundColor(s.ContrastBackgroundColor).
+			SetTextColor(s.PrimaryTextColor).
+			SetButtonStyle(tcell.StyleDefault.Background(s.PrimitiveBackgroundColor).Foreground(s.PrimaryTextColor)).
+			SetButtonActivatedStyle(tcell.StyleDefault.Background(s.PrimaryTextColor).Foreground(s.ContrastBackgroundColor))
+	case *tview.Table:
+		r.box(p.Box)
+		p.SetBordersColor(s.GraphicsColor)
+		r.cells(p)
+	case *tview.TextView:
+		r.box(p.Box)
+		// SetTextColor заново разбирает текст, и теги получают цвета новой темы
+		p.SetBackgroundColor(s.PrimitiveBackgroundColor)
+		p.SetTextColor(s.PrimaryTextColor)
+	case *tview.Box:
+		r.box(p)
+	}
+}
+
+// box перекрашивает фон, рамку и заголовок
+func (r restyler) box(b *tview.Box) {
+	b.SetBackgroundColor(r.new.PrimitiveBackgroundColor).
+		SetBorderColor(r.new.BorderColor).
+		SetTitleColor(r.new.TitleColor)
+}
+
+// cells перекрашивает ячейки таблицы: цвет текста, совпадающий с одним из
+// цветов старой палитры, заменяется соответствующим цветом новой
+func (r restyler) cells(t *tview.Table) {
+	colors := map[tcell.Color]tcell.Color{}
+	for _, c := range [][2]tcell.Color{
+		{r.old.PrimaryTextColor, r.new.PrimaryTextColor},
+		{r.old.SecondaryTextColor, r.new.SecondaryTextColor},
+		{r.old.TertiaryTextColor, r.new.TertiaryTextColor},
+		{r.old.Error, r.new.Error},
+		{r.old.Muted, r.new.Muted},
+	} {
+		if _, ok := colors[c[0]]; !ok {
+			colors[c[0]] = c[1]
+		}
+	}
+
+	for row := 0; row < t.GetRowCount(); row++ {
+		for column := 0; column < t.GetColumnCount(); column++ {
+			cell := t.GetCell(row, column)
+			fg, _, _ := cell.Style.Decompose()
+			if c, ok := colors[fg]; ok {
+				cell.SetTextColor(c)
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/deldim-kam/Jotnal/internal/i18n"
+	"github.com/deldim-kam/Jotnal/internal/store"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// TrashScreen экран корзины с удаленными записями всех типов
+type TrashScreen struct {
+	app   *App
+	view  *tview.Flex
+	table *tview.Table
+	info  *tview.TextView
+	items []store.TrashItem
+}
+
+// NewTrashScreen создает экран корзины
+func NewTrashScreen(app *App) *TrashScreen {
+	s := &TrashScreen{
+		app:   app,
+		table: tview.NewTable().SetBorders(false).SetSelectable(true, false).SetFixed(1, 0),
+		info:  tview.NewTextView().SetDynamicColors(true),
+	}
+
+	s.table.SetBorder(true).
+		SetTitle(i18n.T(" Удаленные записи ")).
+		SetTitleAlign(tview.AlignLeft)
+
+	s.info.SetBorder(true).
+		SetTitle(i18n.T(" Информация ")).
+		SetTitleAlign(tview.AlignLeft)
+
+	s.view = tview.NewFlex().
+		AddItem(s.table, 0, 3, true).
+		AddItem(s.info, 40, 0, false)
+
+	s.table.SetInputCapture(app.keyCapture("trash", map[string]func(){
+		"restore": s.restoreItem,
+		"purge":   s.purgeItem,
+		"history": s.showHistory,
+		"refresh": s.Refresh,
+	}))
+
+	return s
+}
+
+// setupTable настраивает заголовки таблицы
+func (s *TrashScreen) setupTable() {
+	headers := []string{i18n.T("Тип"), "ID", i18n.T("Название"), i18n.T("Удалено")}
+	for i, header := range headers {
+		cell := tview.NewTableCell(header).
+			SetTextColor(tview.Styles.SecondaryTextColor).
+			SetAlign(tview.AlignCenter).
+			SetSelectable(false).
+			SetAttributes(tcell.AttrBold)
+		s.table.SetCell(0, i, cell)
+	}
+}
+
+// Refresh обновляет содержимое корзины
+func (s *TrashScreen) Refresh() {
+	selection := saveSelection(s.table, s.rowKey)
+
+	s.table.Clear()
+	s.setupTable()
+
+	retention := i18n.T("бессрочно")
+	if days := s.app.GetConfigManager().Get().Trash.RetentionDays; days > 0 {
+		retention = i18n.Plural(days, "%d дн.", days)
+	}
+	s.info.SetText(i18n.T("\n  [label]Горячие клавиши:[-]\n\n") + s.app.keyHelp("trash", nil) +
+		i18n.Sprintf("\n  [label]Срок хранения:[-] %s\n", retention))
+
+	items, err := s.app.GetStore().ListTrash()
+	if err != nil {
+		s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось загрузить корзину: ")+err.Error(), 50, 10, nil)
+		return
+	}
+	s.items = items
+
+	for i, item := range items {
+		row := i + 1
+		s.table.SetCell(row, 0, tview.NewTableCell(i18n.T(entityNames[item.Entity])))
+		s.table.SetCell(row, 1, tview.NewTableCell(fmt.Sprintf("%d", item.ID)).SetAlign(tview.AlignCenter))
+		s.table.SetCell(row, 2, tview.NewTableCell(item.Title))
+		s.table.SetCell(row, 3, tview.NewTableCell(i18n.DateTime(item.DeletedAt.Local())))
+	}
+
+	selection.restore(s.table, len(items), s.rowKey)
+}
+
+// rowKey возвращает тип и ID записи в строке таблицы
+func (s *TrashScreen) rowKey(row int) string {
+	if row < 1 || row > len(s.items) {
+		return ""
+	}
+	item := s.items[row-1]
+	return fmt.Sprintf("%s:%d", item.Entity, item.ID)
+}
+
+// selected возвращает выбранную запись корзины
+func (s *TrashScreen) selected() (store.TrashItem, bool) {
+	row, _ := s.table.GetSelection()
+	if row == 0 || row > len(s.items) {
+		return store.TrashItem{}, false
+	}
+	return s.items[row-1], true
+}
+
+// restoreItem восстанавливает выбранную запись
+func (s *TrashScreen) restoreItem() {
+	item, ok := s.selected()
+	if !ok {
+		return
+	}
+
+	if err := s.app.GetStore().Restore(item.Entity, item.ID); err != nil {
+		s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось восстановить запись: ")+err.Error(), 50, 10, nil)
+		return
+	}
+
+	s.Refresh()
+	s.app.ShowModal(i18n.T("Успех"), i18n.Sprintf("'%s' восстановлен(а)", item.Title), 40, 8, nil)
+}
+
+// purgeItem окончательно удаляет выбранную запись после подтверждения
+func (s *TrashScreen) purgeItem() {
+	item, ok := s.selected()
+	if !ok {
+		return
+	}
+
+	message := i18n.Sprintf("Удалить '%s' навсегда? Восстановить запись будет невозможно.", item.Title)
+	if item.Entity == store.EntityProject {
+		message = i18n.Sprintf("Удалить проект '%s' навсегда вместе с файлами, историей и закладками?", item.Title)
+	}
+
+	s.app.ShowConfirm(
+		i18n.T("Окончательное удаление"),
+		message,
+		func() {
+			if err := s.app.GetStore().Purge(item.Entity, item.ID); err != nil {
+				s.app.ShowModal(i18n.T("Ошибка"), i18n.T("Не удалось удалить запись: ")+err.Error(), 50, 10, nil)
+				return
+			}
+			s.Refresh()
+		},
+		nil,
+	)
+}
+
+// showHistory показывает историю изменений выбранной записи
+func (s *TrashScreen) showHistory() {
+	if item, ok := s.selected(); ok {
+		s.app.ShowHistory(item.Entity, item.ID, i18n.T("История: ")+item.Title)
+	}
+}
+
+// GetView возвращает view экрана
+func (s *TrashScreen) GetView() tview.Primitive {
+	return s.view
+}
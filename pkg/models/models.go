@@ -4,12 +4,14 @@ import "time"
 
 // Project представляет проект в IDE
 type Project struct {
-	ID          int64     `json:"id"`
-	Name        string    `json:"name"`
-	Path        string    `json:"path"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Path        string     `json:"path"`
+	Description string     `json:"description"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at"` // не NULL - проект в корзине
+	Version     int64      `json:"version"`
 }
 
 // File представляет файл в проекте
@@ -48,14 +50,16 @@ type FileHistory struct {
 
 // Snippet представляет сниппет кода
 type Snippet struct {
-	ID          int64     `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Language    string    `json:"language"`
-	Code        string    `json:"code"`
-	Tags        string    `json:"tags"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int64      `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Language    string     `json:"language"`
+	Code        string     `json:"code"`
+	Tags        string     `json:"tags"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at"` // не NULL - сниппет в корзине
+	Version     int64      `json:"version"`
 }
 
 // Bookmark представляет закладку в файле
@@ -69,16 +73,49 @@ type Bookmark struct {
 
 // Employee представляет сотрудника с иерархической структурой
 type Employee struct {
-	ID         int64     `json:"id"`
-	FirstName  string    `json:"first_name"`
-	LastName   string    `json:"last_name"`
-	MiddleName string    `json:"middle_name"`
-	Email      string    `json:"email"`
-	Position   string    `json:"position"`
-	Department string    `json:"department"`
-	ManagerID  *int64    `json:"manager_id"` // NULL для главного руководителя
-	Phone      string    `json:"phone"`
-	HireDate   time.Time `json:"hire_date"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID         int64      `json:"id"`
+	FirstName  string     `json:"first_name"`
+	LastName   string     `json:"last_name"`
+	MiddleName string     `json:"middle_name"`
+	Email      string     `json:"email"`
+	Position   string     `json:"position"`
+	Department string     `json:"department"`
+	ManagerID  *int64     `json:"manager_id"` // NULL для главного руководителя
+	Phone      string     `json:"phone"`
+	HireDate   time.Time  `json:"hire_date"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	DeletedAt  *time.Time `json:"deleted_at"` // не NULL - сотрудник в корзине
+	Version    int64      `json:"version"`
+}
+
+// AuditEntry представляет запись журнала изменений
+type AuditEntry struct {
+	ID        int64     `json:"id"`
+	Entity    string    `json:"entity"`
+	EntityID  int64     `json:"entity_id"`
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor"`
+	Before    string    `json:"before"` // JSON записи до изменения, пусто при создании
+	After     string    `json:"after"`  // JSON записи после изменения, пусто при удалении
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Shift представляет смену; одновременно открыта может быть только одна
+type Shift struct {
+	ID       int64      `json:"id"`
+	OpenedBy string     `json:"opened_by"`
+	OpenedAt time.Time  `json:"opened_at"`
+	ClosedBy string     `json:"closed_by"`
+	ClosedAt *time.Time `json:"closed_at"` // NULL - смена открыта
+}
+
+// ShiftEntry представляет запись в журнале смены
+type ShiftEntry struct {
+	ID        int64     `json:"id"`
+	ShiftID   int64     `json:"shift_id"`
+	Severity  string    `json:"severity"` // info, warn или error
+	Message   string    `json:"message"`
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
 }